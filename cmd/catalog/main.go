@@ -0,0 +1,245 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/BullionBear/seq/internal/config"
+	"github.com/BullionBear/seq/internal/db"
+	pms "github.com/BullionBear/seq/internal/srv/catalog"
+	"github.com/BullionBear/seq/pkg/logger"
+	"gorm.io/gorm"
+)
+
+const usage = `Usage: %s -c <config-file> <command> [flags]
+
+Commands:
+  sync    Sync instruments from an exchange's metadata endpoint
+  import  Import instruments and currencies from CSV, JSON or YAML files
+  export  Export instruments and currencies to CSV, JSON or YAML
+`
+
+func main() {
+	// Parse command-line flags
+	configPath := flag.String("c", "", "Path to configuration file")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, usage, os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	// Determine config path: flag takes precedence over environment variable
+	if *configPath == "" {
+		*configPath = os.Getenv("CONFIG")
+	}
+
+	// Exit if no config path or command provided
+	if *configPath == "" || flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	// Load configuration
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to load configuration from %s: %v\n", *configPath, err)
+		os.Exit(1)
+	}
+
+	// Initialize logger (minimal for command-line tools)
+	if err := logger.Init(logger.Options{Level: "info", Output: "stdout"}); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to initialize logger: %v\n", err)
+		os.Exit(1)
+	}
+	log := logger.Get()
+
+	database, err := db.ConnectPostgres(cfg.PMS.Database)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to connect to PostgreSQL database")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	command, args := flag.Arg(0), flag.Args()[1:]
+	switch command {
+	case "sync":
+		err = runSync(ctx, database, args)
+	case "import":
+		err = runImport(ctx, database, args)
+	case "export":
+		err = runExport(ctx, database, args)
+	default:
+		fmt.Fprintf(os.Stderr, "Error: Unknown command %q\n", command)
+		flag.Usage()
+		os.Exit(1)
+	}
+	if err != nil {
+		log.Fatal().Err(err).Str("command", command).Msg("Command failed")
+	}
+}
+
+// runSync diffs the instruments of one exchange against its metadata
+// endpoint, prints the diff and applies it unless -dry-run is set.
+func runSync(ctx context.Context, database *gorm.DB, args []string) error {
+	flags := flag.NewFlagSet("sync", flag.ExitOnError)
+	exchange := flags.String("exchange", "", "Exchange to sync (binance, okx)")
+	baseURL := flags.String("url", "", "Override the exchange REST base URL")
+	dryRun := flags.Bool("dry-run", false, "Print the diff without writing it")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	source, err := pms.NewMetadataSource(*exchange, *baseURL, nil)
+	if err != nil {
+		return err
+	}
+	currencies, err := pms.NewCurrencyRegistry(database)
+	if err != nil {
+		return err
+	}
+	syncer := pms.NewInstrumentSyncer(database, source, currencies)
+	plan, err := syncer.Plan(ctx)
+	if err != nil {
+		return err
+	}
+	if err := plan.Print(os.Stdout); err != nil {
+		return err
+	}
+	if *dryRun || len(plan.Changes) == 0 {
+		return nil
+	}
+	if err := syncer.Apply(ctx, plan); err != nil {
+		return err
+	}
+
+	added, updated, removed := plan.Counts()
+	log := logger.Get()
+	log.Info().
+		Str("exchange", plan.Exchange).
+		Int("added", added).
+		Int("updated", updated).
+		Int("deactivated", removed).
+		Int("skipped", len(plan.Skipped)).
+		Msg("Instruments synced")
+	return nil
+}
+
+// runImport reads every file argument and imports their combined contents
+// in a single transaction, so a bad row in any file rejects all of them.
+func runImport(ctx context.Context, database *gorm.DB, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	format := flags.String("format", "", "File format (csv, json, yaml); defaults to the file extension")
+	mode := flags.String("mode", "insert", "insert fails on existing rows, upsert replaces them")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return fmt.Errorf("no files to import")
+	}
+	var importMode pms.ImportMode
+	switch *mode {
+	case "insert":
+		importMode = pms.ImportInsertOnly
+	case "upsert":
+		importMode = pms.ImportUpsert
+	default:
+		return fmt.Errorf("unknown import mode %q", *mode)
+	}
+
+	var data pms.CatalogData
+	for _, path := range flags.Args() {
+		fileData, err := readCatalogFile(path, formatFlag(*format))
+		if err != nil {
+			return err
+		}
+		data.Currencies = append(data.Currencies, fileData.Currencies...)
+		data.Instruments = append(data.Instruments, fileData.Instruments...)
+	}
+	if err := pms.ImportCatalog(ctx, database, data, importMode); err != nil {
+		return err
+	}
+
+	log := logger.Get()
+	log.Info().
+		Int("currencies", len(data.Currencies)).
+		Int("instruments", len(data.Instruments)).
+		Str("mode", *mode).
+		Msg("Catalog imported")
+	return nil
+}
+
+// formatFlag normalizes a -format value, so CSV and csv are the same.
+func formatFlag(value string) pms.Format {
+	return pms.Format(strings.ToLower(strings.TrimSpace(value)))
+}
+
+func readCatalogFile(path string, format pms.Format) (pms.CatalogData, error) {
+	if format == "" {
+		var err error
+		if format, err = pms.FormatFromPath(path); err != nil {
+			return pms.CatalogData{}, err
+		}
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return pms.CatalogData{}, err
+	}
+	defer file.Close()
+	data, err := pms.DecodeCatalogData(file, format)
+	if err != nil {
+		return pms.CatalogData{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return data, nil
+}
+
+// runExport writes the whole catalog to -o, or stdout. CSV holds a single
+// table, selected with -kind.
+func runExport(ctx context.Context, database *gorm.DB, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	format := flags.String("format", "", "Output format (csv, json, yaml); defaults to the -o extension, else yaml")
+	kind := flags.String("kind", "all", "Tables to export (all, instruments, currencies); CSV needs one table")
+	output := flags.String("o", "", "Output file; defaults to stdout")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	outputFormat := formatFlag(*format)
+	if outputFormat == "" {
+		outputFormat = pms.FormatYAML
+		if *output != "" {
+			var err error
+			if outputFormat, err = pms.FormatFromPath(*output); err != nil {
+				return err
+			}
+		}
+	}
+
+	data, err := pms.ExportCatalog(ctx, database)
+	if err != nil {
+		return err
+	}
+	switch *kind {
+	case "all":
+	case "instruments":
+		data.Currencies = nil
+	case "currencies":
+		data.Instruments = nil
+	default:
+		return fmt.Errorf("unknown kind %q", *kind)
+	}
+
+	w := os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	return pms.EncodeCatalogData(w, outputFormat, data)
+}
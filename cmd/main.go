@@ -1,14 +1,18 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/BullionBear/seq/env"
 	"github.com/BullionBear/seq/internal/config"
 	"github.com/BullionBear/seq/internal/db"
 	pms "github.com/BullionBear/seq/internal/srv/catalog"
+	"github.com/BullionBear/seq/internal/srv/sms"
 	"github.com/BullionBear/seq/pkg/logger"
 )
 
@@ -43,6 +47,7 @@ func main() {
 		Path:           cfg.Logger.Path,
 		MaxByteSize:    cfg.Logger.MaxByteSize,
 		MaxBackupFiles: cfg.Logger.MaxBackupFiles,
+		RedactPatterns: cfg.Logger.RedactPatterns,
 	}
 	if err := logger.Init(loggerOpts); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: Failed to initialize logger: %v\n", err)
@@ -70,5 +75,29 @@ func main() {
 	}
 	_ = pmsService // TODO: Use PMS service as needed
 
+	// Initialize currency registry
+	currencyRegistry, err := pms.NewCurrencyRegistry(db)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to initialize currency registry")
+	}
+	_ = currencyRegistry // TODO: Use currency registry as needed
+
 	log.Info().Msg("PMS service initialized successfully")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Initialize SMS service, checking every API key with its venue before
+	// use and again every key_check.interval_minutes
+	secretManager, err := sms.NewSecretManagerFromConfig(cfg.SMS, db)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to initialize SMS service")
+	}
+	secretManager.StartKeyChecks(ctx, sms.KeyCheckInterval(cfg.SMS), func(err error) {
+		log.Error().Err(err).Msg("API key check failed")
+	})
+	log.Info().Msg("SMS service initialized successfully")
+
+	<-ctx.Done()
+	log.Info().Msg("Stopping Seq...")
 }
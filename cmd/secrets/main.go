@@ -0,0 +1,285 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/BullionBear/seq/internal/config"
+	"github.com/BullionBear/seq/internal/db"
+	"github.com/BullionBear/seq/internal/srv/sms"
+	"github.com/BullionBear/seq/pkg/logger"
+	"gorm.io/gorm"
+)
+
+const usage = `Usage: %s -c <config-file> <command> [flags]
+
+Commands:
+  rotate             Re-wrap every data key, or re-seal the secrets file, with the primary master key
+  encrypt-plaintext  Encrypt secrets still stored in plaintext
+  access-log         Print who accessed the credentials of an account
+  check-keys         Re-check the permissions of every API key with its venue
+  seal-file          Encrypt a plaintext YAML list of secrets into the file backend
+  generate-key       Print a new random base64-encoded master key
+`
+
+func main() {
+	// Parse command-line flags
+	configPath := flag.String("c", "", "Path to configuration file")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, usage, os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	// generate-key needs neither configuration nor database
+	if flag.Arg(0) == "generate-key" {
+		key, err := sms.GenerateKey()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(key)
+		return
+	}
+
+	// Determine config path: flag takes precedence over environment variable
+	if *configPath == "" {
+		*configPath = os.Getenv("CONFIG")
+	}
+
+	// Exit if no config path or command provided
+	if *configPath == "" || flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	// Load configuration
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to load configuration from %s: %v\n", *configPath, err)
+		os.Exit(1)
+	}
+
+	// Initialize logger (minimal for command-line tools)
+	if err := logger.Init(logger.Options{Level: "info", Output: "stdout"}); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to initialize logger: %v\n", err)
+		os.Exit(1)
+	}
+	log := logger.Get()
+
+	ring, err := sms.NewKeyRingFromConfig(cfg.SMS)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load master keys")
+	}
+
+	// seal-file writes the file backend and needs no database, nor does
+	// rotate for any backend but postgres
+	if flag.Arg(0) == "seal-file" {
+		if err := runSealFile(cfg.SMS, ring, flag.Args()[1:]); err != nil {
+			log.Fatal().Err(err).Str("command", "seal-file").Msg("Command failed")
+		}
+		return
+	}
+	if backend := cfg.SMS.Backend.Type; flag.Arg(0) == "rotate" && backend != "" && backend != "postgres" {
+		if err := runRotateFile(cfg.SMS, ring); err != nil {
+			log.Fatal().Err(err).Str("command", "rotate").Msg("Command failed")
+		}
+		return
+	}
+
+	database, err := db.ConnectPostgres(cfg.PMS.Database)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to connect to PostgreSQL database")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	command, args := flag.Arg(0), flag.Args()[1:]
+	switch command {
+	case "rotate":
+		err = runRotate(ctx, database, ring, args)
+	case "encrypt-plaintext":
+		err = runEncryptPlaintext(database, ring)
+	case "access-log":
+		err = runAccessLog(ctx, database, args)
+	case "check-keys":
+		err = runCheckKeys(ctx, cfg.SMS, database)
+	default:
+		fmt.Fprintf(os.Stderr, "Error: Unknown command %q\n", command)
+		flag.Usage()
+		os.Exit(1)
+	}
+	if err != nil {
+		log.Fatal().Err(err).Str("command", command).Msg("Command failed")
+	}
+}
+
+// runRotate re-wraps the data keys of every secret not yet wrapped with the
+// primary master key. Run it after every service has been deployed with the
+// new key ring, and remove the old key from previous_keys afterwards.
+func runRotate(ctx context.Context, database *gorm.DB, ring *sms.KeyRing, args []string) error {
+	flags := flag.NewFlagSet("rotate", flag.ExitOnError)
+	rotatedBy := flags.String("by", os.Getenv("USER"), "Operator recorded in the rotation audit log")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *rotatedBy == "" {
+		return fmt.Errorf("-by is required when USER is not set")
+	}
+
+	rotation, err := sms.RotateMasterKey(ctx, database, ring, *rotatedBy)
+	if err != nil {
+		return err
+	}
+	log := logger.Get()
+	log.Info().
+		Int64("rotation_id", rotation.RotationID).
+		Int("key_version", rotation.KeyVersion).
+		Int("rewrapped", rotation.Rewrapped).
+		Str("rotated_by", rotation.RotatedBy).
+		Msg("Master key rotated")
+	return nil
+}
+
+// runRotateFile re-seals the secrets file under the primary master key, so
+// the old key can be removed from previous_keys afterwards. The env backend
+// is not encrypted and has nothing to rotate.
+func runRotateFile(cfg config.ConfigSMS, ring *sms.KeyRing) error {
+	if cfg.Backend.Type != "file" {
+		return fmt.Errorf("rotate does not support the %s secret backend, only postgres and file", cfg.Backend.Type)
+	}
+	if cfg.Backend.Path == "" {
+		return fmt.Errorf("sms.backend.path is not configured")
+	}
+	previous, err := sms.NewFileBackend(cfg.Backend.Path, ring).Reseal()
+	if err != nil {
+		return err
+	}
+	log := logger.Get()
+	log.Info().
+		Int("key_version", ring.Primary().Version()).
+		Int("previous_version", previous).
+		Str("path", cfg.Backend.Path).
+		Msg("Secrets file re-sealed")
+	return nil
+}
+
+func runEncryptPlaintext(database *gorm.DB, ring *sms.KeyRing) error {
+	secretManager, err := sms.NewSecretManager(database, ring)
+	if err != nil {
+		return err
+	}
+	encrypted, err := secretManager.EncryptPlaintextSecrets()
+	if err != nil {
+		return err
+	}
+	log := logger.Get()
+	log.Info().Int("encrypted", encrypted).Msg("Plaintext secrets encrypted")
+	return nil
+}
+
+// runAccessLog prints the access history of an account.
+func runAccessLog(ctx context.Context, database *gorm.DB, args []string) error {
+	flags := flag.NewFlagSet("access-log", flag.ExitOnError)
+	acctID := flags.Int("acct", 0, "Account to print the access history of")
+	since := flags.Duration("since", 24*time.Hour, "How far back to look")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *acctID == 0 {
+		return fmt.Errorf("-acct is required")
+	}
+
+	records, err := sms.QueryAccessHistory(ctx, database, *acctID, time.Now().Add(-*since))
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		outcome := "ok"
+		if record.Denied {
+			outcome = "denied"
+		} else if record.Err != "" {
+			outcome = "error: " + record.Err
+		}
+		fmt.Printf("%s\t%s\t%s\t%s\n", record.AccessedAt.Format(time.RFC3339), record.Service, record.Action, outcome)
+	}
+	return nil
+}
+
+// runCheckKeys re-checks the API keys of the exchanges in sms.key_check,
+// disabling any key found able to withdraw, and prints the outcome.
+func runCheckKeys(ctx context.Context, cfg config.ConfigSMS, database *gorm.DB) error {
+	checkers, err := sms.NewKeyCheckersFromConfig(cfg, nil)
+	if err != nil {
+		return err
+	}
+	if len(checkers) == 0 {
+		return fmt.Errorf("sms.key_check.exchanges is not configured")
+	}
+	backend, err := sms.NewBackendFromConfig(cfg, database)
+	if err != nil {
+		return err
+	}
+	secretManager, err := sms.NewSecretManagerWithBackend(backend)
+	if err != nil {
+		return err
+	}
+	secretManager.SetKeyCheckers(checkers...)
+
+	failed := 0
+	for _, check := range secretManager.RecheckKeys(ctx) {
+		outcome := "ok"
+		switch {
+		case check.Disabled:
+			outcome = "disabled: " + check.Err.Error()
+		case check.Err != nil:
+			outcome = "error: " + check.Err.Error()
+		}
+		if check.Err != nil {
+			failed++
+		}
+		perms := check.Permissions
+		fmt.Printf("%d\tread=%t trade=%t margin=%t futures=%t withdraw=%t transfer=%t ip_restricted=%t\t%s\n", check.AcctID,
+			perms.Read, perms.Trade, perms.Margin, perms.Futures, perms.Withdraw, perms.Transfer, perms.IPRestricted, outcome)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d key checks failed", failed)
+	}
+	return nil
+}
+
+// runSealFile replaces the secrets of the file backend with those of a
+// plaintext YAML file, which should be deleted afterwards.
+func runSealFile(cfg config.ConfigSMS, ring *sms.KeyRing, args []string) error {
+	flags := flag.NewFlagSet("seal-file", flag.ExitOnError)
+	input := flags.String("in", "", "Plaintext YAML list of secrets")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *input == "" {
+		return fmt.Errorf("-in is required")
+	}
+	if cfg.Backend.Path == "" {
+		return fmt.Errorf("sms.backend.path is not configured")
+	}
+
+	data, err := os.ReadFile(*input)
+	if err != nil {
+		return err
+	}
+	secrets, err := sms.ParseSecretsYAML(data)
+	if err != nil {
+		return err
+	}
+	if err := sms.NewFileBackend(cfg.Backend.Path, ring).Replace(secrets); err != nil {
+		return err
+	}
+	log := logger.Get()
+	log.Info().Int("secrets", len(secrets)).Str("path", cfg.Backend.Path).Msg("Secrets file sealed")
+	return nil
+}
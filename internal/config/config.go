@@ -11,15 +11,17 @@ type Config struct {
 	Logger ConfigLogger `yaml:"logger"`
 	EMS    ConfigEMS    `yaml:"ems"`
 	PMS    ConfigPMS    `yaml:"pms"`
+	SMS    ConfigSMS    `yaml:"sms"`
 }
 
 // ConfigLogger contains logger configuration
 type ConfigLogger struct {
-	Level          string `yaml:"level"`
-	Output         string `yaml:"output"`           // "stdout" or "file"
-	Path           string `yaml:"path"`             // Required when output is "file"
-	MaxByteSize    int    `yaml:"max_byte_size"`    // Max size in bytes before rotation (0 = no rotation)
-	MaxBackupFiles int    `yaml:"max_backup_files"` // Max number of backup files to keep (0 = keep all)
+	Level          string   `yaml:"level"`
+	Output         string   `yaml:"output"`           // "stdout" or "file"
+	Path           string   `yaml:"path"`             // Required when output is "file"
+	MaxByteSize    int      `yaml:"max_byte_size"`    // Max size in bytes before rotation (0 = no rotation)
+	MaxBackupFiles int      `yaml:"max_backup_files"` // Max number of backup files to keep (0 = keep all)
+	RedactPatterns []string `yaml:"redact_patterns"`  // Extra regular expressions scrubbed from log output
 }
 
 // ConfigEMS contains EMS (Event Management System) configuration
@@ -33,6 +35,44 @@ type ConfigPMS struct {
 	Database ConfigDatabase `yaml:"database"`
 }
 
+// ConfigSMS contains SMS (Secret Management System) configuration
+type ConfigSMS struct {
+	Backend      ConfigSecretBackend            `yaml:"backend"`
+	MasterKey    ConfigMasterKey                `yaml:"master_key"`
+	PreviousKeys []ConfigMasterKey              `yaml:"previous_keys"` // Older versions still able to decrypt
+	Services     map[string]ConfigServiceAccess `yaml:"services"`      // What each calling service may do
+	KeyCheck     ConfigKeyCheck                 `yaml:"key_check"`     // API key permission checks against the venues
+}
+
+// ConfigKeyCheck selects the exchanges whose API key permissions are checked
+type ConfigKeyCheck struct {
+	Exchanges       []string          `yaml:"exchanges"`        // "binance" and/or "okx"
+	BaseURLs        map[string]string `yaml:"base_urls"`        // Endpoint by exchange, public endpoint if unset
+	IntervalMinutes int               `yaml:"interval_minutes"` // How often keys are re-checked (default 60)
+}
+
+// ConfigSecretBackend selects where secrets are stored
+type ConfigSecretBackend struct {
+	Type      string `yaml:"type"`       // "postgres" (default), "file" or "env"
+	Path      string `yaml:"path"`       // Encrypted secrets file for "file"
+	EnvPrefix string `yaml:"env_prefix"` // Variable prefix for "env" (default "SEQ_SECRET_")
+}
+
+// ConfigServiceAccess grants a service permissions on accounts
+type ConfigServiceAccess struct {
+	Permissions []string `yaml:"permissions"` // "read", "trade", "transfer", "reveal", "manage" or "all"
+	Accounts    []int    `yaml:"accounts"`    // Accounts covered, every account if empty
+}
+
+// ConfigMasterKey selects the master key wrapping the data keys of secrets
+type ConfigMasterKey struct {
+	Source  string `yaml:"source"`  // "file", "env" or "kms"
+	Path    string `yaml:"path"`    // Key file for "file", key store for "kms"
+	Env     string `yaml:"env"`     // Environment variable for "env"
+	KeyID   string `yaml:"key_id"`  // Key to use for "kms"
+	Version int    `yaml:"version"` // Key version stored with each secret (default 1)
+}
+
 // ConfigDatabase contains database configuration
 type ConfigDatabase struct {
 	Host     string `yaml:"host"`
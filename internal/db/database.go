@@ -9,29 +9,37 @@ import (
 	"gorm.io/gorm"
 )
 
-// ConnectPostgres initializes a PostgreSQL database connection using GORM
-func ConnectPostgres(cfg config.ConfigDatabase) (*gorm.DB, error) {
+// withDefaults fills in the SSL mode and port when they are not specified
+func withDefaults(cfg config.ConfigDatabase) config.ConfigDatabase {
 	// Set default SSL mode if not specified
-	sslMode := cfg.SSLMode
-	if sslMode == "" {
-		sslMode = "disable"
+	if cfg.SSLMode == "" {
+		cfg.SSLMode = "disable"
 	}
 
 	// Set default port if not specified
-	port := cfg.Port
-	if port == 0 {
-		port = 5432
+	if cfg.Port == 0 {
+		cfg.Port = 5432
 	}
+	return cfg
+}
 
-	// Build DSN (Data Source Name)
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=%s",
+// DSN builds a PostgreSQL connection string (Data Source Name) from the database configuration
+func DSN(cfg config.ConfigDatabase) string {
+	cfg = withDefaults(cfg)
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=%s",
 		cfg.Host,
 		cfg.User,
 		cfg.Password,
 		cfg.DBName,
-		port,
-		sslMode,
+		cfg.Port,
+		cfg.SSLMode,
 	)
+}
+
+// ConnectPostgres initializes a PostgreSQL database connection using GORM
+func ConnectPostgres(cfg config.ConfigDatabase) (*gorm.DB, error) {
+	cfg = withDefaults(cfg)
+	dsn := DSN(cfg)
 
 	// Open database connection
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
@@ -52,7 +60,7 @@ func ConnectPostgres(cfg config.ConfigDatabase) (*gorm.DB, error) {
 	log := logger.Get()
 	log.Info().
 		Str("host", cfg.Host).
-		Int("port", port).
+		Int("port", cfg.Port).
 		Str("database", cfg.DBName).
 		Msg("Successfully connected to PostgreSQL database")
 
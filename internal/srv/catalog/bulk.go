@@ -0,0 +1,459 @@
+package pms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+const (
+	UpsertInstrument = InsertInstrument + `
+		ON CONFLICT (symbol_id) DO UPDATE SET
+			exchange = EXCLUDED.exchange, venue = EXCLUDED.venue, type = EXCLUDED.type, symbol = EXCLUDED.symbol,
+			base_ccy = EXCLUDED.base_ccy, base_ccy_id = EXCLUDED.base_ccy_id, quote_ccy = EXCLUDED.quote_ccy, quote_ccy_id = EXCLUDED.quote_ccy_id,
+			price_tick_size = EXCLUDED.price_tick_size, qty_tick_size = EXCLUDED.qty_tick_size,
+			min_qty = EXCLUDED.min_qty, min_notional = EXCLUDED.min_notional, active = EXCLUDED.active, calendar_id = EXCLUDED.calendar_id,
+			contract_size = EXCLUDED.contract_size, settle_ccy = EXCLUDED.settle_ccy, settle_ccy_id = EXCLUDED.settle_ccy_id,
+			inverse = EXCLUDED.inverse, expiry = EXCLUDED.expiry, strike = EXCLUDED.strike, option_type = EXCLUDED.option_type,
+			underlying_id = EXCLUDED.underlying_id, funding_interval_sec = EXCLUDED.funding_interval_sec
+	`
+	InsertCurrency = `
+		INSERT INTO currencies (ccy_id, code, name, precision, asset_class) VALUES (?, ?, ?, ?, ?)
+	`
+	UpsertCurrency = InsertCurrency + `
+		ON CONFLICT (ccy_id) DO UPDATE SET
+			code = EXCLUDED.code, name = EXCLUDED.name, precision = EXCLUDED.precision, asset_class = EXCLUDED.asset_class
+	`
+	DeleteCurrencyAliases = `
+		DELETE FROM currency_aliases WHERE ccy_id = ?
+	`
+	InsertCurrencyAlias = `
+		INSERT INTO currency_aliases (exchange, alias, ccy_id) VALUES (?, ?, ?)
+	`
+)
+
+// CatalogData is the file representation of instruments and currencies used
+// by bulk import and export. Instruments reference currencies by the code
+// their exchange uses; their IDs are resolved through the exchange's aliases,
+// then by canonical code, against the currencies being imported and those
+// already in the database.
+type CatalogData struct {
+	Currencies  []CurrencyRecord   `json:"currencies,omitempty" yaml:"currencies,omitempty"`
+	Instruments []InstrumentRecord `json:"instruments,omitempty" yaml:"instruments,omitempty"`
+}
+
+type CurrencyRecord struct {
+	CcyID      int             `json:"ccy_id" yaml:"ccy_id"`
+	Code       string          `json:"code" yaml:"code"`
+	Name       string          `json:"name" yaml:"name"`
+	Precision  int             `json:"precision" yaml:"precision"`
+	AssetClass AssetClass      `json:"asset_class" yaml:"asset_class"`
+	Aliases    []CurrencyAlias `json:"aliases,omitempty" yaml:"aliases,omitempty"`
+}
+
+// CurrencyAlias is a code exchange uses for a currency. An exchange may
+// have several aliases for one currency, e.g. Kraken's "XBT" and "XXBT".
+type CurrencyAlias struct {
+	Exchange string `json:"exchange" yaml:"exchange"`
+	Alias    string `json:"alias" yaml:"alias"`
+}
+
+type InstrumentRecord struct {
+	SymbolID           int             `json:"symbol_id" yaml:"symbol_id"`
+	Exchange           string          `json:"exchange" yaml:"exchange"`
+	Venue              string          `json:"venue" yaml:"venue"`
+	Type               string          `json:"type" yaml:"type"`
+	Symbol             string          `json:"symbol" yaml:"symbol"`
+	BaseCcy            string          `json:"base_ccy" yaml:"base_ccy"`
+	QuoteCcy           string          `json:"quote_ccy" yaml:"quote_ccy"`
+	PriceTickSize      decimal.Decimal `json:"price_tick_size" yaml:"price_tick_size"`
+	QtyTickSize        decimal.Decimal `json:"qty_tick_size" yaml:"qty_tick_size"`
+	MinQty             decimal.Decimal `json:"min_qty" yaml:"min_qty"`
+	MinNotional        decimal.Decimal `json:"min_notional" yaml:"min_notional"`
+	Active             *bool           `json:"active,omitempty" yaml:"active,omitempty"` // Defaults to true
+	CalendarID         int             `json:"calendar_id,omitempty" yaml:"calendar_id,omitempty"`
+	ContractSize       decimal.Decimal `json:"contract_size" yaml:"contract_size"`
+	SettleCcy          string          `json:"settle_ccy,omitempty" yaml:"settle_ccy,omitempty"`
+	Inverse            bool            `json:"inverse,omitempty" yaml:"inverse,omitempty"`
+	Expiry             string          `json:"expiry,omitempty" yaml:"expiry,omitempty"` // RFC 3339
+	Strike             decimal.Decimal `json:"strike" yaml:"strike"`
+	OptionType         OptionType      `json:"option_type,omitempty" yaml:"option_type,omitempty"`
+	UnderlyingID       int             `json:"underlying_id,omitempty" yaml:"underlying_id,omitempty"`
+	FundingIntervalSec int             `json:"funding_interval_sec,omitempty" yaml:"funding_interval_sec,omitempty"`
+}
+
+type ImportMode int
+
+const (
+	ImportInsertOnly ImportMode = iota // Fail on rows that already exist
+	ImportUpsert                       // Replace rows that already exist
+)
+
+func (r CurrencyRecord) currency() Currency {
+	return Currency{CcyID: r.CcyID, Code: strings.ToUpper(r.Code), Name: r.Name, Precision: r.Precision, AssetClass: r.AssetClass}
+}
+
+func newCurrencyRecord(currency Currency, aliases []CurrencyAlias) CurrencyRecord {
+	return CurrencyRecord{
+		CcyID:      currency.CcyID,
+		Code:       currency.Code,
+		Name:       currency.Name,
+		Precision:  currency.Precision,
+		AssetClass: currency.AssetClass,
+		Aliases:    aliases,
+	}
+}
+
+// instrument converts r, resolving currency codes with currencies.
+func (r InstrumentRecord) instrument(currencies importCurrencies) (Instrument, error) {
+	baseCcyID, _ := currencies.resolve(r.Exchange, r.BaseCcy)
+	quoteCcyID, _ := currencies.resolve(r.Exchange, r.QuoteCcy)
+	settleCcyID, _ := currencies.resolve(r.Exchange, r.SettleCcy)
+	instrument := Instrument{
+		SymbolID:        r.SymbolID,
+		Exchange:        r.Exchange,
+		Venue:           r.Venue,
+		Type:            r.Type,
+		Symbol:          r.Symbol,
+		BaseCcy:         r.BaseCcy,
+		BaseCcyID:       baseCcyID,
+		QuoteCcy:        r.QuoteCcy,
+		QuoteCcyID:      quoteCcyID,
+		PriceTickSize:   r.PriceTickSize,
+		QtyTickSize:     r.QtyTickSize,
+		MinQty:          r.MinQty,
+		MinNotional:     r.MinNotional,
+		Active:          r.Active == nil || *r.Active,
+		CalendarID:      r.CalendarID,
+		ContractSize:    r.ContractSize,
+		SettleCcy:       r.SettleCcy,
+		SettleCcyID:     settleCcyID,
+		Inverse:         r.Inverse,
+		Strike:          r.Strike,
+		OptionType:      r.OptionType,
+		UnderlyingID:    r.UnderlyingID,
+		FundingInterval: time.Duration(r.FundingIntervalSec) * time.Second,
+	}
+	if r.Expiry != "" {
+		expiry, err := time.Parse(time.RFC3339, r.Expiry)
+		if err != nil {
+			return Instrument{}, fmt.Errorf("invalid expiry %q", r.Expiry)
+		}
+		instrument.Expiry = expiry
+	}
+	return instrument, nil
+}
+
+func newInstrumentRecord(instrument Instrument) InstrumentRecord {
+	active := instrument.Active
+	record := InstrumentRecord{
+		SymbolID:           instrument.SymbolID,
+		Exchange:           instrument.Exchange,
+		Venue:              instrument.Venue,
+		Type:               instrument.Type,
+		Symbol:             instrument.Symbol,
+		BaseCcy:            instrument.BaseCcy,
+		QuoteCcy:           instrument.QuoteCcy,
+		PriceTickSize:      instrument.PriceTickSize,
+		QtyTickSize:        instrument.QtyTickSize,
+		MinQty:             instrument.MinQty,
+		MinNotional:        instrument.MinNotional,
+		Active:             &active,
+		CalendarID:         instrument.CalendarID,
+		ContractSize:       instrument.ContractSize,
+		SettleCcy:          instrument.SettleCcy,
+		Inverse:            instrument.Inverse,
+		Strike:             instrument.Strike,
+		OptionType:         instrument.OptionType,
+		UnderlyingID:       instrument.UnderlyingID,
+		FundingIntervalSec: int(instrument.FundingInterval / time.Second),
+	}
+	if !instrument.Expiry.IsZero() {
+		record.Expiry = instrument.Expiry.UTC().Format(time.RFC3339)
+	}
+	return record
+}
+
+// importCurrencies resolves the currency codes of imported instruments.
+type importCurrencies struct {
+	ids     map[string]int // Code to ID
+	aliases map[exchangeAliasKey]int
+}
+
+// resolve returns the ID of the currency exchange calls code, matching its
+// aliases first and canonical codes second, as GetCurrencyByAlias does.
+func (c importCurrencies) resolve(exchange, code string) (int, bool) {
+	if ccyID, ok := c.aliases[exchangeAliasKey{exchange, code}]; ok {
+		return ccyID, true
+	}
+	ccyID, ok := c.ids[strings.ToUpper(code)]
+	return ccyID, ok
+}
+
+// instrumentKey identifies an instrument on its exchange.
+type instrumentKey struct {
+	exchange, instrumentType, symbol string
+}
+
+// existingCatalog is what validation needs to know about the database.
+type existingCatalog struct {
+	symbolIDs map[int]bool
+	symbols   map[instrumentKey]int // Exchange, type and symbol to symbol ID
+	ccyIDs    map[string]int        // Code to ID
+	ccyCodes  map[int]string        // ID to code
+	aliases   map[exchangeAliasKey]int
+}
+
+func loadExistingCatalog(db *gorm.DB) (existingCatalog, error) {
+	existing := existingCatalog{symbolIDs: make(map[int]bool), symbols: make(map[instrumentKey]int), ccyIDs: make(map[string]int), ccyCodes: make(map[int]string)}
+	var rows []struct {
+		SymbolID int
+		Exchange string
+		Type     string
+		Symbol   string
+	}
+	if err := db.Raw(`SELECT symbol_id, exchange, type, symbol FROM instruments`).Scan(&rows).Error; err != nil {
+		return existing, fmt.Errorf("failed to load instruments: %w", err)
+	}
+	for _, row := range rows {
+		existing.symbolIDs[row.SymbolID] = true
+		existing.symbols[instrumentKey{row.Exchange, row.Type, row.Symbol}] = row.SymbolID
+	}
+	currencies, aliases, err := queryCurrencies(db)
+	if err != nil {
+		return existing, fmt.Errorf("failed to load currencies: %w", err)
+	}
+	for _, currency := range currencies {
+		existing.ccyIDs[currency.Code] = currency.CcyID
+		existing.ccyCodes[currency.CcyID] = currency.Code
+	}
+	existing.aliases = aliases
+	return existing, nil
+}
+
+// validateImport checks data against itself and the existing catalog and
+// returns the instruments to write, with currency IDs resolved. Every
+// problem is reported, not only the first.
+func validateImport(data CatalogData, existing existingCatalog, mode ImportMode) ([]Instrument, error) {
+	var problems []error
+	problem := func(format string, args ...any) {
+		problems = append(problems, fmt.Errorf(format, args...))
+	}
+
+	ccyIDs := make(map[string]int, len(existing.ccyIDs)+len(data.Currencies))
+	for code, ccyID := range existing.ccyIDs {
+		ccyIDs[code] = ccyID
+	}
+	// Imported aliases replace every existing alias of their currency.
+	replacedAliases := make(map[int]bool)
+	for _, record := range data.Currencies {
+		if record.Aliases != nil {
+			replacedAliases[record.CcyID] = true
+		}
+	}
+	aliases := make(map[exchangeAliasKey]int, len(existing.aliases))
+	for key, ccyID := range existing.aliases {
+		if !replacedAliases[ccyID] {
+			aliases[key] = ccyID
+		}
+	}
+	seenCcyIDs := make(map[int]bool)
+	seenCodes := make(map[string]bool)
+	seenAliases := make(map[exchangeAliasKey]bool)
+	for n, record := range data.Currencies {
+		currency := record.currency()
+		where := fmt.Sprintf("currency %d (%s)", n+1, currency.Code)
+		switch {
+		case currency.CcyID <= 0:
+			problem("%s: ccy_id must be positive", where)
+		case seenCcyIDs[currency.CcyID]:
+			problem("%s: duplicate ccy_id %d", where, currency.CcyID)
+		case mode == ImportInsertOnly && existing.ccyCodes[currency.CcyID] != "":
+			problem("%s: ccy_id %d already exists", where, currency.CcyID)
+		}
+		if currency.Code == "" {
+			problem("%s: code is required", where)
+		} else if seenCodes[currency.Code] {
+			problem("%s: duplicate code %s", where, currency.Code)
+		} else if id, ok := existing.ccyIDs[currency.Code]; ok && id != currency.CcyID {
+			problem("%s: code %s already belongs to ccy_id %d", where, currency.Code, id)
+		}
+		switch currency.AssetClass {
+		case AssetClassCrypto, AssetClassStablecoin, AssetClassFiat:
+		default:
+			problem("%s: unknown asset_class %q", where, currency.AssetClass)
+		}
+		if currency.Precision < 0 {
+			problem("%s: precision must not be negative", where)
+		}
+		for _, alias := range record.Aliases {
+			key := exchangeAliasKey{alias.Exchange, alias.Alias}
+			switch {
+			case alias.Exchange == "" || alias.Alias == "":
+				problem("%s: alias exchange and code are required", where)
+			case seenAliases[key]:
+				problem("%s: duplicate %s alias %s", where, alias.Exchange, alias.Alias)
+			default:
+				if ccyID, ok := aliases[key]; ok && ccyID != currency.CcyID {
+					problem("%s: %s alias %s already belongs to ccy_id %d", where, alias.Exchange, alias.Alias, ccyID)
+				}
+			}
+			seenAliases[key] = true
+			aliases[key] = currency.CcyID
+		}
+		seenCcyIDs[currency.CcyID] = true
+		seenCodes[currency.Code] = true
+		ccyIDs[currency.Code] = currency.CcyID
+	}
+
+	currencies := importCurrencies{ids: ccyIDs, aliases: aliases}
+	instruments := make([]Instrument, 0, len(data.Instruments))
+	seenSymbolIDs := make(map[int]bool)
+	seenSymbols := make(map[instrumentKey]bool)
+	for n, record := range data.Instruments {
+		where := fmt.Sprintf("instrument %d (%s %s)", n+1, record.Exchange, record.Symbol)
+		switch {
+		case record.SymbolID <= 0:
+			problem("%s: symbol_id must be positive", where)
+		case seenSymbolIDs[record.SymbolID]:
+			problem("%s: duplicate symbol_id %d", where, record.SymbolID)
+		case mode == ImportInsertOnly && existing.symbolIDs[record.SymbolID]:
+			problem("%s: symbol_id %d already exists", where, record.SymbolID)
+		}
+		seenSymbolIDs[record.SymbolID] = true
+		if record.Exchange == "" || record.Symbol == "" || record.Type == "" {
+			problem("%s: exchange, type and symbol are required", where)
+		}
+		symbolKey := instrumentKey{record.Exchange, record.Type, record.Symbol}
+		if seenSymbols[symbolKey] {
+			problem("%s: duplicate %s symbol %s", where, record.Type, record.Symbol)
+		} else if symbolID, ok := existing.symbols[symbolKey]; ok && symbolID != record.SymbolID {
+			problem("%s: %s symbol %s already exists as symbol_id %d", where, record.Type, record.Symbol, symbolID)
+		}
+		seenSymbols[symbolKey] = true
+		if record.PriceTickSize.Sign() <= 0 {
+			problem("%s: price_tick_size must be greater than 0", where)
+		}
+		if record.QtyTickSize.Sign() <= 0 {
+			problem("%s: qty_tick_size must be greater than 0", where)
+		}
+		if record.MinQty.Sign() < 0 || record.MinNotional.Sign() < 0 || record.ContractSize.Sign() < 0 {
+			problem("%s: min_qty, min_notional and contract_size must not be negative", where)
+		}
+		ccys := []string{record.BaseCcy, record.QuoteCcy}
+		if record.SettleCcy != "" {
+			ccys = append(ccys, record.SettleCcy) // Defaults to the quote currency
+		}
+		for _, ccy := range ccys {
+			if _, ok := currencies.resolve(record.Exchange, ccy); !ok {
+				problem("%s: unknown currency %q", where, ccy)
+			}
+		}
+		switch record.OptionType {
+		case "", OptionCall, OptionPut:
+		default:
+			problem("%s: unknown option_type %q", where, record.OptionType)
+		}
+		instrument, err := record.instrument(currencies)
+		if err != nil {
+			problem("%s: %v", where, err)
+			continue
+		}
+		instruments = append(instruments, instrument)
+	}
+	return instruments, errors.Join(problems...)
+}
+
+// ImportCatalog validates data and writes its currencies and instruments in
+// a single transaction: either every row is written or none is. Currency
+// aliases in data replace the existing aliases of their currency.
+func ImportCatalog(ctx context.Context, db *gorm.DB, data CatalogData, mode ImportMode) error {
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		existing, err := loadExistingCatalog(tx)
+		if err != nil {
+			return err
+		}
+		instruments, err := validateImport(data, existing, mode)
+		if err != nil {
+			return fmt.Errorf("invalid catalog data:\n%w", err)
+		}
+
+		insertCurrency, insertInstrument := InsertCurrency, InsertInstrument
+		if mode == ImportUpsert {
+			insertCurrency, insertInstrument = UpsertCurrency, UpsertInstrument
+		}
+		for _, record := range data.Currencies {
+			c := record.currency()
+			if err := tx.Exec(insertCurrency, c.CcyID, c.Code, c.Name, c.Precision, string(c.AssetClass)).Error; err != nil {
+				return fmt.Errorf("failed to write currency %s: %w", c.Code, err)
+			}
+			if record.Aliases == nil {
+				continue
+			}
+			if err := tx.Exec(DeleteCurrencyAliases, c.CcyID).Error; err != nil {
+				return fmt.Errorf("failed to replace aliases of %s: %w", c.Code, err)
+			}
+			for _, alias := range record.Aliases {
+				if err := tx.Exec(InsertCurrencyAlias, alias.Exchange, alias.Alias, c.CcyID).Error; err != nil {
+					return fmt.Errorf("failed to write %s alias %s of %s: %w", alias.Exchange, alias.Alias, c.Code, err)
+				}
+			}
+		}
+		for _, instrument := range instruments {
+			if err := execInstrument(tx, insertInstrument, instrument); err != nil {
+				return fmt.Errorf("failed to write instrument %d: %w", instrument.SymbolID, err)
+			}
+		}
+		return nil
+	})
+}
+
+// newCurrencyRecords returns the records of currencies ordered by CcyID,
+// each with every alias of the currency ordered by exchange and alias.
+func newCurrencyRecords(currencies []Currency, aliases map[exchangeAliasKey]int) []CurrencyRecord {
+	aliasesByID := make(map[int][]CurrencyAlias)
+	for key, ccyID := range aliases {
+		aliasesByID[ccyID] = append(aliasesByID[ccyID], CurrencyAlias{Exchange: key.exchange, Alias: key.alias})
+	}
+	currencies = append([]Currency(nil), currencies...)
+	sort.Slice(currencies, func(i, j int) bool { return currencies[i].CcyID < currencies[j].CcyID })
+	records := make([]CurrencyRecord, 0, len(currencies))
+	for _, currency := range currencies {
+		list := aliasesByID[currency.CcyID]
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].Exchange != list[j].Exchange {
+				return list[i].Exchange < list[j].Exchange
+			}
+			return list[i].Alias < list[j].Alias
+		})
+		records = append(records, newCurrencyRecord(currency, list))
+	}
+	return records
+}
+
+// ExportCatalog reads every currency and instrument, including inactive
+// ones, ordered by ID.
+func ExportCatalog(ctx context.Context, db *gorm.DB) (CatalogData, error) {
+	db = db.WithContext(ctx)
+	currencies, aliases, err := queryCurrencies(db)
+	if err != nil {
+		return CatalogData{}, fmt.Errorf("failed to load currencies: %w", err)
+	}
+	instruments, err := queryInstruments(db, QueryAllInstruments)
+	if err != nil {
+		return CatalogData{}, fmt.Errorf("failed to load instruments: %w", err)
+	}
+	sort.Slice(instruments, func(i, j int) bool { return instruments[i].SymbolID < instruments[j].SymbolID })
+
+	data := CatalogData{Currencies: newCurrencyRecords(currencies, aliases)}
+	for _, instrument := range instruments {
+		data.Instruments = append(data.Instruments, newInstrumentRecord(instrument))
+	}
+	return data, nil
+}
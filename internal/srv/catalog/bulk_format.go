@@ -0,0 +1,319 @@
+package pms
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"gopkg.in/yaml.v3"
+)
+
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatJSON Format = "json"
+	FormatYAML Format = "yaml"
+)
+
+// FormatFromPath returns the format of path from its extension.
+func FormatFromPath(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return FormatCSV, nil
+	case ".json":
+		return FormatJSON, nil
+	case ".yaml", ".yml":
+		return FormatYAML, nil
+	}
+	return "", fmt.Errorf("unknown format of %s", path)
+}
+
+// DecodeCatalogData reads catalog data in format. A CSV file holds a single
+// table, instruments or currencies, told apart by its header.
+func DecodeCatalogData(r io.Reader, format Format) (CatalogData, error) {
+	var data CatalogData
+	switch format {
+	case FormatJSON:
+		decoder := json.NewDecoder(r)
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&data); err != nil {
+			return data, fmt.Errorf("failed to decode JSON: %w", err)
+		}
+	case FormatYAML:
+		decoder := yaml.NewDecoder(r)
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&data); err != nil && !errors.Is(err, io.EOF) {
+			return data, fmt.Errorf("failed to decode YAML: %w", err)
+		}
+	case FormatCSV:
+		return decodeCSV(r)
+	default:
+		return data, fmt.Errorf("unknown format %q", format)
+	}
+	return data, nil
+}
+
+// EncodeCatalogData writes data in format. CSV holds a single table, so data
+// must then contain either instruments or currencies.
+func EncodeCatalogData(w io.Writer, format Format, data CatalogData) error {
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(data)
+	case FormatYAML:
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(data); err != nil {
+			return err
+		}
+		return encoder.Close()
+	case FormatCSV:
+		switch {
+		case len(data.Instruments) > 0 && len(data.Currencies) > 0:
+			return errors.New("CSV holds one table: export instruments and currencies separately")
+		case len(data.Currencies) > 0:
+			return encodeCSV(w, currencyColumns, data.Currencies)
+		default:
+			return encodeCSV(w, instrumentColumns, data.Instruments)
+		}
+	}
+	return fmt.Errorf("unknown format %q", format)
+}
+
+// csvColumn maps a CSV column to a field of T.
+type csvColumn[T any] struct {
+	name string
+	get  func(T) string
+	set  func(*T, string) error
+}
+
+func stringColumn[T any](name string, field func(*T) *string) csvColumn[T] {
+	return csvColumn[T]{
+		name: name,
+		get:  func(t T) string { return *field(&t) },
+		set:  func(t *T, s string) error { *field(t) = s; return nil },
+	}
+}
+
+func intColumn[T any](name string, field func(*T) *int) csvColumn[T] {
+	return csvColumn[T]{
+		name: name,
+		get: func(t T) string {
+			if v := *field(&t); v != 0 {
+				return strconv.Itoa(v)
+			}
+			return ""
+		},
+		set: func(t *T, s string) error {
+			if s == "" {
+				return nil
+			}
+			v, err := strconv.Atoi(s)
+			*field(t) = v
+			return err
+		},
+	}
+}
+
+func decimalColumn[T any](name string, field func(*T) *decimal.Decimal) csvColumn[T] {
+	return csvColumn[T]{
+		name: name,
+		get:  func(t T) string { return field(&t).String() },
+		set: func(t *T, s string) error {
+			if s == "" {
+				return nil
+			}
+			v, err := decimal.NewFromString(s)
+			*field(t) = v
+			return err
+		},
+	}
+}
+
+func boolColumn[T any](name string, field func(*T) *bool) csvColumn[T] {
+	return csvColumn[T]{
+		name: name,
+		get:  func(t T) string { return strconv.FormatBool(*field(&t)) },
+		set: func(t *T, s string) error {
+			if s == "" {
+				return nil
+			}
+			v, err := strconv.ParseBool(s)
+			*field(t) = v
+			return err
+		},
+	}
+}
+
+var instrumentColumns = []csvColumn[InstrumentRecord]{
+	intColumn("symbol_id", func(r *InstrumentRecord) *int { return &r.SymbolID }),
+	stringColumn("exchange", func(r *InstrumentRecord) *string { return &r.Exchange }),
+	stringColumn("venue", func(r *InstrumentRecord) *string { return &r.Venue }),
+	stringColumn("type", func(r *InstrumentRecord) *string { return &r.Type }),
+	stringColumn("symbol", func(r *InstrumentRecord) *string { return &r.Symbol }),
+	stringColumn("base_ccy", func(r *InstrumentRecord) *string { return &r.BaseCcy }),
+	stringColumn("quote_ccy", func(r *InstrumentRecord) *string { return &r.QuoteCcy }),
+	decimalColumn("price_tick_size", func(r *InstrumentRecord) *decimal.Decimal { return &r.PriceTickSize }),
+	decimalColumn("qty_tick_size", func(r *InstrumentRecord) *decimal.Decimal { return &r.QtyTickSize }),
+	decimalColumn("min_qty", func(r *InstrumentRecord) *decimal.Decimal { return &r.MinQty }),
+	decimalColumn("min_notional", func(r *InstrumentRecord) *decimal.Decimal { return &r.MinNotional }),
+	{
+		name: "active",
+		get:  func(r InstrumentRecord) string { return strconv.FormatBool(r.Active == nil || *r.Active) },
+		set: func(r *InstrumentRecord, s string) error {
+			if s == "" {
+				return nil
+			}
+			active, err := strconv.ParseBool(s)
+			r.Active = &active
+			return err
+		},
+	},
+	intColumn("calendar_id", func(r *InstrumentRecord) *int { return &r.CalendarID }),
+	decimalColumn("contract_size", func(r *InstrumentRecord) *decimal.Decimal { return &r.ContractSize }),
+	stringColumn("settle_ccy", func(r *InstrumentRecord) *string { return &r.SettleCcy }),
+	boolColumn("inverse", func(r *InstrumentRecord) *bool { return &r.Inverse }),
+	stringColumn("expiry", func(r *InstrumentRecord) *string { return &r.Expiry }),
+	decimalColumn("strike", func(r *InstrumentRecord) *decimal.Decimal { return &r.Strike }),
+	{
+		name: "option_type",
+		get:  func(r InstrumentRecord) string { return string(r.OptionType) },
+		set:  func(r *InstrumentRecord, s string) error { r.OptionType = OptionType(s); return nil },
+	},
+	intColumn("underlying_id", func(r *InstrumentRecord) *int { return &r.UnderlyingID }),
+	intColumn("funding_interval_sec", func(r *InstrumentRecord) *int { return &r.FundingIntervalSec }),
+}
+
+var currencyColumns = []csvColumn[CurrencyRecord]{
+	intColumn("ccy_id", func(r *CurrencyRecord) *int { return &r.CcyID }),
+	stringColumn("code", func(r *CurrencyRecord) *string { return &r.Code }),
+	stringColumn("name", func(r *CurrencyRecord) *string { return &r.Name }),
+	{
+		name: "precision",
+		get:  func(r CurrencyRecord) string { return strconv.Itoa(r.Precision) },
+		set: func(r *CurrencyRecord, s string) error {
+			v, err := strconv.Atoi(s)
+			r.Precision = v
+			return err
+		},
+	},
+	{
+		name: "asset_class",
+		get:  func(r CurrencyRecord) string { return string(r.AssetClass) },
+		set:  func(r *CurrencyRecord, s string) error { r.AssetClass = AssetClass(s); return nil },
+	},
+	{
+		// Aliases are written as "exchange:alias" pairs separated by ";",
+		// e.g. "kraken:XBT;bitmex:XBT".
+		name: "aliases",
+		get: func(r CurrencyRecord) string {
+			pairs := make([]string, 0, len(r.Aliases))
+			for _, alias := range r.Aliases {
+				pairs = append(pairs, alias.Exchange+":"+alias.Alias)
+			}
+			return strings.Join(pairs, ";")
+		},
+		set: func(r *CurrencyRecord, s string) error {
+			if s == "" {
+				return nil
+			}
+			for _, pair := range strings.Split(s, ";") {
+				exchange, alias, ok := strings.Cut(pair, ":")
+				if !ok || exchange == "" || alias == "" {
+					return fmt.Errorf("invalid alias %q", pair)
+				}
+				r.Aliases = append(r.Aliases, CurrencyAlias{Exchange: exchange, Alias: alias})
+			}
+			return nil
+		},
+	},
+}
+
+func encodeCSV[T any](w io.Writer, columns []csvColumn[T], records []T) error {
+	writer := csv.NewWriter(w)
+	row := make([]string, len(columns))
+	for n, column := range columns {
+		row[n] = column.name
+	}
+	if err := writer.Write(row); err != nil {
+		return err
+	}
+	for _, record := range records {
+		for n, column := range columns {
+			row[n] = column.get(record)
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func decodeCSV(r io.Reader) (CatalogData, error) {
+	var data CatalogData
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err != nil {
+		return data, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	switch {
+	case contains(header, "symbol_id"):
+		data.Instruments, err = decodeCSVRecords(reader, header, instrumentColumns)
+	case contains(header, "ccy_id"):
+		data.Currencies, err = decodeCSVRecords(reader, header, currencyColumns)
+	default:
+		err = errors.New("CSV header has neither symbol_id nor ccy_id")
+	}
+	return data, err
+}
+
+func decodeCSVRecords[T any](reader *csv.Reader, header []string, columns []csvColumn[T]) ([]T, error) {
+	byName := make(map[string]csvColumn[T], len(columns))
+	for _, column := range columns {
+		byName[column.name] = column
+	}
+	fields := make([]csvColumn[T], len(header))
+	for n, name := range header {
+		column, ok := byName[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown CSV column %q", name)
+		}
+		fields[n] = column
+	}
+
+	var records []T
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		var record T
+		for n, value := range row {
+			if err := fields[n].set(&record, strings.TrimSpace(value)); err != nil {
+				return nil, fmt.Errorf("line %d, column %s: %w", line, fields[n].name, err)
+			}
+		}
+		records = append(records, record)
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if strings.TrimSpace(v) == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,245 @@
+package pms
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/BullionBear/seq/internal/db/dbtest"
+)
+
+func testCatalogData() CatalogData {
+	inactive := false
+	return CatalogData{
+		Currencies: []CurrencyRecord{
+			{CcyID: 1, Code: "BTC", Name: "Bitcoin", Precision: 8, AssetClass: AssetClassCrypto, Aliases: []CurrencyAlias{
+				{Exchange: "bitmex", Alias: "XBT"}, {Exchange: "kraken", Alias: "XBT"}, {Exchange: "kraken", Alias: "XXBT"},
+			}},
+			{CcyID: 2, Code: "USDT", Name: "Tether", Precision: 6, AssetClass: AssetClassStablecoin},
+		},
+		Instruments: []InstrumentRecord{
+			{SymbolID: 1, Exchange: "binance", Venue: "spot", Type: "spot", Symbol: "BTCUSDT", BaseCcy: "BTC", QuoteCcy: "USDT",
+				PriceTickSize: dec("0.01"), QtyTickSize: dec("0.00001"), MinQty: dec("0.00001"), MinNotional: dec("5"), ContractSize: dec("1")},
+			{SymbolID: 2, Exchange: "okx", Venue: "futures", Type: "future", Symbol: "BTC-USD-241227", BaseCcy: "BTC", QuoteCcy: "USDT",
+				PriceTickSize: dec("0.1"), QtyTickSize: dec("1"), Active: &inactive, ContractSize: dec("100"), SettleCcy: "BTC",
+				Inverse: true, Expiry: "2024-12-27T08:00:00Z"},
+		},
+	}
+}
+
+func TestCatalogData_RoundTrip(t *testing.T) {
+	data := testCatalogData()
+	active := true
+	data.Instruments[0].Active = &active // Always written on export
+
+	for _, format := range []Format{FormatJSON, FormatYAML} {
+		var buf bytes.Buffer
+		if err := EncodeCatalogData(&buf, format, data); err != nil {
+			t.Fatalf("Encode %s failed: %v", format, err)
+		}
+		got, err := DecodeCatalogData(&buf, format)
+		if err != nil {
+			t.Fatalf("Decode %s failed: %v", format, err)
+		}
+		if !catalogDataEqual(got, data) {
+			t.Errorf("Expected %s round trip to preserve data, got %+v", format, got)
+		}
+	}
+
+	var got CatalogData
+	for _, table := range []CatalogData{{Currencies: data.Currencies}, {Instruments: data.Instruments}} {
+		var buf bytes.Buffer
+		if err := EncodeCatalogData(&buf, FormatCSV, table); err != nil {
+			t.Fatalf("Encode CSV failed: %v", err)
+		}
+		decoded, err := DecodeCatalogData(&buf, FormatCSV)
+		if err != nil {
+			t.Fatalf("Decode CSV failed: %v", err)
+		}
+		got.Currencies = append(got.Currencies, decoded.Currencies...)
+		got.Instruments = append(got.Instruments, decoded.Instruments...)
+	}
+	if !catalogDataEqual(got, data) {
+		t.Errorf("Expected CSV round trip to preserve data, got %+v", got)
+	}
+	if err := EncodeCatalogData(&bytes.Buffer{}, FormatCSV, data); err == nil {
+		t.Error("Expected error encoding both tables to CSV")
+	}
+}
+
+func catalogDataEqual(a, b CatalogData) bool {
+	if !reflect.DeepEqual(a.Currencies, b.Currencies) || len(a.Instruments) != len(b.Instruments) {
+		return false
+	}
+	for n := range a.Instruments {
+		x, errX := a.Instruments[n].instrument(importCurrencies{})
+		y, errY := b.Instruments[n].instrument(importCurrencies{})
+		if errX != nil || errY != nil || !x.Equal(y) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestNewCurrencyRecords(t *testing.T) {
+	records := newCurrencyRecords([]Currency{
+		{CcyID: 2, Code: "USDT", AssetClass: AssetClassStablecoin},
+		{CcyID: 1, Code: "BTC", AssetClass: AssetClassCrypto},
+	}, map[exchangeAliasKey]int{
+		{"kraken", "XXBT"}: 1,
+		{"kraken", "XBT"}:  1,
+		{"bitmex", "XBT"}:  1,
+	})
+	want := []CurrencyAlias{{Exchange: "bitmex", Alias: "XBT"}, {Exchange: "kraken", Alias: "XBT"}, {Exchange: "kraken", Alias: "XXBT"}}
+	if len(records) != 2 || records[0].CcyID != 1 || !reflect.DeepEqual(records[0].Aliases, want) || records[1].Aliases != nil {
+		t.Errorf("Expected BTC with every alias, then USDT, got %+v", records)
+	}
+}
+
+func TestDecodeCatalogData_CSV(t *testing.T) {
+	input := "symbol_id,exchange,type,symbol,base_ccy,quote_ccy,price_tick_size,qty_tick_size\n" +
+		"7, binance ,spot,ETHUSDT,ETH,USDT,0.01,0.0001\n"
+	data, err := DecodeCatalogData(strings.NewReader(input), FormatCSV)
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if len(data.Instruments) != 1 || data.Instruments[0].SymbolID != 7 || data.Instruments[0].Exchange != "binance" ||
+		!data.Instruments[0].QtyTickSize.Equal(dec("0.0001")) || data.Instruments[0].Active != nil {
+		t.Errorf("Unexpected instruments: %+v", data.Instruments)
+	}
+
+	for name, input := range map[string]string{
+		"unknown table":  "id,code\n1,BTC\n",
+		"unknown column": "symbol_id,colour\n1,red\n",
+		"bad decimal":    "symbol_id,price_tick_size\n1,abc\n",
+		"bad alias":      "ccy_id,code,precision,aliases\n1,BTC,8,kraken\n",
+	} {
+		if _, err := DecodeCatalogData(strings.NewReader(input), FormatCSV); err == nil {
+			t.Errorf("Expected error for %s", name)
+		}
+	}
+	if _, err := DecodeCatalogData(strings.NewReader(`{"instrument": []}`), FormatJSON); err == nil {
+		t.Error("Expected error for unknown JSON field")
+	}
+}
+
+func TestValidateImport(t *testing.T) {
+	existing := existingCatalog{
+		symbolIDs: map[int]bool{1: true},
+		symbols:   map[instrumentKey]int{{"binance", "spot", "BTCUSDT"}: 1, {"binance", "spot", "ETHUSDT"}: 7},
+		ccyIDs:    map[string]int{"BTC": 1, "ETH": 3},
+		ccyCodes:  map[int]string{1: "BTC", 3: "ETH"},
+		aliases:   map[exchangeAliasKey]int{{"kraken", "XETH"}: 3, {"okx", "XBT"}: 1},
+	}
+	data := testCatalogData()
+	data.Instruments = append(data.Instruments,
+		InstrumentRecord{SymbolID: 3, Exchange: "binance", Venue: "spot", Type: "spot", Symbol: "ETHBTC", BaseCcy: "eth", QuoteCcy: "BTC",
+			PriceTickSize: dec("0.00001"), QtyTickSize: dec("0.001")},
+		InstrumentRecord{SymbolID: 4, Exchange: "kraken", Venue: "spot", Type: "spot", Symbol: "XETHXXBT", BaseCcy: "XETH", QuoteCcy: "XXBT",
+			PriceTickSize: dec("0.00001"), QtyTickSize: dec("0.001")})
+
+	instruments, err := validateImport(data, existing, ImportUpsert)
+	if err != nil {
+		t.Fatalf("Expected valid upsert, got %v", err)
+	}
+	if len(instruments) != 4 || instruments[2].BaseCcyID != 3 || instruments[2].QuoteCcyID != 1 ||
+		instruments[3].BaseCcyID != 3 || instruments[3].QuoteCcyID != 1 ||
+		instruments[1].SettleCcyID != 1 || instruments[1].Active || instruments[1].Expiry.IsZero() {
+		t.Errorf("Unexpected instruments: %+v", instruments)
+	}
+
+	_, err = validateImport(data, existing, ImportInsertOnly)
+	if err == nil || !strings.Contains(err.Error(), "symbol_id 1 already exists") || !strings.Contains(err.Error(), "ccy_id 1 already exists") {
+		t.Errorf("Expected existing IDs to fail insert-only, got %v", err)
+	}
+
+	bad := testCatalogData()
+	bad.Currencies = append(bad.Currencies, CurrencyRecord{CcyID: 2, Code: "usdt", AssetClass: "metal",
+		Aliases: []CurrencyAlias{{Exchange: "kraken", Alias: "XXBT"}, {Exchange: "kraken"}}})
+	bad.Instruments[1].SymbolID = 1
+	bad.Instruments[1].QtyTickSize = dec("0")
+	bad.Instruments[1].QuoteCcy = "DOGE"
+	bad.Instruments[1].OptionType = "straddle"
+	bad.Instruments[1].Expiry = "27 Dec 2024"
+	bad.Currencies[0].Aliases = append(bad.Currencies[0].Aliases, CurrencyAlias{Exchange: "kraken", Alias: "XETH"})
+	bad.Instruments = append(bad.Instruments,
+		InstrumentRecord{SymbolID: 5, Exchange: "binance", Venue: "spot", Type: "spot", Symbol: "ETHUSDT", BaseCcy: "ETH", QuoteCcy: "USDT",
+			PriceTickSize: dec("0.01"), QtyTickSize: dec("0.0001")},
+		InstrumentRecord{SymbolID: 6, Exchange: "okx", Venue: "spot", Type: "spot", Symbol: "XBT-USDT", BaseCcy: "XBT", QuoteCcy: "USDT",
+			PriceTickSize: dec("0.1"), QtyTickSize: dec("0.0001")})
+	_, err = validateImport(bad, existing, ImportUpsert)
+	for _, want := range []string{
+		"duplicate ccy_id 2", "duplicate code USDT", `unknown asset_class "metal"`, "duplicate kraken alias XXBT",
+		"alias exchange and code are required", "duplicate symbol_id 1",
+		"qty_tick_size must be greater than 0", `unknown currency "DOGE"`, `unknown option_type "straddle"`, "invalid expiry",
+		"kraken alias XETH already belongs to ccy_id 3", "spot symbol ETHUSDT already exists as symbol_id 7", `unknown currency "XBT"`,
+	} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error containing %q, got %v", want, err)
+		}
+	}
+}
+
+func TestImportExportCatalog_Postgres(t *testing.T) {
+	pg := dbtest.Postgres(t)
+	ctx := context.Background()
+	data := testCatalogData()
+
+	if err := ImportCatalog(ctx, pg.DB, data, ImportInsertOnly); err != nil {
+		t.Fatalf("ImportCatalog failed: %v", err)
+	}
+	if err := ImportCatalog(ctx, pg.DB, data, ImportInsertOnly); err == nil {
+		t.Fatal("Expected second insert-only import to fail")
+	}
+
+	// A bad row rolls back the whole import.
+	data.Currencies[1].Name = "Tether USD"
+	data.Instruments[0].MinNotional = dec("10")
+	data.Instruments = append(data.Instruments, InstrumentRecord{SymbolID: 3, Exchange: "binance", Type: "spot", Symbol: "XRPUSDT",
+		BaseCcy: "XRP", QuoteCcy: "USDT", PriceTickSize: dec("0.0001"), QtyTickSize: dec("1")})
+	if err := ImportCatalog(ctx, pg.DB, data, ImportUpsert); err == nil {
+		t.Fatal("Expected unknown currency XRP to fail")
+	}
+	exported, err := ExportCatalog(ctx, pg.DB)
+	if err != nil {
+		t.Fatalf("ExportCatalog failed: %v", err)
+	}
+	if len(exported.Instruments) != 2 || !exported.Instruments[0].MinNotional.Equal(dec("5")) || exported.Currencies[1].Name != "Tether" {
+		t.Errorf("Expected failed import to change nothing, got %+v", exported)
+	}
+
+	data.Instruments = data.Instruments[:2]
+	kraken := []CurrencyAlias{{Exchange: "kraken", Alias: "XBT"}, {Exchange: "kraken", Alias: "XXBT"}}
+	data.Currencies[0].Aliases = kraken
+	if err := ImportCatalog(ctx, pg.DB, data, ImportUpsert); err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+	exported, err = ExportCatalog(ctx, pg.DB)
+	if err != nil {
+		t.Fatalf("ExportCatalog failed: %v", err)
+	}
+	if !exported.Instruments[0].MinNotional.Equal(dec("10")) || exported.Currencies[1].Name != "Tether USD" ||
+		!reflect.DeepEqual(exported.Currencies[0].Aliases, kraken) {
+		t.Errorf("Expected upsert to replace rows, got %+v", exported)
+	}
+
+	// Re-importing an export keeps every alias, including several on one
+	// exchange, and resolves instruments listed under an alias.
+	exported.Instruments = append(exported.Instruments, InstrumentRecord{SymbolID: 3, Exchange: "kraken", Venue: "spot", Type: "spot",
+		Symbol: "XXBTZUSDT", BaseCcy: "XXBT", QuoteCcy: "USDT", PriceTickSize: dec("0.1"), QtyTickSize: dec("0.0001"), ContractSize: dec("1")})
+	if err := ImportCatalog(ctx, pg.DB, exported, ImportUpsert); err != nil {
+		t.Fatalf("Re-import failed: %v", err)
+	}
+	reexported, err := ExportCatalog(ctx, pg.DB)
+	if err != nil {
+		t.Fatalf("ExportCatalog failed: %v", err)
+	}
+	if !catalogDataEqual(reexported, exported) {
+		t.Errorf("Expected the export to survive a round trip, got %+v", reexported)
+	}
+	if *exported.Instruments[1].Active || exported.Instruments[1].Expiry != "2024-12-27T08:00:00Z" || exported.Instruments[1].SettleCcy != "BTC" {
+		t.Errorf("Unexpected exported future: %+v", exported.Instruments[1])
+	}
+}
@@ -0,0 +1,392 @@
+package pms
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // Calendar time zones must resolve on hosts without zoneinfo
+)
+
+const (
+	QueryTradingCalendars = `
+		SELECT calendar_id, name, timezone FROM trading_calendars
+	`
+	QueryTradingSessions = `
+		SELECT calendar_id, weekday, open_time::text, close_time::text FROM trading_sessions
+	`
+	QueryTradingCalendarExceptions = `
+		SELECT calendar_id, trade_date::text, close_time::text FROM trading_calendar_exceptions
+	`
+	QueryMaintenanceWindows = `
+		SELECT calendar_id, start_at, end_at FROM maintenance_windows
+	`
+)
+
+// calendarHorizon bounds how far NextOpen and NextClose search.
+const calendarHorizon = 370 * 24 * time.Hour
+
+var (
+	ErrNoSession = errors.New("no session within calendar horizon")
+	ErrNoClose   = errors.New("market does not close within calendar horizon")
+)
+
+// Session is a weekly trading session. Open and Close are offsets from
+// local midnight; a Close at or before Open falls on the following day.
+type Session struct {
+	Weekday time.Weekday
+	Open    time.Duration
+	Close   time.Duration
+}
+
+// MaintenanceWindow is a period during which a venue does not accept orders.
+type MaintenanceWindow struct {
+	Start time.Time
+	End   time.Time
+}
+
+// TradingCalendar describes when instruments attached to it are tradable.
+// A calendar without sessions is always open (crypto venues) apart from
+// its maintenance windows. Holidays and EarlyCloses are keyed by trade
+// date, the local date ("2006-01-02") on which a session closes.
+type TradingCalendar struct {
+	CalendarID  int
+	Name        string
+	Location    *time.Location
+	Sessions    []Session
+	Holidays    map[string]bool
+	EarlyCloses map[string]time.Duration // Offset from local midnight
+	Maintenance []MaintenanceWindow
+}
+
+type interval struct {
+	start, end time.Time
+}
+
+// IsOpen reports whether the calendar is in session at t.
+func (c *TradingCalendar) IsOpen(t time.Time) bool {
+	for _, iv := range c.openIntervals(t.Add(-48*time.Hour), t.Add(time.Nanosecond)) {
+		if !t.Before(iv.start) && t.Before(iv.end) {
+			return true
+		}
+	}
+	return false
+}
+
+// NextOpen returns the earliest time at or after t at which the calendar is
+// in session; t itself when it is open at t.
+func (c *TradingCalendar) NextOpen(t time.Time) (time.Time, error) {
+	for _, iv := range c.openIntervals(t.Add(-48*time.Hour), t.Add(calendarHorizon)) {
+		if iv.end.After(t) {
+			if iv.start.After(t) {
+				return iv.start, nil
+			}
+			return t, nil
+		}
+	}
+	return time.Time{}, ErrNoSession
+}
+
+// NextClose returns the earliest time at or after t at which the calendar
+// is out of session; t itself when it is closed at t.
+func (c *TradingCalendar) NextClose(t time.Time) (time.Time, error) {
+	to := t.Add(calendarHorizon)
+	for _, iv := range c.openIntervals(t.Add(-48*time.Hour), to) {
+		if !iv.end.After(t) {
+			continue
+		}
+		if iv.start.After(t) {
+			return t, nil
+		}
+		if !iv.end.Before(to) {
+			return time.Time{}, ErrNoClose
+		}
+		return iv.end, nil
+	}
+	return t, nil
+}
+
+// openIntervals returns the merged, ordered in-session intervals of the
+// sessions opening between from and to, less maintenance windows.
+// Calendars without sessions yield the single interval [from, to).
+func (c *TradingCalendar) openIntervals(from, to time.Time) []interval {
+	var intervals []interval
+	if len(c.Sessions) == 0 {
+		intervals = []interval{{from, to}}
+	} else {
+		loc := c.location()
+		local := from.In(loc)
+		day := time.Date(local.Year(), local.Month(), local.Day()-1, 0, 0, 0, 0, loc)
+		for !day.After(to) {
+			for _, session := range c.Sessions {
+				if session.Weekday != day.Weekday() {
+					continue
+				}
+				if iv, ok := c.sessionInterval(day, session); ok && iv.end.After(from) && iv.start.Before(to) {
+					intervals = append(intervals, iv)
+				}
+			}
+			day = time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, loc)
+		}
+		sort.Slice(intervals, func(i, j int) bool { return intervals[i].start.Before(intervals[j].start) })
+		intervals = mergeIntervals(intervals)
+	}
+	return subtractIntervals(intervals, c.Maintenance)
+}
+
+// sessionInterval resolves session opening on day, applying the holiday or
+// early close of its trade date.
+func (c *TradingCalendar) sessionInterval(day time.Time, session Session) (interval, bool) {
+	open := atOffset(day, session.Open)
+	closeDay := day
+	if session.Close <= session.Open {
+		closeDay = time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, day.Location())
+	}
+	end := atOffset(closeDay, session.Close)
+
+	tradeDate := closeDay.Format(time.DateOnly)
+	if c.Holidays[tradeDate] {
+		return interval{}, false
+	}
+	if offset, ok := c.EarlyCloses[tradeDate]; ok {
+		if early := atOffset(closeDay, offset); early.Before(end) {
+			end = early
+		}
+	}
+	if !end.After(open) {
+		return interval{}, false
+	}
+	return interval{open, end}, true
+}
+
+func (c *TradingCalendar) location() *time.Location {
+	if c.Location == nil {
+		return time.UTC
+	}
+	return c.Location
+}
+
+// atOffset returns the wall-clock time offset after midnight on day, so
+// sessions keep their local times across DST changes.
+func atOffset(day time.Time, offset time.Duration) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, int(offset), day.Location())
+}
+
+func mergeIntervals(intervals []interval) []interval {
+	merged := intervals[:0]
+	for _, iv := range intervals {
+		if n := len(merged); n > 0 && !iv.start.After(merged[n-1].end) {
+			if iv.end.After(merged[n-1].end) {
+				merged[n-1].end = iv.end
+			}
+			continue
+		}
+		merged = append(merged, iv)
+	}
+	return merged
+}
+
+func subtractIntervals(intervals []interval, windows []MaintenanceWindow) []interval {
+	for _, window := range windows {
+		var remaining []interval
+		for _, iv := range intervals {
+			if !window.Start.Before(iv.end) || !window.End.After(iv.start) {
+				remaining = append(remaining, iv)
+				continue
+			}
+			if window.Start.After(iv.start) {
+				remaining = append(remaining, interval{iv.start, window.Start})
+			}
+			if window.End.Before(iv.end) {
+				remaining = append(remaining, interval{window.End, iv.end})
+			}
+		}
+		intervals = remaining
+	}
+	return intervals
+}
+
+// parseClock parses a PostgreSQL TIME value such as "17:00:00" or
+// "24:00:00" into an offset from midnight.
+func parseClock(s string) (time.Duration, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("invalid time of day: %q", s)
+	}
+	hours, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day: %q", s)
+	}
+	minutes, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day: %q", s)
+	}
+	seconds, err := strconv.ParseFloat(parts[2], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day: %q", s)
+	}
+	return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute +
+		time.Duration(seconds*float64(time.Second)), nil
+}
+
+// loadCalendars reads every trading calendar with its sessions, exceptions
+// and maintenance windows.
+func (c *InstrumentCatalog) loadCalendars() (map[int]*TradingCalendar, error) {
+	calendars := make(map[int]*TradingCalendar)
+	rows, err := c.db.Raw(QueryTradingCalendars).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var calendar TradingCalendar
+		var timezone string
+		if err := rows.Scan(&calendar.CalendarID, &calendar.Name, &timezone); err != nil {
+			return nil, err
+		}
+		if calendar.Location, err = time.LoadLocation(timezone); err != nil {
+			return nil, fmt.Errorf("calendar %s: %w", calendar.Name, err)
+		}
+		calendar.Holidays = make(map[string]bool)
+		calendar.EarlyCloses = make(map[string]time.Duration)
+		calendars[calendar.CalendarID] = &calendar
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	calendar := func(calendarID int) (*TradingCalendar, error) {
+		if calendar, ok := calendars[calendarID]; ok {
+			return calendar, nil
+		}
+		return nil, fmt.Errorf("trading calendar not found for calendarID: %d", calendarID)
+	}
+
+	sessionRows, err := c.db.Raw(QueryTradingSessions).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer sessionRows.Close()
+	for sessionRows.Next() {
+		var calendarID, weekday int
+		var openTime, closeTime string
+		if err := sessionRows.Scan(&calendarID, &weekday, &openTime, &closeTime); err != nil {
+			return nil, err
+		}
+		cal, err := calendar(calendarID)
+		if err != nil {
+			return nil, err
+		}
+		session := Session{Weekday: time.Weekday(weekday)}
+		if session.Open, err = parseClock(openTime); err != nil {
+			return nil, err
+		}
+		if session.Close, err = parseClock(closeTime); err != nil {
+			return nil, err
+		}
+		cal.Sessions = append(cal.Sessions, session)
+	}
+	if err := sessionRows.Err(); err != nil {
+		return nil, err
+	}
+
+	exceptionRows, err := c.db.Raw(QueryTradingCalendarExceptions).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer exceptionRows.Close()
+	for exceptionRows.Next() {
+		var calendarID int
+		var tradeDate string
+		var closeTime sql.NullString
+		if err := exceptionRows.Scan(&calendarID, &tradeDate, &closeTime); err != nil {
+			return nil, err
+		}
+		cal, err := calendar(calendarID)
+		if err != nil {
+			return nil, err
+		}
+		if !closeTime.Valid {
+			cal.Holidays[tradeDate] = true
+			continue
+		}
+		if cal.EarlyCloses[tradeDate], err = parseClock(closeTime.String); err != nil {
+			return nil, err
+		}
+	}
+	if err := exceptionRows.Err(); err != nil {
+		return nil, err
+	}
+
+	windowRows, err := c.db.Raw(QueryMaintenanceWindows).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer windowRows.Close()
+	for windowRows.Next() {
+		var calendarID int
+		var window MaintenanceWindow
+		if err := windowRows.Scan(&calendarID, &window.Start, &window.End); err != nil {
+			return nil, err
+		}
+		cal, err := calendar(calendarID)
+		if err != nil {
+			return nil, err
+		}
+		cal.Maintenance = append(cal.Maintenance, window)
+	}
+	return calendars, windowRows.Err()
+}
+
+// calendar returns the trading calendar of symbolID, nil when the
+// instrument has none and is always tradable.
+func (c *InstrumentCatalog) calendar(symbolID int) (*TradingCalendar, error) {
+	instrument, err := c.GetInstrument(symbolID)
+	if err != nil {
+		return nil, err
+	}
+	if instrument.CalendarID == 0 {
+		return nil, nil
+	}
+	calendar, ok := (*c.calendars.Load())[instrument.CalendarID]
+	if !ok {
+		return nil, fmt.Errorf("trading calendar not found for calendarID: %d", instrument.CalendarID)
+	}
+	return calendar, nil
+}
+
+// IsOpen reports whether symbolID is tradable at t. Instruments without a
+// calendar are always open.
+func (c *InstrumentCatalog) IsOpen(symbolID int, t time.Time) (bool, error) {
+	calendar, err := c.calendar(symbolID)
+	if err != nil || calendar == nil {
+		return err == nil, err
+	}
+	return calendar.IsOpen(t), nil
+}
+
+// NextOpen returns the earliest time at or after t at which symbolID is
+// tradable.
+func (c *InstrumentCatalog) NextOpen(symbolID int, t time.Time) (time.Time, error) {
+	calendar, err := c.calendar(symbolID)
+	if err != nil || calendar == nil {
+		return t, err
+	}
+	return calendar.NextOpen(t)
+}
+
+// NextClose returns the earliest time at or after t at which symbolID stops
+// being tradable. It returns ErrNoClose for instruments without a calendar.
+func (c *InstrumentCatalog) NextClose(symbolID int, t time.Time) (time.Time, error) {
+	calendar, err := c.calendar(symbolID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if calendar == nil {
+		return time.Time{}, ErrNoClose
+	}
+	return calendar.NextClose(t)
+}
@@ -0,0 +1,221 @@
+package pms
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/BullionBear/seq/internal/db/dbtest"
+)
+
+func mustLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("Failed to load location %s: %v", name, err)
+	}
+	return loc
+}
+
+func clock(h, m int) time.Duration {
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute
+}
+
+// equitiesCalendar trades 09:30-16:00 New York time on weekdays.
+func equitiesCalendar(t *testing.T) *TradingCalendar {
+	calendar := &TradingCalendar{
+		CalendarID:  1,
+		Name:        "XNYS",
+		Location:    mustLocation(t, "America/New_York"),
+		Holidays:    map[string]bool{"2024-07-04": true},
+		EarlyCloses: map[string]time.Duration{"2024-07-03": clock(13, 0)},
+	}
+	for day := time.Monday; day <= time.Friday; day++ {
+		calendar.Sessions = append(calendar.Sessions, Session{Weekday: day, Open: clock(9, 30), Close: clock(16, 0)})
+	}
+	return calendar
+}
+
+// futuresCalendar trades 17:00-16:00 Chicago time, Sunday to Thursday
+// evening, with trade dates Monday to Friday.
+func futuresCalendar(t *testing.T) *TradingCalendar {
+	calendar := &TradingCalendar{
+		CalendarID:  2,
+		Name:        "CME Globex",
+		Location:    mustLocation(t, "America/Chicago"),
+		Holidays:    map[string]bool{"2024-12-25": true},
+		EarlyCloses: map[string]time.Duration{"2024-12-24": clock(12, 15)},
+	}
+	for day := time.Sunday; day <= time.Thursday; day++ {
+		calendar.Sessions = append(calendar.Sessions, Session{Weekday: day, Open: clock(17, 0), Close: clock(16, 0)})
+	}
+	return calendar
+}
+
+func TestTradingCalendar_IsOpen(t *testing.T) {
+	ny := mustLocation(t, "America/New_York")
+	chicago := mustLocation(t, "America/Chicago")
+	equities, futures := equitiesCalendar(t), futuresCalendar(t)
+	crypto := &TradingCalendar{Maintenance: []MaintenanceWindow{{
+		Start: time.Date(2024, 3, 5, 2, 0, 0, 0, time.UTC),
+		End:   time.Date(2024, 3, 5, 4, 0, 0, 0, time.UTC),
+	}}}
+
+	tests := []struct {
+		name     string
+		calendar *TradingCalendar
+		at       time.Time
+		want     bool
+	}{
+		{"equities at open", equities, time.Date(2024, 3, 1, 9, 30, 0, 0, ny), true},
+		{"equities before open", equities, time.Date(2024, 3, 1, 9, 29, 59, 0, ny), false},
+		{"equities at close", equities, time.Date(2024, 3, 1, 16, 0, 0, 0, ny), false},
+		{"equities weekend", equities, time.Date(2024, 3, 2, 12, 0, 0, 0, ny), false},
+		{"equities after DST change", equities, time.Date(2024, 3, 11, 9, 45, 0, 0, ny), true},
+		{"equities holiday", equities, time.Date(2024, 7, 4, 12, 0, 0, 0, ny), false},
+		{"equities early close", equities, time.Date(2024, 7, 3, 13, 30, 0, 0, ny), false},
+		{"equities before early close", equities, time.Date(2024, 7, 3, 12, 59, 0, 0, ny), true},
+		{"futures sunday evening", futures, time.Date(2024, 3, 3, 17, 0, 0, 0, chicago), true},
+		{"futures overnight", futures, time.Date(2024, 3, 5, 2, 0, 0, 0, chicago), true},
+		{"futures daily break", futures, time.Date(2024, 3, 5, 16, 30, 0, 0, chicago), false},
+		{"futures friday evening", futures, time.Date(2024, 3, 8, 17, 30, 0, 0, chicago), false},
+		{"futures christmas eve session", futures, time.Date(2024, 12, 24, 12, 0, 0, 0, chicago), true},
+		{"futures christmas eve after early close", futures, time.Date(2024, 12, 24, 12, 15, 0, 0, chicago), false},
+		{"futures christmas", futures, time.Date(2024, 12, 25, 9, 0, 0, 0, chicago), false},
+		{"futures after christmas", futures, time.Date(2024, 12, 25, 17, 0, 0, 0, chicago), true},
+		{"crypto weekend", crypto, time.Date(2024, 3, 2, 12, 0, 0, 0, time.UTC), true},
+		{"crypto maintenance", crypto, time.Date(2024, 3, 5, 3, 0, 0, 0, time.UTC), false},
+		{"crypto after maintenance", crypto, time.Date(2024, 3, 5, 4, 0, 0, 0, time.UTC), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.calendar.IsOpen(tt.at); got != tt.want {
+				t.Errorf("Expected IsOpen(%s) = %t, got %t", tt.at, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestTradingCalendar_NextOpenClose(t *testing.T) {
+	ny := mustLocation(t, "America/New_York")
+	chicago := mustLocation(t, "America/Chicago")
+	equities, futures := equitiesCalendar(t), futuresCalendar(t)
+
+	// Friday after the close opens Monday morning.
+	friday := time.Date(2024, 3, 1, 17, 0, 0, 0, ny)
+	if got, err := equities.NextOpen(friday); err != nil || !got.Equal(time.Date(2024, 3, 4, 9, 30, 0, 0, ny)) {
+		t.Errorf("Expected next open Monday 09:30, got %s (%v)", got, err)
+	}
+	if got, err := equities.NextClose(friday); err != nil || !got.Equal(friday) {
+		t.Errorf("Expected NextClose to return t while closed, got %s (%v)", got, err)
+	}
+	// July 3rd closes early and July 4th is skipped.
+	july3 := time.Date(2024, 7, 3, 10, 0, 0, 0, ny)
+	if got, err := equities.NextClose(july3); err != nil || !got.Equal(time.Date(2024, 7, 3, 13, 0, 0, 0, ny)) {
+		t.Errorf("Expected early close 13:00, got %s (%v)", got, err)
+	}
+	if got, err := equities.NextOpen(july3.Add(4 * time.Hour)); err != nil || !got.Equal(time.Date(2024, 7, 5, 9, 30, 0, 0, ny)) {
+		t.Errorf("Expected next open July 5th, got %s (%v)", got, err)
+	}
+	if got, err := equities.NextOpen(july3); err != nil || !got.Equal(july3) {
+		t.Errorf("Expected NextOpen to return t while open, got %s (%v)", got, err)
+	}
+
+	// Back-to-back futures sessions only close for the daily break.
+	monday := time.Date(2024, 3, 4, 3, 0, 0, 0, chicago)
+	if got, err := futures.NextClose(monday); err != nil || !got.Equal(time.Date(2024, 3, 4, 16, 0, 0, 0, chicago)) {
+		t.Errorf("Expected close Monday 16:00, got %s (%v)", got, err)
+	}
+	if got, err := futures.NextOpen(time.Date(2024, 3, 8, 16, 0, 0, 0, chicago)); err != nil || !got.Equal(time.Date(2024, 3, 10, 17, 0, 0, 0, chicago)) {
+		t.Errorf("Expected reopen Sunday 17:00, got %s (%v)", got, err)
+	}
+
+	crypto := &TradingCalendar{}
+	if _, err := crypto.NextClose(friday); !errors.Is(err, ErrNoClose) {
+		t.Errorf("Expected ErrNoClose for an always-open calendar, got %v", err)
+	}
+	if _, err := (&TradingCalendar{Sessions: []Session{{Weekday: time.Monday, Open: clock(9, 0), Close: clock(9, 0)}}, Holidays: map[string]bool{}}).NextOpen(friday); err != nil {
+		t.Errorf("Expected 24-hour session to open, got %v", err)
+	}
+}
+
+func TestParseClock(t *testing.T) {
+	for input, want := range map[string]time.Duration{
+		"09:30:00":   clock(9, 30),
+		"24:00:00":   24 * time.Hour,
+		"16:00:00.5": clock(16, 0) + 500*time.Millisecond,
+		"00:00:00":   0,
+	} {
+		if got, err := parseClock(input); err != nil || got != want {
+			t.Errorf("Expected parseClock(%q) = %s, got %s (%v)", input, want, got, err)
+		}
+	}
+	if _, err := parseClock("9:30"); err == nil {
+		t.Error("Expected error for malformed time")
+	}
+}
+
+func TestInstrumentCatalog_IsOpen(t *testing.T) {
+	c := testCatalog(
+		Instrument{SymbolID: 1, Exchange: "binance", Symbol: "BTCUSDT", Active: true},
+		Instrument{SymbolID: 2, Exchange: "nyse", Symbol: "IBM", Active: true, CalendarID: 1},
+		Instrument{SymbolID: 3, Exchange: "nyse", Symbol: "XOM", Active: true, CalendarID: 9},
+	)
+	c.calendars.Store(&map[int]*TradingCalendar{1: equitiesCalendar(t)})
+	saturday := time.Date(2024, 3, 2, 15, 0, 0, 0, time.UTC)
+
+	if open, err := c.IsOpen(1, saturday); err != nil || !open {
+		t.Errorf("Expected instrument without calendar to be open, got %t (%v)", open, err)
+	}
+	if open, err := c.IsOpen(2, saturday); err != nil || open {
+		t.Errorf("Expected equity to be closed on Saturday, got %t (%v)", open, err)
+	}
+	if got, err := c.NextOpen(2, saturday); err != nil || got.Weekday() != time.Monday {
+		t.Errorf("Expected next open on Monday, got %s (%v)", got, err)
+	}
+	if _, err := c.NextClose(1, saturday); !errors.Is(err, ErrNoClose) {
+		t.Errorf("Expected ErrNoClose without calendar, got %v", err)
+	}
+	if _, err := c.IsOpen(3, saturday); err == nil {
+		t.Error("Expected error for unknown calendar")
+	}
+	if _, err := c.IsOpen(4, saturday); err == nil {
+		t.Error("Expected error for unknown instrument")
+	}
+}
+
+func TestInstrumentCatalog_Calendars_Postgres(t *testing.T) {
+	pg := dbtest.Postgres(t)
+	for _, statement := range []string{
+		`INSERT INTO trading_calendars (calendar_id, name, timezone) VALUES (1, 'XNYS', 'America/New_York')`,
+		`INSERT INTO trading_sessions (calendar_id, weekday, open_time, close_time) VALUES
+		(1, 1, '09:30', '16:00'), (1, 2, '09:30', '16:00'), (1, 3, '09:30', '16:00'), (1, 4, '09:30', '16:00'), (1, 5, '09:30', '16:00')`,
+		`INSERT INTO trading_calendar_exceptions (calendar_id, trade_date, close_time) VALUES
+		(1, '2024-07-03', '13:00'), (1, '2024-07-04', NULL)`,
+		`INSERT INTO maintenance_windows (calendar_id, start_at, end_at) VALUES
+		(1, '2024-03-01 15:00:00+00', '2024-03-01 15:30:00+00')`,
+		`INSERT INTO currencies (ccy_id, code) VALUES (3, 'USD'), (10, 'IBM')`,
+		`INSERT INTO instruments (symbol_id, exchange, type, symbol, base_ccy, base_ccy_id, quote_ccy, quote_ccy_id, price_tick_size, qty_tick_size, calendar_id) VALUES
+		(1, 'nyse', 'equity', 'IBM', 'IBM', 10, 'USD', 3, 0.01, 1, 1)`,
+	} {
+		if err := pg.DB.Exec(statement).Error; err != nil {
+			t.Fatalf("Failed to insert calendar: %v", err)
+		}
+	}
+
+	c, err := NewInstrumentCatalog(pg.DB)
+	if err != nil {
+		t.Fatalf("NewInstrumentCatalog failed: %v", err)
+	}
+	ny := mustLocation(t, "America/New_York")
+	for at, want := range map[time.Time]bool{
+		time.Date(2024, 3, 1, 9, 45, 0, 0, ny):  true,
+		time.Date(2024, 3, 1, 10, 15, 0, 0, ny): false, // Maintenance
+		time.Date(2024, 7, 3, 14, 0, 0, 0, ny):  false, // Early close
+		time.Date(2024, 7, 4, 10, 0, 0, 0, ny):  false, // Holiday
+	} {
+		if open, err := c.IsOpen(1, at); err != nil || open != want {
+			t.Errorf("Expected IsOpen at %s = %t, got %t (%v)", at, want, open, err)
+		}
+	}
+}
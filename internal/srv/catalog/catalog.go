@@ -1,8 +1,10 @@
 package pms
 
 import (
-	"fmt"
+	"sync"
+	"sync/atomic"
 
+	"github.com/BullionBear/seq/pkg/evbus"
 	"github.com/rs/zerolog/log"
 	"gorm.io/gorm"
 )
@@ -14,41 +16,185 @@ const (
 )
 
 type InstrumentCatalog struct {
-	db          *gorm.DB
-	instruments map[int]Instrument
+	db            *gorm.DB
+	mu            sync.Mutex // serializes writers; readers only load index
+	index         atomic.Pointer[instrumentIndex]
+	calendars     atomic.Pointer[map[int]*TradingCalendar]
+	changes       *evbus.Bus[InstrumentChange]
+	changeFactory *evbus.EventFactory[InstrumentChange]
 }
 
 func NewInstrumentCatalog(db *gorm.DB) (*InstrumentCatalog, error) {
-	instrumentCatalog := &InstrumentCatalog{db: db, instruments: make(map[int]Instrument, 1024)}
-	if err := instrumentCatalog.loadInstruments(); err != nil {
+	instrumentCatalog := newInstrumentCatalog(db)
+	if err := instrumentCatalog.Reload(); err != nil {
 		log.Error().Err(err).Msg("Failed to load instruments")
 		return nil, err
 	}
 	return instrumentCatalog, nil
 }
 
-func (c *InstrumentCatalog) loadInstruments() error {
-	rows, err := c.db.Raw(QueryActiveInstruments).Rows()
+func newInstrumentCatalog(db *gorm.DB) *InstrumentCatalog {
+	c := &InstrumentCatalog{
+		db:      db,
+		changes: evbus.NewBus[InstrumentChange](),
+		changeFactory: evbus.NewEventFactory(func(change *InstrumentChange) {
+			*change = InstrumentChange{}
+		}),
+	}
+	c.index.Store(newInstrumentIndex(nil))
+	c.calendars.Store(&map[int]*TradingCalendar{})
+	return c
+}
+
+// Reload reads the active instruments and the trading calendars from the
+// database and replaces every index at once; readers see either the old or
+// the new catalog, never a mix. Differences from the previous snapshot are
+// published as changes.
+func (c *InstrumentCatalog) Reload() error {
+	c.mu.Lock()
+	instruments, err := c.loadInstruments()
 	if err != nil {
+		c.mu.Unlock()
 		return err
 	}
+	calendars, err := c.loadCalendars()
+	if err != nil {
+		c.mu.Unlock()
+		return err
+	}
+	c.calendars.Store(&calendars)
+	next := newInstrumentIndex(instruments)
+	changes := diffIndexes(c.index.Swap(next), next)
+	c.mu.Unlock()
+	c.publishChanges(changes)
+	return nil
+}
+
+func (c *InstrumentCatalog) loadInstruments() ([]Instrument, error) {
+	return queryInstruments(c.db, QueryActiveInstruments)
+}
+
+// queryInstruments runs query and scans every row into an Instrument.
+func queryInstruments(db *gorm.DB, query string, args ...any) ([]Instrument, error) {
+	rows, err := db.Raw(query, args...).Rows()
+	if err != nil {
+		return nil, err
+	}
 	defer rows.Close()
 
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	instruments := make([]Instrument, 0, 1024)
 	for rows.Next() {
 		var instrument Instrument
-		err := rows.Scan(&instrument.SymbolID, &instrument.Symbol, &instrument.Exchange, &instrument.Type, &instrument.Active)
-		if err != nil {
-			return err
+		if err := rows.Scan(instrumentScanTargets(&instrument, columns)...); err != nil {
+			return nil, err
 		}
-		c.instruments[instrument.SymbolID] = instrument
+		instruments = append(instruments, instrument)
 	}
-	return nil
+	return instruments, rows.Err()
 }
 
-func (c *InstrumentCatalog) GetInstrument(symbolID int) (Instrument, error) {
-	instrument, ok := c.instruments[symbolID]
-	if !ok {
-		return Instrument{}, fmt.Errorf("instrument not found for symbolID: %d", symbolID)
+// instrumentScanTargets maps each result column, by name, to the Instrument
+// field it populates. Columns without a matching field are discarded.
+func instrumentScanTargets(instrument *Instrument, columns []string) []any {
+	targets := make([]any, len(columns))
+	for i, column := range columns {
+		switch column {
+		case "symbol_id":
+			targets[i] = &instrument.SymbolID
+		case "exchange":
+			targets[i] = &instrument.Exchange
+		case "venue":
+			targets[i] = &instrument.Venue
+		case "type":
+			targets[i] = &instrument.Type
+		case "symbol":
+			targets[i] = &instrument.Symbol
+		case "base_ccy":
+			targets[i] = &instrument.BaseCcy
+		case "base_ccy_id":
+			targets[i] = &instrument.BaseCcyID
+		case "quote_ccy":
+			targets[i] = &instrument.QuoteCcy
+		case "quote_ccy_id":
+			targets[i] = &instrument.QuoteCcyID
+		case "price_tick_size":
+			targets[i] = &instrument.PriceTickSize
+		case "qty_tick_size":
+			targets[i] = &instrument.QtyTickSize
+		case "min_qty":
+			targets[i] = &instrument.MinQty
+		case "min_notional":
+			targets[i] = &instrument.MinNotional
+		case "active":
+			targets[i] = &instrument.Active
+		case "calendar_id":
+			targets[i] = &instrument.CalendarID
+		case "contract_size":
+			targets[i] = &instrument.ContractSize
+		case "settle_ccy":
+			targets[i] = &instrument.SettleCcy
+		case "settle_ccy_id":
+			targets[i] = (*nullID)(&instrument.SettleCcyID)
+		case "inverse":
+			targets[i] = &instrument.Inverse
+		case "expiry":
+			targets[i] = (*nullTime)(&instrument.Expiry)
+		case "strike":
+			targets[i] = &instrument.Strike
+		case "option_type":
+			targets[i] = &instrument.OptionType
+		case "underlying_id":
+			targets[i] = &instrument.UnderlyingID
+		case "funding_interval_sec":
+			targets[i] = (*durationSeconds)(&instrument.FundingInterval)
+		default:
+			targets[i] = new(any)
+		}
 	}
-	return instrument, nil
+	return targets
+}
+
+func (c *InstrumentCatalog) GetInstrument(symbolID int) (Instrument, error) {
+	return c.index.Load().getInstrument(symbolID)
+}
+
+// GetInstrumentByVenueSymbol looks up an instrument by the symbol its
+// exchange lists it under, e.g. ("binance", "BTCUSDT").
+func (c *InstrumentCatalog) GetInstrumentByVenueSymbol(exchange, symbol string) (Instrument, error) {
+	return c.index.Load().getInstrumentByVenueSymbol(exchange, symbol)
+}
+
+// GetInstrumentsBySymbol returns the instruments of every exchange and type
+// trading a normalized symbol such as "BTC-USDT". The returned slice is
+// shared and must not be modified.
+func (c *InstrumentCatalog) GetInstrumentsBySymbol(symbol string) []Instrument {
+	return c.index.Load().bySymbol[symbol]
+}
+
+// GetInstrumentsByPair returns the instruments quoting baseCcyID in
+// quoteCcyID. The returned slice is shared and must not be modified.
+func (c *InstrumentCatalog) GetInstrumentsByPair(baseCcyID, quoteCcyID int) []Instrument {
+	return c.index.Load().byPair[pairKey{baseCcyID, quoteCcyID}]
+}
+
+// ListByExchange returns the instruments of exchange ordered by SymbolID.
+// The returned slice is shared and must not be modified.
+func (c *InstrumentCatalog) ListByExchange(exchange string) []Instrument {
+	return c.index.Load().byExchange[exchange]
+}
+
+// ListByType returns the instruments of type ordered by SymbolID.
+// The returned slice is shared and must not be modified.
+func (c *InstrumentCatalog) ListByType(instrumentType string) []Instrument {
+	return c.index.Load().byType[instrumentType]
+}
+
+// ListInstruments returns every instrument ordered by SymbolID.
+// The returned slice is shared and must not be modified.
+func (c *InstrumentCatalog) ListInstruments() []Instrument {
+	return c.index.Load().all
 }
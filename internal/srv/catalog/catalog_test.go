@@ -0,0 +1,98 @@
+package pms
+
+import (
+	"testing"
+
+	"github.com/BullionBear/seq/internal/db/dbtest"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// insertTestInstruments inserts two binance instruments and the currencies
+// they and the binance fixture listing use.
+func insertTestInstruments(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	for _, insert := range []string{
+		`INSERT INTO currencies (ccy_id, code) VALUES (1, 'BTC'), (2, 'USDT'), (3, 'ETH'), (4, 'SOL')`,
+		`INSERT INTO instruments (symbol_id, exchange, venue, type, symbol, base_ccy, base_ccy_id, quote_ccy, quote_ccy_id, price_tick_size, qty_tick_size, active) VALUES
+		(1, 'binance', 'spot', 'spot', 'BTCUSDT', 'BTC', 1, 'USDT', 2, 0.01, 0.0001, true),
+		(2, 'binance', 'spot', 'spot', 'ETHUSDT', 'ETH', 3, 'USDT', 2, 0.01, 0.001, false)`,
+	} {
+		if err := db.Exec(insert).Error; err != nil {
+			t.Fatalf("Failed to insert instruments: %v", err)
+		}
+	}
+}
+
+func TestInstrumentScanTargets(t *testing.T) {
+	var instrument Instrument
+	columns := []string{"active", "unknown", "quote_ccy_id", "symbol_id", "price_tick_size"}
+	targets := instrumentScanTargets(&instrument, columns)
+
+	if targets[0] != &instrument.Active {
+		t.Error("Expected 'active' to map to Active")
+	}
+	if _, ok := targets[1].(*any); !ok {
+		t.Errorf("Expected unknown column to be discarded, got %T", targets[1])
+	}
+	if targets[2] != &instrument.QuoteCcyID {
+		t.Error("Expected 'quote_ccy_id' to map to QuoteCcyID")
+	}
+	if targets[3] != &instrument.SymbolID {
+		t.Error("Expected 'symbol_id' to map to SymbolID")
+	}
+	if targets[4] != &instrument.PriceTickSize {
+		t.Error("Expected 'price_tick_size' to map to PriceTickSize")
+	}
+}
+
+func TestNewInstrumentCatalog_Postgres(t *testing.T) {
+	pg := dbtest.Postgres(t)
+	insertTestInstruments(t, pg.DB)
+
+	catalog, err := NewInstrumentCatalog(pg.DB)
+	if err != nil {
+		t.Fatalf("NewInstrumentCatalog failed: %v", err)
+	}
+
+	got, err := catalog.GetInstrument(1)
+	if err != nil {
+		t.Fatalf("GetInstrument failed: %v", err)
+	}
+	want := Instrument{
+		SymbolID:      1,
+		Exchange:      "binance",
+		Venue:         "spot",
+		Type:          "spot",
+		Symbol:        "BTCUSDT",
+		BaseCcy:       "BTC",
+		BaseCcyID:     1,
+		QuoteCcy:      "USDT",
+		QuoteCcyID:    2,
+		PriceTickSize: decimal.RequireFromString("0.01"),
+		QtyTickSize:   decimal.RequireFromString("0.0001"),
+		Active:        true,
+		ContractSize:  dec("1"),
+		Strike:        dec("0"),
+	}
+	if !got.Equal(want) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+
+	if _, err := catalog.GetInstrument(2); err == nil {
+		t.Error("Expected inactive instrument not to be loaded")
+	}
+
+	if err := pg.DB.Exec(`UPDATE instruments SET active = true WHERE symbol_id = 2`).Error; err != nil {
+		t.Fatalf("Failed to activate instrument: %v", err)
+	}
+	if err := catalog.Reload(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if got, err := catalog.GetInstrumentByVenueSymbol("binance", "ETHUSDT"); err != nil || got.SymbolID != 2 {
+		t.Errorf("Expected ETHUSDT after reload, got %d (%v)", got.SymbolID, err)
+	}
+	if pair := catalog.GetInstrumentsByPair(1, 2); len(pair) != 1 {
+		t.Errorf("Expected 1 instrument for pair (1, 2), got %d", len(pair))
+	}
+}
@@ -0,0 +1,150 @@
+package pms
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Multiplier returns the contract size, treating an unset size as 1.
+func (i Instrument) Multiplier() decimal.Decimal {
+	if i.ContractSize.IsZero() {
+		return decimal.NewFromInt(1)
+	}
+	return i.ContractSize
+}
+
+// SettlementCcy returns the currency PnL and margin are denominated in:
+// SettleCcy when set, otherwise the base currency for inverse contracts and
+// the quote currency for everything else.
+func (i Instrument) SettlementCcy() string {
+	switch {
+	case i.SettleCcy != "":
+		return i.SettleCcy
+	case i.Inverse:
+		return i.BaseCcy
+	default:
+		return i.QuoteCcy
+	}
+}
+
+// ValueCcy returns the currency Notional and PnL are expressed in: the base
+// currency for inverse contracts and the quote currency for everything else.
+// It differs from SettlementCcy for quanto contracts, whose values the caller
+// must convert at the quanto rate.
+func (i Instrument) ValueCcy() string {
+	if i.Inverse {
+		return i.BaseCcy
+	}
+	return i.QuoteCcy
+}
+
+// Notional returns the value of qty contracts at price in ValueCcy. Linear
+// contracts are worth qty * multiplier * price quote units; inverse
+// contracts are worth qty * multiplier / price base units. For options,
+// pass the underlying price. The result is zero for an inverse contract at
+// a zero price.
+func (i Instrument) Notional(qty, price decimal.Decimal) decimal.Decimal {
+	size := qty.Mul(i.Multiplier())
+	if !i.Inverse {
+		return size.Mul(price)
+	}
+	if price.IsZero() {
+		return decimal.Zero
+	}
+	return size.Div(price)
+}
+
+// PnL returns the profit of qty contracts, positive for long and negative
+// for short, opened at entry and closed at exit, in ValueCcy. Linear
+// contracts earn qty * multiplier * (exit - entry); inverse contracts earn
+// qty * multiplier * (1/entry - 1/exit). The result is zero for an inverse
+// contract when either price is zero.
+func (i Instrument) PnL(qty, entry, exit decimal.Decimal) decimal.Decimal {
+	size := qty.Mul(i.Multiplier())
+	if !i.Inverse {
+		return size.Mul(exit.Sub(entry))
+	}
+	if entry.IsZero() || exit.IsZero() {
+		return decimal.Zero
+	}
+	one := decimal.NewFromInt(1)
+	return size.Mul(one.Div(entry).Sub(one.Div(exit)))
+}
+
+// nullTime scans a nullable timestamp column or JSON value into a
+// time.Time, mapping NULL to the zero time.
+type nullTime time.Time
+
+func (t *nullTime) Scan(src any) error {
+	var nt sql.NullTime
+	if err := nt.Scan(src); err != nil {
+		return err
+	}
+	*t = nullTime(nt.Time)
+	return nil
+}
+
+func (t *nullTime) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*t = nullTime{}
+		return nil
+	}
+	return (*time.Time)(t).UnmarshalJSON(data)
+}
+
+// nullID scans a nullable ID column into an int, mapping NULL to 0.
+type nullID int
+
+func (id *nullID) Scan(src any) error {
+	var n sql.NullInt64
+	if err := n.Scan(src); err != nil {
+		return err
+	}
+	*id = nullID(n.Int64)
+	return nil
+}
+
+// durationSeconds scans an integer number of seconds into a time.Duration.
+type durationSeconds time.Duration
+
+func (d *durationSeconds) Scan(src any) error {
+	var seconds sql.NullInt64
+	if err := seconds.Scan(src); err != nil {
+		return fmt.Errorf("scan duration seconds: %w", err)
+	}
+	*d = durationSeconds(time.Duration(seconds.Int64) * time.Second)
+	return nil
+}
+
+func (d *durationSeconds) UnmarshalJSON(data []byte) error {
+	var seconds *int64
+	if err := json.Unmarshal(data, &seconds); err != nil {
+		return err
+	}
+	*d = 0
+	if seconds != nil {
+		*d = durationSeconds(time.Duration(*seconds) * time.Second)
+	}
+	return nil
+}
+
+// nullableID returns the driver value of id, NULL for 0.
+func nullableID(id int) driver.Value {
+	if id == 0 {
+		return nil
+	}
+	return int64(id)
+}
+
+// nullableTime returns the driver value of t, NULL for the zero time.
+func nullableTime(t time.Time) driver.Value {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
@@ -0,0 +1,155 @@
+package pms
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/BullionBear/seq/internal/db/dbtest"
+	"github.com/shopspring/decimal"
+)
+
+func dec(s string) decimal.Decimal {
+	return decimal.RequireFromString(s)
+}
+
+var (
+	btcSpot  = Instrument{Type: "spot", BaseCcy: "BTC", QuoteCcy: "USDT"}
+	btcLin   = Instrument{Type: "perp", BaseCcy: "BTC", QuoteCcy: "USDT", ContractSize: dec("0.001")}
+	btcInv   = Instrument{Type: "perp", BaseCcy: "BTC", QuoteCcy: "USD", ContractSize: dec("100"), Inverse: true}
+	ethQuant = Instrument{Type: "future", BaseCcy: "ETH", QuoteCcy: "USD", SettleCcy: "BTC", ContractSize: dec("0.1")}
+)
+
+func TestInstrument_Notional(t *testing.T) {
+	tests := []struct {
+		name       string
+		instrument Instrument
+		qty, price string
+		want       string
+		ccy        string
+		settle     string
+	}{
+		{"spot", btcSpot, "0.5", "60000", "30000", "USDT", "USDT"},
+		{"linear", btcLin, "250", "60000", "15000", "USDT", "USDT"},
+		{"inverse", btcInv, "600", "60000", "1", "BTC", "BTC"},
+		{"inverse short", btcInv, "-300", "50000", "-0.6", "BTC", "BTC"},
+		{"inverse zero price", btcInv, "1", "0", "0", "BTC", "BTC"},
+		{"quanto", ethQuant, "10", "3000", "3000", "USD", "BTC"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.instrument.Notional(dec(tt.qty), dec(tt.price))
+			if !got.Equal(dec(tt.want)) {
+				t.Errorf("Expected notional %s, got %s", tt.want, got)
+			}
+			if ccy := tt.instrument.ValueCcy(); ccy != tt.ccy {
+				t.Errorf("Expected value currency %s, got %s", tt.ccy, ccy)
+			}
+			if ccy := tt.instrument.SettlementCcy(); ccy != tt.settle {
+				t.Errorf("Expected settlement currency %s, got %s", tt.settle, ccy)
+			}
+		})
+	}
+}
+
+func TestInstrument_PnL(t *testing.T) {
+	tests := []struct {
+		name             string
+		instrument       Instrument
+		qty, entry, exit string
+		want             string
+	}{
+		{"linear long", btcLin, "1000", "60000", "61000", "1000"},
+		{"linear short", btcLin, "-1000", "60000", "61000", "-1000"},
+		{"inverse long", btcInv, "1000", "50000", "62500", "0.4"},
+		{"inverse short", btcInv, "-1000", "50000", "62500", "-0.4"},
+		{"inverse zero entry", btcInv, "1000", "0", "62500", "0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.instrument.PnL(dec(tt.qty), dec(tt.entry), dec(tt.exit))
+			if !got.Equal(dec(tt.want)) {
+				t.Errorf("Expected PnL %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestContractScanTargets(t *testing.T) {
+	var instrument Instrument
+	targets := instrumentScanTargets(&instrument, []string{"expiry", "funding_interval_sec"})
+	expiry := time.Date(2026, 12, 25, 8, 0, 0, 0, time.UTC)
+
+	if err := targets[0].(*nullTime).Scan(expiry); err != nil || !instrument.Expiry.Equal(expiry) {
+		t.Errorf("Expected expiry %s, got %s (%v)", expiry, instrument.Expiry, err)
+	}
+	if err := targets[0].(*nullTime).Scan(nil); err != nil || !instrument.Expiry.IsZero() {
+		t.Errorf("Expected NULL expiry to scan as zero time, got %s (%v)", instrument.Expiry, err)
+	}
+	if err := targets[1].(*durationSeconds).Scan(int64(28800)); err != nil || instrument.FundingInterval != 8*time.Hour {
+		t.Errorf("Expected funding interval 8h, got %s (%v)", instrument.FundingInterval, err)
+	}
+}
+
+func TestDecodeInstrumentRow_Contract(t *testing.T) {
+	var n instrumentNotification
+	payload := `{"op":"INSERT","row":{"symbol_id":7,"type":"future","contract_size":100,"inverse":true,` +
+		`"expiry":"2026-12-25T08:00:00+00:00","strike":0,"option_type":"","underlying_id":1,"funding_interval_sec":0}}`
+	if err := json.Unmarshal([]byte(payload), &n); err != nil {
+		t.Fatalf("Failed to unmarshal payload: %v", err)
+	}
+	instrument, err := decodeInstrumentRow(n.Row)
+	if err != nil {
+		t.Fatalf("decodeInstrumentRow failed: %v", err)
+	}
+	if !instrument.Expiry.Equal(time.Date(2026, 12, 25, 8, 0, 0, 0, time.UTC)) || !instrument.Inverse ||
+		!instrument.ContractSize.Equal(dec("100")) || instrument.UnderlyingID != 1 {
+		t.Errorf("Unexpected contract specification: %+v", instrument)
+	}
+
+	if err := json.Unmarshal([]byte(`{"op":"INSERT","row":{"expiry":null,"funding_interval_sec":3600,"option_type":"put"}}`), &n); err != nil {
+		t.Fatalf("Failed to unmarshal payload: %v", err)
+	}
+	if instrument, err = decodeInstrumentRow(n.Row); err != nil {
+		t.Fatalf("decodeInstrumentRow failed: %v", err)
+	}
+	if !instrument.Expiry.IsZero() || instrument.FundingInterval != time.Hour || instrument.OptionType != OptionPut {
+		t.Errorf("Unexpected contract specification: %+v", instrument)
+	}
+}
+
+func TestInstrumentContract_Postgres(t *testing.T) {
+	pg := dbtest.Postgres(t)
+	future := Instrument{
+		SymbolID: 1, Exchange: "okx", Venue: "futures", Type: "future", Symbol: "BTC-USD-261225",
+		BaseCcy: "BTC", BaseCcyID: 1, QuoteCcy: "USD", QuoteCcyID: 2,
+		PriceTickSize: dec("0.1"), QtyTickSize: dec("1"), Active: true,
+		ContractSize: dec("100"), SettleCcy: "BTC", SettleCcyID: 1, Inverse: true,
+		Expiry: time.Date(2026, 12, 25, 8, 0, 0, 0, time.UTC), UnderlyingID: 1,
+	}
+	perp := Instrument{
+		SymbolID: 2, Exchange: "okx", Venue: "swap", Type: "perp", Symbol: "BTC-USDT-SWAP",
+		BaseCcy: "BTC", BaseCcyID: 1, QuoteCcy: "USDT", QuoteCcyID: 3,
+		PriceTickSize: dec("0.1"), QtyTickSize: dec("0.01"), Active: true,
+		ContractSize: dec("0.01"), FundingInterval: 8 * time.Hour,
+	}
+	if err := pg.DB.Exec(`INSERT INTO currencies (ccy_id, code) VALUES (1, 'BTC'), (2, 'USD'), (3, 'USDT')`).Error; err != nil {
+		t.Fatalf("Failed to insert currencies: %v", err)
+	}
+	for _, instrument := range []Instrument{future, perp} {
+		if err := insertInstrument(pg.DB, instrument); err != nil {
+			t.Fatalf("Failed to insert %s: %v", instrument.Symbol, err)
+		}
+	}
+
+	catalog, err := NewInstrumentCatalog(pg.DB)
+	if err != nil {
+		t.Fatalf("NewInstrumentCatalog failed: %v", err)
+	}
+	if got, err := catalog.GetInstrument(1); err != nil || !got.Equal(future) {
+		t.Errorf("Expected %+v, got %+v (%v)", future, got, err)
+	}
+	if got, err := catalog.GetInstrument(2); err != nil || !got.Equal(perp) {
+		t.Errorf("Expected %+v, got %+v (%v)", perp, got, err)
+	}
+}
@@ -0,0 +1,179 @@
+package pms
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+const (
+	QueryCurrencies = `
+		SELECT ccy_id, code, name, precision, asset_class FROM currencies
+	`
+	QueryCurrencyAliases = `
+		SELECT exchange, alias, ccy_id FROM currency_aliases
+	`
+)
+
+type AssetClass string
+
+const (
+	AssetClassCrypto     AssetClass = "crypto"
+	AssetClassStablecoin AssetClass = "stablecoin"
+	AssetClassFiat       AssetClass = "fiat"
+)
+
+// Currency is an asset referenced by ID from instruments (base_ccy_id,
+// quote_ccy_id, settle_ccy_id) and fills (FeeCcyID).
+type Currency struct {
+	CcyID      int
+	Code       string // Canonical upper-case code, e.g. "BTC"
+	Name       string
+	Precision  int // Decimal places amounts are stored with
+	AssetClass AssetClass
+}
+
+type exchangeAliasKey struct {
+	exchange string
+	alias    string
+}
+
+// currencyIndex is an immutable snapshot of the registry.
+type currencyIndex struct {
+	byID    map[int]Currency
+	byCode  map[string]Currency
+	byAlias map[exchangeAliasKey]Currency
+	all     []Currency
+}
+
+func newCurrencyIndex(currencies []Currency, aliases map[exchangeAliasKey]int) *currencyIndex {
+	all := make([]Currency, len(currencies))
+	copy(all, currencies)
+	sort.Slice(all, func(i, j int) bool { return all[i].CcyID < all[j].CcyID })
+
+	idx := &currencyIndex{
+		byID:    make(map[int]Currency, len(all)),
+		byCode:  make(map[string]Currency, len(all)),
+		byAlias: make(map[exchangeAliasKey]Currency, len(aliases)),
+		all:     all,
+	}
+	for i := range all {
+		all[i].Code = strings.ToUpper(all[i].Code)
+		idx.byID[all[i].CcyID] = all[i]
+		idx.byCode[all[i].Code] = all[i]
+	}
+	for key, ccyID := range aliases {
+		if currency, ok := idx.byID[ccyID]; ok {
+			idx.byAlias[key] = currency
+		}
+	}
+	return idx
+}
+
+// CurrencyRegistry is the in-memory view of the currencies table and the
+// per-exchange aliases of each currency (e.g. Kraken's "XBT" for "BTC").
+type CurrencyRegistry struct {
+	db    *gorm.DB
+	index atomic.Pointer[currencyIndex]
+}
+
+func NewCurrencyRegistry(db *gorm.DB) (*CurrencyRegistry, error) {
+	registry := &CurrencyRegistry{db: db}
+	if err := registry.Reload(); err != nil {
+		log.Error().Err(err).Msg("Failed to load currencies")
+		return nil, err
+	}
+	return registry, nil
+}
+
+// Reload reads the currencies and their aliases and replaces the registry
+// contents at once.
+func (r *CurrencyRegistry) Reload() error {
+	currencies, aliases, err := queryCurrencies(r.db)
+	if err != nil {
+		return err
+	}
+	r.index.Store(newCurrencyIndex(currencies, aliases))
+	return nil
+}
+
+// queryCurrencies reads every currency and the currency ID of every
+// exchange alias.
+func queryCurrencies(db *gorm.DB) ([]Currency, map[exchangeAliasKey]int, error) {
+	var currencies []Currency
+	rows, err := db.Raw(QueryCurrencies).Rows()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var currency Currency
+		if err := rows.Scan(&currency.CcyID, &currency.Code, &currency.Name, &currency.Precision, &currency.AssetClass); err != nil {
+			return nil, nil, err
+		}
+		currencies = append(currencies, currency)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	aliases := make(map[exchangeAliasKey]int)
+	aliasRows, err := db.Raw(QueryCurrencyAliases).Rows()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer aliasRows.Close()
+	for aliasRows.Next() {
+		var key exchangeAliasKey
+		var ccyID int
+		if err := aliasRows.Scan(&key.exchange, &key.alias, &ccyID); err != nil {
+			return nil, nil, err
+		}
+		aliases[key] = ccyID
+	}
+	if err := aliasRows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return currencies, aliases, nil
+}
+
+func (r *CurrencyRegistry) GetCurrency(ccyID int) (Currency, error) {
+	currency, ok := r.index.Load().byID[ccyID]
+	if !ok {
+		return Currency{}, fmt.Errorf("currency not found for ccyID: %d", ccyID)
+	}
+	return currency, nil
+}
+
+// GetCurrencyByCode looks up a currency by its canonical code, ignoring case.
+func (r *CurrencyRegistry) GetCurrencyByCode(code string) (Currency, error) {
+	currency, ok := r.index.Load().byCode[strings.ToUpper(code)]
+	if !ok {
+		return Currency{}, fmt.Errorf("currency not found for code: %s", code)
+	}
+	return currency, nil
+}
+
+// GetCurrencyByAlias resolves the code exchange uses for a currency, e.g.
+// ("kraken", "XBT"). Codes without an alias on exchange resolve as
+// canonical codes.
+func (r *CurrencyRegistry) GetCurrencyByAlias(exchange, alias string) (Currency, error) {
+	idx := r.index.Load()
+	if currency, ok := idx.byAlias[exchangeAliasKey{exchange, alias}]; ok {
+		return currency, nil
+	}
+	if currency, ok := idx.byCode[strings.ToUpper(alias)]; ok {
+		return currency, nil
+	}
+	return Currency{}, fmt.Errorf("currency not found for %s alias: %s", exchange, alias)
+}
+
+// ListCurrencies returns every currency ordered by CcyID.
+// The returned slice is shared and must not be modified.
+func (r *CurrencyRegistry) ListCurrencies() []Currency {
+	return r.index.Load().all
+}
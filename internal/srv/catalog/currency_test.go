@@ -0,0 +1,88 @@
+package pms
+
+import (
+	"testing"
+
+	"github.com/BullionBear/seq/internal/db/dbtest"
+)
+
+func testRegistry() *CurrencyRegistry {
+	r := &CurrencyRegistry{}
+	r.index.Store(newCurrencyIndex([]Currency{
+		{CcyID: 2, Code: "USDT", Name: "Tether", Precision: 6, AssetClass: AssetClassStablecoin},
+		{CcyID: 1, Code: "BTC", Name: "Bitcoin", Precision: 8, AssetClass: AssetClassCrypto},
+		{CcyID: 3, Code: "usd", Name: "US Dollar", Precision: 2, AssetClass: AssetClassFiat},
+	}, map[exchangeAliasKey]int{
+		{"kraken", "XBT"}:  1,
+		{"kraken", "ZUSD"}: 3,
+		{"kraken", "GONE"}: 99,
+	}))
+	return r
+}
+
+func TestCurrencyRegistry_Lookups(t *testing.T) {
+	r := testRegistry()
+
+	if got, err := r.GetCurrency(1); err != nil || got.Code != "BTC" {
+		t.Errorf("Expected BTC for ccyID 1, got %+v (%v)", got, err)
+	}
+	if _, err := r.GetCurrency(4); err == nil {
+		t.Error("Expected error for unknown ccyID")
+	}
+	if got, err := r.GetCurrencyByCode("usdt"); err != nil || got.CcyID != 2 || got.Precision != 6 {
+		t.Errorf("Expected USDT for code usdt, got %+v (%v)", got, err)
+	}
+	if got, err := r.GetCurrencyByCode("USD"); err != nil || got.CcyID != 3 || got.Code != "USD" {
+		t.Errorf("Expected a lower-case code to be stored upper-case, got %+v (%v)", got, err)
+	}
+	if got, err := r.GetCurrencyByAlias("kraken", "XBT"); err != nil || got.CcyID != 1 {
+		t.Errorf("Expected BTC for kraken XBT, got %+v (%v)", got, err)
+	}
+	if got, err := r.GetCurrencyByAlias("binance", "BTC"); err != nil || got.CcyID != 1 {
+		t.Errorf("Expected canonical code fallback for binance BTC, got %+v (%v)", got, err)
+	}
+	if _, err := r.GetCurrencyByAlias("binance", "XBT"); err == nil {
+		t.Error("Expected kraken alias not to resolve on binance")
+	}
+	if _, err := r.GetCurrencyByAlias("kraken", "GONE"); err == nil {
+		t.Error("Expected alias of unknown currency to be dropped")
+	}
+	if all := r.ListCurrencies(); len(all) != 3 || all[0].Code != "BTC" || all[2].Code != "USD" {
+		t.Errorf("Expected currencies ordered by ccyID, got %+v", all)
+	}
+}
+
+func TestNewCurrencyRegistry_Postgres(t *testing.T) {
+	pg := dbtest.Postgres(t)
+	for _, insert := range []string{
+		`INSERT INTO currencies (ccy_id, code, name, precision, asset_class) VALUES
+		(1, 'BTC', 'Bitcoin', 8, 'crypto'),
+		(2, 'USD', 'US Dollar', 2, 'fiat')`,
+		`INSERT INTO currency_aliases (exchange, alias, ccy_id) VALUES
+		('kraken', 'XBT', 1),
+		('kraken', 'ZUSD', 2)`,
+	} {
+		if err := pg.DB.Exec(insert).Error; err != nil {
+			t.Fatalf("Failed to insert currencies: %v", err)
+		}
+	}
+
+	r, err := NewCurrencyRegistry(pg.DB)
+	if err != nil {
+		t.Fatalf("NewCurrencyRegistry failed: %v", err)
+	}
+	want := Currency{CcyID: 2, Code: "USD", Name: "US Dollar", Precision: 2, AssetClass: AssetClassFiat}
+	if got, err := r.GetCurrencyByAlias("kraken", "ZUSD"); err != nil || got != want {
+		t.Errorf("Expected %+v, got %+v (%v)", want, got, err)
+	}
+
+	if err := pg.DB.Exec(`DELETE FROM currencies WHERE ccy_id = 1`).Error; err != nil {
+		t.Fatalf("Failed to delete currency: %v", err)
+	}
+	if err := r.Reload(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if _, err := r.GetCurrencyByAlias("kraken", "XBT"); err == nil {
+		t.Error("Expected alias to be removed with its currency")
+	}
+}
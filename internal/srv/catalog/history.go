@@ -0,0 +1,139 @@
+package pms
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	QueryInstrumentsAsOf = `
+		SELECT valid_from, valid_to, definition FROM instrument_versions
+		WHERE valid_from <= ? AND (valid_to IS NULL OR valid_to > ?)
+	`
+	QueryInstrumentVersions = `
+		SELECT valid_from, valid_to, definition FROM instrument_versions
+		WHERE symbol_id = ? ORDER BY valid_from
+	`
+)
+
+// InstrumentVersion is an instrument definition as it was between ValidFrom
+// and ValidTo.
+type InstrumentVersion struct {
+	Instrument
+	ValidFrom time.Time
+	ValidTo   time.Time // Zero for the current version
+}
+
+// InstrumentView is a read-only catalog of the instruments that were active
+// at one point in time. It offers the lookups of InstrumentCatalog and does
+// not change after it is created.
+type InstrumentView struct {
+	asOf  time.Time
+	index *instrumentIndex
+}
+
+// AsOf returns the catalog as it was at t, from the instrument_versions
+// history, for backtests that must see the tick sizes and listings of the
+// day. Instruments inactive at t are left out, as in the live catalog. The
+// live catalog itself is unaffected and keeps the current versions.
+func (c *InstrumentCatalog) AsOf(t time.Time) (*InstrumentView, error) {
+	versions, err := queryInstrumentVersions(c.db, QueryInstrumentsAsOf, t, t)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load instruments as of %s: %w", t, err)
+	}
+	instruments := make([]Instrument, 0, len(versions))
+	for _, version := range versions {
+		if version.Active {
+			instruments = append(instruments, version.Instrument)
+		}
+	}
+	return &InstrumentView{asOf: t, index: newInstrumentIndex(instruments)}, nil
+}
+
+// History returns every version of symbolID, oldest first.
+func (c *InstrumentCatalog) History(symbolID int) ([]InstrumentVersion, error) {
+	versions, err := queryInstrumentVersions(c.db, QueryInstrumentVersions, symbolID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load history of instrument %d: %w", symbolID, err)
+	}
+	return versions, nil
+}
+
+// queryInstrumentVersions runs query, which selects valid_from, valid_to and
+// definition, and decodes every row.
+func queryInstrumentVersions(db *gorm.DB, query string, args ...any) ([]InstrumentVersion, error) {
+	rows, err := db.Raw(query, args...).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []InstrumentVersion
+	for rows.Next() {
+		var version InstrumentVersion
+		var definition []byte
+		if err := rows.Scan(&version.ValidFrom, (*nullTime)(&version.ValidTo), &definition); err != nil {
+			return nil, err
+		}
+		if version.Instrument, err = decodeInstrumentDefinition(definition); err != nil {
+			return nil, err
+		}
+		versions = append(versions, version)
+	}
+	return versions, rows.Err()
+}
+
+// decodeInstrumentDefinition decodes an instruments row stored as JSON.
+func decodeInstrumentDefinition(definition []byte) (Instrument, error) {
+	var row map[string]json.RawMessage
+	if err := json.Unmarshal(definition, &row); err != nil {
+		return Instrument{}, fmt.Errorf("invalid instrument definition: %w", err)
+	}
+	return decodeInstrumentRow(row)
+}
+
+// AsOf returns the point in time the view was taken at.
+func (v *InstrumentView) AsOf() time.Time {
+	return v.asOf
+}
+
+func (v *InstrumentView) GetInstrument(symbolID int) (Instrument, error) {
+	return v.index.getInstrument(symbolID)
+}
+
+func (v *InstrumentView) GetInstrumentByVenueSymbol(exchange, symbol string) (Instrument, error) {
+	return v.index.getInstrumentByVenueSymbol(exchange, symbol)
+}
+
+// GetInstrumentsBySymbol returns the instruments trading a normalized symbol
+// such as "BTC-USDT". The returned slice is shared and must not be modified.
+func (v *InstrumentView) GetInstrumentsBySymbol(symbol string) []Instrument {
+	return v.index.bySymbol[symbol]
+}
+
+// GetInstrumentsByPair returns the instruments quoting baseCcyID in
+// quoteCcyID. The returned slice is shared and must not be modified.
+func (v *InstrumentView) GetInstrumentsByPair(baseCcyID, quoteCcyID int) []Instrument {
+	return v.index.byPair[pairKey{baseCcyID, quoteCcyID}]
+}
+
+// ListByExchange returns the instruments of exchange ordered by SymbolID.
+// The returned slice is shared and must not be modified.
+func (v *InstrumentView) ListByExchange(exchange string) []Instrument {
+	return v.index.byExchange[exchange]
+}
+
+// ListByType returns the instruments of type ordered by SymbolID.
+// The returned slice is shared and must not be modified.
+func (v *InstrumentView) ListByType(instrumentType string) []Instrument {
+	return v.index.byType[instrumentType]
+}
+
+// ListInstruments returns every instrument ordered by SymbolID.
+// The returned slice is shared and must not be modified.
+func (v *InstrumentView) ListInstruments() []Instrument {
+	return v.index.all
+}
@@ -0,0 +1,110 @@
+package pms
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/BullionBear/seq/internal/db/dbtest"
+	"gorm.io/gorm"
+)
+
+func TestDecodeInstrumentDefinition(t *testing.T) {
+	// As written by to_jsonb(instruments) in the versioning trigger.
+	definition := `{"symbol_id": 2, "exchange": "okx", "venue": "futures", "type": "future", "symbol": "BTC-USD-241227",
+		"base_ccy": "BTC", "base_ccy_id": 1, "quote_ccy": "USD", "quote_ccy_id": 3, "price_tick_size": 0.1, "qty_tick_size": 1,
+		"min_qty": 1, "min_notional": 0, "active": true, "calendar_id": 0, "contract_size": 100, "settle_ccy": "BTC",
+		"settle_ccy_id": 1, "inverse": true, "expiry": "2024-12-27T08:00:00+00:00", "strike": null, "option_type": "",
+		"underlying_id": 0, "funding_interval_sec": 0}`
+	instrument, err := decodeInstrumentDefinition([]byte(definition))
+	if err != nil {
+		t.Fatalf("decodeInstrumentDefinition failed: %v", err)
+	}
+	if instrument.SymbolID != 2 || !instrument.PriceTickSize.Equal(dec("0.1")) || !instrument.Inverse ||
+		!instrument.Expiry.Equal(time.Date(2024, 12, 27, 8, 0, 0, 0, time.UTC)) || !instrument.ContractSize.Equal(dec("100")) {
+		t.Errorf("Unexpected instrument: %+v", instrument)
+	}
+	if _, err := decodeInstrumentDefinition([]byte(`[]`)); err == nil {
+		t.Error("Expected error for a definition that is not an object")
+	}
+}
+
+func TestInstrumentView(t *testing.T) {
+	asOf := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	view := &InstrumentView{asOf: asOf, index: newInstrumentIndex([]Instrument{
+		{SymbolID: 1, Exchange: "binance", Type: "spot", Symbol: "BTCUSDT", BaseCcy: "BTC", BaseCcyID: 1, QuoteCcy: "USDT", QuoteCcyID: 2},
+	})}
+	if !view.AsOf().Equal(asOf) {
+		t.Errorf("Expected AsOf %s, got %s", asOf, view.AsOf())
+	}
+	if _, err := view.GetInstrumentByVenueSymbol("binance", "BTCUSDT"); err != nil {
+		t.Errorf("Expected BTCUSDT in view, got %v", err)
+	}
+	if _, err := view.GetInstrument(2); err == nil {
+		t.Error("Expected error for unknown instrument")
+	}
+	if len(view.GetInstrumentsByPair(1, 2)) != 1 || len(view.GetInstrumentsBySymbol("BTC-USDT")) != 1 || len(view.ListByType("spot")) != 1 {
+		t.Error("Expected BTCUSDT in every index")
+	}
+}
+
+func TestInstrumentCatalog_AsOf_Postgres(t *testing.T) {
+	pg := dbtest.Postgres(t)
+	now := func() time.Time {
+		var ts time.Time
+		if err := pg.DB.Raw(`SELECT clock_timestamp()`).Scan(&ts).Error; err != nil {
+			t.Fatalf("Failed to read database clock: %v", err)
+		}
+		return ts
+	}
+
+	beforeListing := now()
+	insertTestInstruments(t, pg.DB)
+	listed := now()
+	// Several changes in one transaction make a single version.
+	err := pg.DB.WithContext(context.Background()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`UPDATE instruments SET price_tick_size = 0.1 WHERE symbol_id = 1`).Error; err != nil {
+			return err
+		}
+		return tx.Exec(`UPDATE instruments SET min_notional = 5 WHERE symbol_id = 1`).Error
+	})
+	if err != nil {
+		t.Fatalf("Failed to update instrument: %v", err)
+	}
+	if err := pg.DB.Exec(`UPDATE instruments SET active = true WHERE symbol_id = 1`).Error; err != nil {
+		t.Fatalf("Failed to run no-op update: %v", err)
+	}
+	if err := pg.DB.Exec(`UPDATE instruments SET active = true WHERE symbol_id = 2`).Error; err != nil {
+		t.Fatalf("Failed to activate instrument: %v", err)
+	}
+
+	c, err := NewInstrumentCatalog(pg.DB)
+	if err != nil {
+		t.Fatalf("NewInstrumentCatalog failed: %v", err)
+	}
+	if view, err := c.AsOf(beforeListing); err != nil || len(view.ListInstruments()) != 0 {
+		t.Errorf("Expected no instruments before listing, got %v (%v)", view, err)
+	}
+	view, err := c.AsOf(listed)
+	if err != nil {
+		t.Fatalf("AsOf failed: %v", err)
+	}
+	if btc, err := view.GetInstrument(1); err != nil || !btc.PriceTickSize.Equal(dec("0.01")) {
+		t.Errorf("Expected historical tick 0.01, got %+v (%v)", btc, err)
+	}
+	if _, err := view.GetInstrument(2); err == nil {
+		t.Error("Expected ETHUSDT to be inactive at listing time")
+	}
+	if btc, err := c.GetInstrument(1); err != nil || !btc.PriceTickSize.Equal(dec("0.1")) {
+		t.Errorf("Expected live catalog to use tick 0.1, got %+v (%v)", btc, err)
+	}
+
+	history, err := c.History(1)
+	if err != nil {
+		t.Fatalf("History failed: %v", err)
+	}
+	if len(history) != 2 || !history[0].ValidTo.Equal(history[1].ValidFrom) || !history[1].ValidTo.IsZero() ||
+		!history[1].MinNotional.Equal(dec("5")) {
+		t.Errorf("Expected two contiguous versions, got %+v", history)
+	}
+}
@@ -0,0 +1,77 @@
+package pms
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type venueSymbolKey struct {
+	exchange string
+	symbol   string
+}
+
+type pairKey struct {
+	baseCcyID  int
+	quoteCcyID int
+}
+
+// instrumentIndex is an immutable snapshot of the catalog with one index per
+// lookup key. Slices are shared between callers and must not be modified.
+type instrumentIndex struct {
+	bySymbolID    map[int]Instrument
+	byVenueSymbol map[venueSymbolKey]Instrument
+	bySymbol      map[string][]Instrument
+	byPair        map[pairKey][]Instrument
+	byExchange    map[string][]Instrument
+	byType        map[string][]Instrument
+	all           []Instrument
+}
+
+func newInstrumentIndex(instruments []Instrument) *instrumentIndex {
+	all := make([]Instrument, len(instruments))
+	copy(all, instruments)
+	sort.Slice(all, func(i, j int) bool { return all[i].SymbolID < all[j].SymbolID })
+
+	idx := &instrumentIndex{
+		bySymbolID:    make(map[int]Instrument, len(all)),
+		byVenueSymbol: make(map[venueSymbolKey]Instrument, len(all)),
+		bySymbol:      make(map[string][]Instrument),
+		byPair:        make(map[pairKey][]Instrument),
+		byExchange:    make(map[string][]Instrument),
+		byType:        make(map[string][]Instrument),
+		all:           all,
+	}
+	for _, instrument := range all {
+		idx.bySymbolID[instrument.SymbolID] = instrument
+		idx.byVenueSymbol[venueSymbolKey{instrument.Exchange, instrument.Symbol}] = instrument
+		symbol := instrument.NormalizedSymbol()
+		idx.bySymbol[symbol] = append(idx.bySymbol[symbol], instrument)
+		pair := pairKey{instrument.BaseCcyID, instrument.QuoteCcyID}
+		idx.byPair[pair] = append(idx.byPair[pair], instrument)
+		idx.byExchange[instrument.Exchange] = append(idx.byExchange[instrument.Exchange], instrument)
+		idx.byType[instrument.Type] = append(idx.byType[instrument.Type], instrument)
+	}
+	return idx
+}
+
+func (idx *instrumentIndex) getInstrument(symbolID int) (Instrument, error) {
+	instrument, ok := idx.bySymbolID[symbolID]
+	if !ok {
+		return Instrument{}, fmt.Errorf("instrument not found for symbolID: %d", symbolID)
+	}
+	return instrument, nil
+}
+
+func (idx *instrumentIndex) getInstrumentByVenueSymbol(exchange, symbol string) (Instrument, error) {
+	instrument, ok := idx.byVenueSymbol[venueSymbolKey{exchange, symbol}]
+	if !ok {
+		return Instrument{}, fmt.Errorf("instrument not found for %s symbol: %s", exchange, symbol)
+	}
+	return instrument, nil
+}
+
+// NormalizedSymbol returns the venue-independent symbol, e.g. "BTC-USDT".
+func (i Instrument) NormalizedSymbol() string {
+	return strings.ToUpper(i.BaseCcy) + "-" + strings.ToUpper(i.QuoteCcy)
+}
@@ -0,0 +1,91 @@
+package pms
+
+import (
+	"testing"
+)
+
+func testCatalog(instruments ...Instrument) *InstrumentCatalog {
+	c := newInstrumentCatalog(nil)
+	c.index.Store(newInstrumentIndex(instruments))
+	return c
+}
+
+var testInstruments = []Instrument{
+	{SymbolID: 3, Exchange: "okx", Type: "spot", Symbol: "BTC-USDT", BaseCcy: "BTC", BaseCcyID: 1, QuoteCcy: "USDT", QuoteCcyID: 2, Active: true},
+	{SymbolID: 1, Exchange: "binance", Type: "spot", Symbol: "BTCUSDT", BaseCcy: "BTC", BaseCcyID: 1, QuoteCcy: "USDT", QuoteCcyID: 2, Active: true},
+	{SymbolID: 2, Exchange: "binance", Type: "perp", Symbol: "ETHUSDT", BaseCcy: "eth", BaseCcyID: 3, QuoteCcy: "usdt", QuoteCcyID: 2, Active: true},
+}
+
+func TestInstrumentCatalog_Lookups(t *testing.T) {
+	c := testCatalog(testInstruments...)
+
+	if got, err := c.GetInstrumentByVenueSymbol("binance", "BTCUSDT"); err != nil || got.SymbolID != 1 {
+		t.Errorf("Expected symbolID 1 for binance BTCUSDT, got %d (%v)", got.SymbolID, err)
+	}
+	if _, err := c.GetInstrumentByVenueSymbol("okx", "BTCUSDT"); err == nil {
+		t.Error("Expected error for unknown venue symbol")
+	}
+
+	btc := c.GetInstrumentsBySymbol("BTC-USDT")
+	if len(btc) != 2 || btc[0].SymbolID != 1 || btc[1].SymbolID != 3 {
+		t.Errorf("Expected BTC-USDT on symbolIDs [1 3], got %+v", btc)
+	}
+	if eth := c.GetInstrumentsBySymbol("ETH-USDT"); len(eth) != 1 || eth[0].SymbolID != 2 {
+		t.Errorf("Expected normalized ETH-USDT for symbolID 2, got %+v", eth)
+	}
+	if pair := c.GetInstrumentsByPair(1, 2); len(pair) != 2 {
+		t.Errorf("Expected 2 instruments for pair (1, 2), got %d", len(pair))
+	}
+	if binance := c.ListByExchange("binance"); len(binance) != 2 || binance[0].SymbolID != 1 {
+		t.Errorf("Expected binance instruments [1 2], got %+v", binance)
+	}
+	if perps := c.ListByType("perp"); len(perps) != 1 || perps[0].SymbolID != 2 {
+		t.Errorf("Expected perp instruments [2], got %+v", perps)
+	}
+	if all := c.ListInstruments(); len(all) != 3 || all[2].SymbolID != 3 {
+		t.Errorf("Expected 3 instruments ordered by symbolID, got %+v", all)
+	}
+	if missing := c.ListByExchange("kraken"); len(missing) != 0 {
+		t.Errorf("Expected no kraken instruments, got %d", len(missing))
+	}
+}
+
+func TestInstrumentCatalog_IndexesSwappedTogether(t *testing.T) {
+	c := testCatalog(testInstruments...)
+	c.index.Store(newInstrumentIndex(testInstruments[:1]))
+
+	if _, err := c.GetInstrument(1); err == nil {
+		t.Error("Expected symbolID 1 to be gone after reload")
+	}
+	if _, err := c.GetInstrumentByVenueSymbol("binance", "BTCUSDT"); err == nil {
+		t.Error("Expected venue index to be rebuilt with the reload")
+	}
+	if btc := c.GetInstrumentsBySymbol("BTC-USDT"); len(btc) != 1 {
+		t.Errorf("Expected 1 BTC-USDT instrument after reload, got %d", len(btc))
+	}
+}
+
+func TestInstrumentCatalog_LookupsZeroAllocation(t *testing.T) {
+	c := testCatalog(testInstruments...)
+	exchange, symbol := "binance", "BTCUSDT"
+	allocs := testing.AllocsPerRun(1000, func() {
+		_, _ = c.GetInstrument(1)
+		_, _ = c.GetInstrumentByVenueSymbol(exchange, symbol)
+		_ = c.GetInstrumentsBySymbol("BTC-USDT")
+		_ = c.GetInstrumentsByPair(1, 2)
+		_ = c.ListByExchange(exchange)
+	})
+	if allocs > 0 {
+		t.Errorf("Expected zero allocations, got %.2f", allocs)
+	}
+}
+
+// BenchmarkInstrumentCatalog_GetInstrumentByVenueSymbol benchmarks the venue adapter hot path
+func BenchmarkInstrumentCatalog_GetInstrumentByVenueSymbol(b *testing.B) {
+	c := testCatalog(testInstruments...)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = c.GetInstrumentByVenueSymbol("binance", "BTCUSDT")
+	}
+}
@@ -0,0 +1,196 @@
+package pms
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/BullionBear/seq/pkg/evbus"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// InstrumentChannel is the PostgreSQL notification channel the instruments
+// trigger (migration 000005) publishes row changes on.
+const InstrumentChannel = "instrument_changes"
+
+// InstrumentChangesPattern matches the topics of all instrument changes.
+const InstrumentChangesPattern = "catalog.instruments.*"
+
+// InstrumentChangeTopic returns the evbus topic carrying changes of symbolID.
+func InstrumentChangeTopic(symbolID int) string {
+	return fmt.Sprintf("catalog.instruments.%d", symbolID)
+}
+
+type ChangeKind int
+
+const (
+	ChangeAdded ChangeKind = iota + 1
+	ChangeUpdated
+	ChangeRemoved // Deleted or deactivated
+)
+
+// InstrumentChange describes a change applied to the catalog.
+type InstrumentChange struct {
+	Kind     ChangeKind
+	SymbolID int
+	Before   Instrument // Zero for ChangeAdded
+	After    Instrument // Zero for ChangeRemoved
+}
+
+// instrumentNotification is the payload sent by notify_instrument_change().
+type instrumentNotification struct {
+	Op  string                     `json:"op"`
+	Row map[string]json.RawMessage `json:"row"`
+}
+
+// SubscribeInstrumentChanges registers callback for every change applied by
+// Reload or the LISTEN/NOTIFY listener.
+func (c *InstrumentCatalog) SubscribeInstrumentChanges(callback func(*evbus.Event[InstrumentChange]) error, errCallback func(error)) (unsubscribe func(), err error) {
+	return c.changes.Subscribe(InstrumentChangesPattern, callback, errCallback)
+}
+
+// InstrumentChanges returns the bus instrument changes are published on.
+func (c *InstrumentCatalog) InstrumentChanges() *evbus.Bus[InstrumentChange] {
+	return c.changes
+}
+
+// Listen keeps the catalog in sync with the instruments table until ctx is
+// canceled. It opens a dedicated connection to dsn, LISTENs on
+// InstrumentChannel and applies each notified insert, update, deactivation
+// or delete as a copy-on-write snapshot swap, so readers never lock. The
+// catalog is reloaded once listening to pick up changes committed since it
+// was loaded. Listen returns an error when the connection is lost; callers
+// should call it again to resume.
+func (c *InstrumentCatalog) Listen(ctx context.Context, dsn string) error {
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return fmt.Errorf("failed to connect instrument listener: %w", err)
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+InstrumentChannel); err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", InstrumentChannel, err)
+	}
+	if err := c.Reload(); err != nil {
+		return err
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("instrument listener: %w", err)
+		}
+		if err := c.applyNotification([]byte(notification.Payload)); err != nil {
+			log.Error().Err(err).Str("payload", notification.Payload).Msg("Failed to apply instrument change")
+		}
+	}
+}
+
+func (c *InstrumentCatalog) applyNotification(payload []byte) error {
+	var n instrumentNotification
+	if err := json.Unmarshal(payload, &n); err != nil {
+		return err
+	}
+	instrument, err := decodeInstrumentRow(n.Row)
+	if err != nil {
+		return err
+	}
+	switch n.Op {
+	case "INSERT", "UPDATE":
+		c.apply(instrument, !instrument.Active)
+	case "DELETE":
+		c.apply(instrument, true)
+	default:
+		return fmt.Errorf("unknown instrument change op: %s", n.Op)
+	}
+	return nil
+}
+
+// decodeInstrumentRow maps a row_to_json object onto an Instrument using the
+// same column names as the loader.
+func decodeInstrumentRow(row map[string]json.RawMessage) (Instrument, error) {
+	var instrument Instrument
+	columns := make([]string, 0, len(row))
+	for column := range row {
+		columns = append(columns, column)
+	}
+	for i, target := range instrumentScanTargets(&instrument, columns) {
+		if err := json.Unmarshal(row[columns[i]], target); err != nil {
+			return Instrument{}, fmt.Errorf("column %s: %w", columns[i], err)
+		}
+	}
+	return instrument, nil
+}
+
+// apply upserts instrument, or removes it, by swapping in a new snapshot.
+func (c *InstrumentCatalog) apply(instrument Instrument, remove bool) {
+	c.mu.Lock()
+	old := c.index.Load()
+	before, existed := old.bySymbolID[instrument.SymbolID]
+
+	var change InstrumentChange
+	switch {
+	case remove && !existed:
+		c.mu.Unlock()
+		return
+	case remove:
+		change = InstrumentChange{Kind: ChangeRemoved, SymbolID: instrument.SymbolID, Before: before}
+	case !existed:
+		change = InstrumentChange{Kind: ChangeAdded, SymbolID: instrument.SymbolID, After: instrument}
+	case before.Equal(instrument):
+		c.mu.Unlock()
+		return
+	default:
+		change = InstrumentChange{Kind: ChangeUpdated, SymbolID: instrument.SymbolID, Before: before, After: instrument}
+	}
+
+	instruments := make([]Instrument, 0, len(old.all)+1)
+	for _, existing := range old.all {
+		if existing.SymbolID != instrument.SymbolID {
+			instruments = append(instruments, existing)
+		}
+	}
+	if !remove {
+		instruments = append(instruments, instrument)
+	}
+	c.index.Store(newInstrumentIndex(instruments))
+	c.mu.Unlock()
+
+	c.publishChanges([]InstrumentChange{change})
+}
+
+// diffIndexes lists the changes turning old into next, ordered by SymbolID.
+func diffIndexes(old, next *instrumentIndex) []InstrumentChange {
+	var changes []InstrumentChange
+	if old == nil {
+		old = newInstrumentIndex(nil)
+	}
+	for _, after := range next.all {
+		before, existed := old.bySymbolID[after.SymbolID]
+		switch {
+		case !existed:
+			changes = append(changes, InstrumentChange{Kind: ChangeAdded, SymbolID: after.SymbolID, After: after})
+		case !before.Equal(after):
+			changes = append(changes, InstrumentChange{Kind: ChangeUpdated, SymbolID: after.SymbolID, Before: before, After: after})
+		}
+	}
+	for _, before := range old.all {
+		if _, ok := next.bySymbolID[before.SymbolID]; !ok {
+			changes = append(changes, InstrumentChange{Kind: ChangeRemoved, SymbolID: before.SymbolID, Before: before})
+		}
+	}
+	return changes
+}
+
+func (c *InstrumentCatalog) publishChanges(changes []InstrumentChange) {
+	for _, change := range changes {
+		event := c.changeFactory.GetEvent()
+		event.Data = change
+		_ = c.changes.Publish(InstrumentChangeTopic(change.SymbolID), event)
+		c.changeFactory.PutEvent(event)
+	}
+}
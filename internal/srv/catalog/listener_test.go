@@ -0,0 +1,137 @@
+package pms
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/BullionBear/seq/internal/db/dbtest"
+	"github.com/BullionBear/seq/pkg/evbus"
+)
+
+const testRowJSON = `{"symbol_id":1,"exchange":"binance","type":"spot","symbol":"BTCUSDT","base_ccy":"BTC","base_ccy_id":1,"quote_ccy":"USDT","quote_ccy_id":2,"price_tick_size":%s,"qty_tick_size":0.0001,"active":%s,"venue":"spot"}`
+
+func notification(op, tick, active string) []byte {
+	return []byte(`{"op":"` + op + `","row":` + fmt.Sprintf(testRowJSON, tick, active) + `}`)
+}
+
+func collectChanges(t *testing.T, c *InstrumentCatalog) *[]InstrumentChange {
+	t.Helper()
+	changes := &[]InstrumentChange{}
+	_, err := c.SubscribeInstrumentChanges(func(e *evbus.Event[InstrumentChange]) error {
+		*changes = append(*changes, e.Data)
+		return nil
+	}, nil)
+	if err != nil {
+		t.Fatalf("SubscribeInstrumentChanges failed: %v", err)
+	}
+	return changes
+}
+
+func TestInstrumentCatalog_ApplyNotification(t *testing.T) {
+	c := testCatalog()
+	changes := collectChanges(t, c)
+
+	steps := []struct {
+		payload []byte
+		kind    ChangeKind
+		present bool
+	}{
+		{notification("INSERT", "0.01", "true"), ChangeAdded, true},
+		{notification("UPDATE", "0.01", "true"), 0, true}, // no-op update
+		{notification("UPDATE", "0.1", "true"), ChangeUpdated, true},
+		{notification("UPDATE", "0.1", "false"), ChangeRemoved, false},
+		{notification("DELETE", "0.1", "false"), 0, false}, // already gone
+		{notification("INSERT", "0.1", "true"), ChangeAdded, true},
+		{notification("DELETE", "0.1", "true"), ChangeRemoved, false},
+	}
+	for i, step := range steps {
+		before := len(*changes)
+		if err := c.applyNotification(step.payload); err != nil {
+			t.Fatalf("step %d: applyNotification failed: %v", i, err)
+		}
+		if step.kind == 0 {
+			if len(*changes) != before {
+				t.Errorf("step %d: expected no change, got %+v", i, (*changes)[before:])
+			}
+		} else if len(*changes) != before+1 || (*changes)[before].Kind != step.kind {
+			t.Errorf("step %d: expected change kind %d, got %+v", i, step.kind, (*changes)[before:])
+		}
+		_, err := c.GetInstrumentByVenueSymbol("binance", "BTCUSDT")
+		if (err == nil) != step.present {
+			t.Errorf("step %d: expected present=%t, got err %v", i, step.present, err)
+		}
+	}
+
+	updated := (*changes)[1]
+	if updated.Before.PriceTickSize.String() != "0.01" || updated.After.PriceTickSize.String() != "0.1" {
+		t.Errorf("Expected tick size change 0.01 -> 0.1, got %s -> %s", updated.Before.PriceTickSize, updated.After.PriceTickSize)
+	}
+	if updated.After.Venue != "spot" || updated.After.QuoteCcyID != 2 {
+		t.Errorf("Expected every column decoded, got %+v", updated.After)
+	}
+
+	if err := c.applyNotification([]byte(`{"op":"TRUNCATE","row":{}}`)); err == nil {
+		t.Error("Expected error for unknown op")
+	}
+}
+
+func TestDiffIndexes(t *testing.T) {
+	old := newInstrumentIndex(testInstruments[:2])
+	changed := testInstruments[1]
+	changed.Venue = "margin"
+	next := newInstrumentIndex([]Instrument{changed, testInstruments[2]})
+
+	changes := diffIndexes(old, next)
+	if len(changes) != 3 {
+		t.Fatalf("Expected 3 changes, got %+v", changes)
+	}
+	if changes[0].Kind != ChangeUpdated || changes[0].SymbolID != 1 {
+		t.Errorf("Expected symbolID 1 updated, got %+v", changes[0])
+	}
+	if changes[1].Kind != ChangeAdded || changes[1].SymbolID != 2 {
+		t.Errorf("Expected symbolID 2 added, got %+v", changes[1])
+	}
+	if changes[2].Kind != ChangeRemoved || changes[2].SymbolID != 3 {
+		t.Errorf("Expected symbolID 3 removed, got %+v", changes[2])
+	}
+}
+
+func TestInstrumentCatalog_Listen_Postgres(t *testing.T) {
+	pg := dbtest.Postgres(t)
+	c, err := NewInstrumentCatalog(pg.DB)
+	if err != nil {
+		t.Fatalf("NewInstrumentCatalog failed: %v", err)
+	}
+	received := make(chan InstrumentChange, 8)
+	_, _ = c.SubscribeInstrumentChanges(func(e *evbus.Event[InstrumentChange]) error {
+		received <- e.Data
+		return nil
+	}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- c.Listen(ctx, pg.URL) }()
+	defer func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Listen returned error: %v", err)
+		}
+	}()
+
+	// Give the listener time to subscribe before writing.
+	time.Sleep(200 * time.Millisecond)
+	insertTestInstruments(t, pg.DB)
+	select {
+	case change := <-received:
+		if change.Kind != ChangeAdded || change.SymbolID != 1 {
+			t.Errorf("Expected symbolID 1 added, got %+v", change)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for instrument change")
+	}
+	if _, err := c.GetInstrument(1); err != nil {
+		t.Errorf("Expected instrument 1 in catalog: %v", err)
+	}
+}
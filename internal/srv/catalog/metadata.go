@@ -0,0 +1,188 @@
+package pms
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	BinanceBaseURL = "https://api.binance.com"
+	OKXBaseURL     = "https://www.okx.com"
+)
+
+// MetadataSource lists the instruments an exchange currently offers.
+// Returned instruments carry exchange metadata only; SymbolID and the
+// currency IDs are assigned by the InstrumentSyncer.
+type MetadataSource interface {
+	Exchange() string
+	FetchInstruments(ctx context.Context) ([]Instrument, error)
+}
+
+// NewMetadataSource returns the source for exchange ("binance" or "okx").
+// An empty baseURL selects the exchange's public endpoint.
+func NewMetadataSource(exchange, baseURL string, client *http.Client) (MetadataSource, error) {
+	switch exchange {
+	case "binance":
+		return NewBinanceSource(baseURL, client), nil
+	case "okx":
+		return NewOKXSource(baseURL, client), nil
+	default:
+		return nil, fmt.Errorf("unsupported exchange: %s", exchange)
+	}
+}
+
+func defaultHTTPClient(client *http.Client) *http.Client {
+	if client == nil {
+		return &http.Client{Timeout: 10 * time.Second}
+	}
+	return client
+}
+
+// getJSON decodes the JSON response of a GET request to url into v.
+func getJSON(ctx context.Context, client *http.Client, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch %s: %s", url, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", url, err)
+	}
+	return nil
+}
+
+// BinanceSource reads spot instruments from Binance's exchangeInfo endpoint.
+type BinanceSource struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewBinanceSource(baseURL string, client *http.Client) *BinanceSource {
+	if baseURL == "" {
+		baseURL = BinanceBaseURL
+	}
+	return &BinanceSource{baseURL: strings.TrimSuffix(baseURL, "/"), client: defaultHTTPClient(client)}
+}
+
+func (s *BinanceSource) Exchange() string {
+	return "binance"
+}
+
+type binanceExchangeInfo struct {
+	Symbols []struct {
+		Symbol     string `json:"symbol"`
+		Status     string `json:"status"`
+		BaseAsset  string `json:"baseAsset"`
+		QuoteAsset string `json:"quoteAsset"`
+		Filters    []struct {
+			FilterType  string          `json:"filterType"`
+			TickSize    decimal.Decimal `json:"tickSize"`
+			StepSize    decimal.Decimal `json:"stepSize"`
+			MinQty      decimal.Decimal `json:"minQty"`
+			MinNotional decimal.Decimal `json:"minNotional"`
+		} `json:"filters"`
+	} `json:"symbols"`
+}
+
+func (s *BinanceSource) FetchInstruments(ctx context.Context) ([]Instrument, error) {
+	var info binanceExchangeInfo
+	if err := getJSON(ctx, s.client, s.baseURL+"/api/v3/exchangeInfo", &info); err != nil {
+		return nil, err
+	}
+	instruments := make([]Instrument, 0, len(info.Symbols))
+	for _, symbol := range info.Symbols {
+		instrument := Instrument{
+			Exchange: s.Exchange(),
+			Venue:    "spot",
+			Type:     "spot",
+			Symbol:   symbol.Symbol,
+			BaseCcy:  symbol.BaseAsset,
+			QuoteCcy: symbol.QuoteAsset,
+			Active:   symbol.Status == "TRADING",
+		}
+		for _, filter := range symbol.Filters {
+			switch filter.FilterType {
+			case "PRICE_FILTER":
+				instrument.PriceTickSize = filter.TickSize
+			case "LOT_SIZE":
+				instrument.QtyTickSize = filter.StepSize
+				instrument.MinQty = filter.MinQty
+			case "NOTIONAL", "MIN_NOTIONAL":
+				instrument.MinNotional = filter.MinNotional
+			}
+		}
+		instruments = append(instruments, instrument)
+	}
+	return instruments, nil
+}
+
+// OKXSource reads spot instruments from OKX's public instruments endpoint.
+// OKX does not publish a minimum notional, so MinNotional is left at zero.
+type OKXSource struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewOKXSource(baseURL string, client *http.Client) *OKXSource {
+	if baseURL == "" {
+		baseURL = OKXBaseURL
+	}
+	return &OKXSource{baseURL: strings.TrimSuffix(baseURL, "/"), client: defaultHTTPClient(client)}
+}
+
+func (s *OKXSource) Exchange() string {
+	return "okx"
+}
+
+type okxInstruments struct {
+	Code string `json:"code"`
+	Msg  string `json:"msg"`
+	Data []struct {
+		InstID   string          `json:"instId"`
+		BaseCcy  string          `json:"baseCcy"`
+		QuoteCcy string          `json:"quoteCcy"`
+		TickSz   decimal.Decimal `json:"tickSz"`
+		LotSz    decimal.Decimal `json:"lotSz"`
+		MinSz    decimal.Decimal `json:"minSz"`
+		State    string          `json:"state"`
+	} `json:"data"`
+}
+
+func (s *OKXSource) FetchInstruments(ctx context.Context) ([]Instrument, error) {
+	var resp okxInstruments
+	if err := getJSON(ctx, s.client, s.baseURL+"/api/v5/public/instruments?instType=SPOT", &resp); err != nil {
+		return nil, err
+	}
+	if resp.Code != "0" {
+		return nil, fmt.Errorf("okx instruments error %s: %s", resp.Code, resp.Msg)
+	}
+	instruments := make([]Instrument, 0, len(resp.Data))
+	for _, data := range resp.Data {
+		instruments = append(instruments, Instrument{
+			Exchange:      s.Exchange(),
+			Venue:         "spot",
+			Type:          "spot",
+			Symbol:        data.InstID,
+			BaseCcy:       data.BaseCcy,
+			QuoteCcy:      data.QuoteCcy,
+			PriceTickSize: data.TickSz,
+			QtyTickSize:   data.LotSz,
+			MinQty:        data.MinSz,
+			Active:        data.State == "live",
+		})
+	}
+	return instruments, nil
+}
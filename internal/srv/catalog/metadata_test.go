@@ -0,0 +1,103 @@
+package pms
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newFixtureServer serves the recorded responses in testdata by request path.
+func newFixtureServer(t *testing.T, fixtures map[string]string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fixture, ok := fixtures[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		http.ServeFile(w, r, fixture)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newBinanceFixtureSource(t *testing.T) *BinanceSource {
+	server := newFixtureServer(t, map[string]string{"/api/v3/exchangeInfo": "testdata/binance_exchange_info.json"})
+	return NewBinanceSource(server.URL, server.Client())
+}
+
+func TestBinanceSource_FetchInstruments(t *testing.T) {
+	instruments, err := newBinanceFixtureSource(t).FetchInstruments(context.Background())
+	if err != nil {
+		t.Fatalf("FetchInstruments failed: %v", err)
+	}
+	if len(instruments) != 4 {
+		t.Fatalf("Expected 4 instruments, got %d", len(instruments))
+	}
+
+	btc := instruments[1]
+	if btc.Exchange != "binance" || btc.Type != "spot" || btc.Symbol != "BTCUSDT" || btc.BaseCcy != "BTC" || btc.QuoteCcy != "USDT" || !btc.Active {
+		t.Errorf("Unexpected BTCUSDT metadata: %+v", btc)
+	}
+	for name, got := range map[string]string{
+		"price_tick_size": btc.PriceTickSize.String(),
+		"qty_tick_size":   btc.QtyTickSize.String(),
+		"min_qty":         btc.MinQty.String(),
+		"min_notional":    btc.MinNotional.String(),
+	} {
+		want := map[string]string{"price_tick_size": "0.01", "qty_tick_size": "0.00001", "min_qty": "0.00001", "min_notional": "5"}[name]
+		if got != want {
+			t.Errorf("Expected BTCUSDT %s %s, got %s", name, want, got)
+		}
+	}
+	if sol := instruments[2]; sol.MinNotional.String() != "0.0001" {
+		t.Errorf("Expected legacy MIN_NOTIONAL filter to be read, got %s", sol.MinNotional)
+	}
+	if bcc := instruments[3]; bcc.Active {
+		t.Error("Expected BREAK symbol to be inactive")
+	}
+}
+
+func TestOKXSource_FetchInstruments(t *testing.T) {
+	server := newFixtureServer(t, map[string]string{"/api/v5/public/instruments": "testdata/okx_instruments.json"})
+	instruments, err := NewOKXSource(server.URL, server.Client()).FetchInstruments(context.Background())
+	if err != nil {
+		t.Fatalf("FetchInstruments failed: %v", err)
+	}
+	if len(instruments) != 2 {
+		t.Fatalf("Expected 2 instruments, got %d", len(instruments))
+	}
+	btc := instruments[0]
+	if btc.Exchange != "okx" || btc.Symbol != "BTC-USDT" || !btc.Active {
+		t.Errorf("Unexpected BTC-USDT metadata: %+v", btc)
+	}
+	if btc.PriceTickSize.String() != "0.1" || btc.QtyTickSize.String() != "0.00000001" || btc.MinQty.String() != "0.00001" {
+		t.Errorf("Expected tick 0.1, lot 0.00000001, min 0.00001, got %s, %s, %s", btc.PriceTickSize, btc.QtyTickSize, btc.MinQty)
+	}
+	if instruments[1].Active {
+		t.Error("Expected suspended instrument to be inactive")
+	}
+}
+
+func TestMetadataSource_Errors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v5/public/instruments" {
+			_, _ = w.Write([]byte(`{"code":"50011","msg":"Too Many Requests","data":[]}`))
+			return
+		}
+		http.Error(w, "maintenance", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	if _, err := NewBinanceSource(server.URL, server.Client()).FetchInstruments(context.Background()); err == nil {
+		t.Error("Expected error for non-200 response")
+	}
+	if _, err := NewOKXSource(server.URL, server.Client()).FetchInstruments(context.Background()); err == nil {
+		t.Error("Expected error for OKX error code")
+	}
+	if _, err := NewMetadataSource("kraken", "", nil); err == nil {
+		t.Error("Expected error for unsupported exchange")
+	}
+}
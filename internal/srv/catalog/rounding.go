@@ -0,0 +1,121 @@
+package pms
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrBelowMinQty      = errors.New("quantity below minimum")
+	ErrBelowMinNotional = errors.New("notional below minimum")
+)
+
+type RoundingMode int
+
+const (
+	RoundDown    RoundingMode = iota // Toward negative infinity
+	RoundUp                          // Toward positive infinity
+	RoundNearest                     // Halves away from zero
+)
+
+// roundToStep rounds value to a multiple of step; a zero step leaves value
+// unchanged. The result is exact, unlike dividing and multiplying back.
+func roundToStep(value, step decimal.Decimal, mode RoundingMode) decimal.Decimal {
+	if step.Sign() <= 0 {
+		return value
+	}
+	q, r := value.QuoRem(step, 0)
+	if r.IsZero() {
+		return value
+	}
+	switch mode {
+	case RoundDown:
+		if r.Sign() < 0 {
+			q = q.Sub(decimal.NewFromInt(1))
+		}
+	case RoundUp:
+		if r.Sign() > 0 {
+			q = q.Add(decimal.NewFromInt(1))
+		}
+	case RoundNearest:
+		if r.Abs().Mul(decimal.NewFromInt(2)).GreaterThanOrEqual(step) {
+			q = q.Add(decimal.NewFromInt(int64(r.Sign())))
+		}
+	}
+	return q.Mul(step)
+}
+
+// RoundPrice rounds price to a multiple of PriceTickSize.
+func (i Instrument) RoundPrice(price decimal.Decimal, mode RoundingMode) decimal.Decimal {
+	return roundToStep(price, i.PriceTickSize, mode)
+}
+
+// RoundBuyPrice rounds a buy price down to the tick, so the order never
+// pays more than requested.
+func (i Instrument) RoundBuyPrice(price decimal.Decimal) decimal.Decimal {
+	return i.RoundPrice(price, RoundDown)
+}
+
+// RoundSellPrice rounds a sell price up to the tick, so the order never
+// receives less than requested.
+func (i Instrument) RoundSellPrice(price decimal.Decimal) decimal.Decimal {
+	return i.RoundPrice(price, RoundUp)
+}
+
+// RoundQty rounds qty toward zero to a multiple of QtyTickSize, so an order
+// never exceeds the requested size.
+func (i Instrument) RoundQty(qty decimal.Decimal) decimal.Decimal {
+	if qty.Sign() < 0 {
+		return roundToStep(qty, i.QtyTickSize, RoundUp)
+	}
+	return roundToStep(qty, i.QtyTickSize, RoundDown)
+}
+
+// CheckMinQty returns ErrBelowMinQty when the size of qty is below MinQty.
+func (i Instrument) CheckMinQty(qty decimal.Decimal) error {
+	if qty.Abs().LessThan(i.MinQty) {
+		return fmt.Errorf("%w: %s < %s on %s %s", ErrBelowMinQty, qty.Abs(), i.MinQty, i.Exchange, i.Symbol)
+	}
+	return nil
+}
+
+// CheckMinNotional returns ErrBelowMinNotional when the Notional of qty at
+// price is below MinNotional.
+func (i Instrument) CheckMinNotional(qty, price decimal.Decimal) error {
+	notional := i.Notional(qty, price).Abs()
+	if notional.LessThan(i.MinNotional) {
+		return fmt.Errorf("%w: %s < %s on %s %s", ErrBelowMinNotional, notional, i.MinNotional, i.Exchange, i.Symbol)
+	}
+	return nil
+}
+
+// FormatPrice formats price with as many decimal places as PriceTickSize,
+// e.g. "101.50" for a 0.01 tick, as venues require. It does not snap to the
+// tick; round the price first.
+func (i Instrument) FormatPrice(price decimal.Decimal) string {
+	return formatToStep(price, i.PriceTickSize)
+}
+
+// FormatQty formats qty with as many decimal places as QtyTickSize.
+func (i Instrument) FormatQty(qty decimal.Decimal) string {
+	return formatToStep(qty, i.QtyTickSize)
+}
+
+func formatToStep(value, step decimal.Decimal) string {
+	if step.Sign() <= 0 {
+		return value.String()
+	}
+	return value.StringFixed(stepPlaces(step))
+}
+
+// stepPlaces returns the number of decimal places of step ignoring trailing
+// zeros, e.g. 2 for 0.0100 and 0 for 10.
+func stepPlaces(step decimal.Decimal) int32 {
+	for places := int32(0); ; places++ {
+		if step.Equal(step.Truncate(places)) {
+			return places
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package pms
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+
+	"github.com/shopspring/decimal"
+)
+
+// steps are tick and lot sizes seen on real venues.
+var steps = []string{"0.00000001", "0.000001", "0.0001", "0.01", "0.05", "0.1", "0.25", "0.5", "1", "5", "10", "25"}
+
+// stepCase is a random value with up to 10 decimal places and a step size.
+type stepCase struct {
+	Value decimal.Decimal
+	Step  decimal.Decimal
+}
+
+func (stepCase) Generate(r *rand.Rand, _ int) reflect.Value {
+	value := decimal.New(r.Int63n(2_000_000_000_000)-1_000_000_000_000, -int32(r.Intn(11)))
+	return reflect.ValueOf(stepCase{Value: value, Step: decimal.RequireFromString(steps[r.Intn(len(steps))])})
+}
+
+func isMultiple(value, step decimal.Decimal) bool {
+	_, r := value.QuoRem(step, 0)
+	return r.IsZero()
+}
+
+func TestRoundToStep_Properties(t *testing.T) {
+	config := &quick.Config{MaxCount: 5000}
+	properties := map[string]any{
+		"result is a multiple of step": func(c stepCase) bool {
+			for _, mode := range []RoundingMode{RoundDown, RoundUp, RoundNearest} {
+				if !isMultiple(roundToStep(c.Value, c.Step, mode), c.Step) {
+					return false
+				}
+			}
+			return true
+		},
+		"down <= value <= up within one step": func(c stepCase) bool {
+			down, up := roundToStep(c.Value, c.Step, RoundDown), roundToStep(c.Value, c.Step, RoundUp)
+			gap := up.Sub(down)
+			return down.LessThanOrEqual(c.Value) && c.Value.LessThanOrEqual(up) && (gap.IsZero() || gap.Equal(c.Step))
+		},
+		"nearest is within half a step": func(c stepCase) bool {
+			nearest := roundToStep(c.Value, c.Step, RoundNearest)
+			return nearest.Sub(c.Value).Abs().Mul(decimal.NewFromInt(2)).LessThanOrEqual(c.Step)
+		},
+		"rounding is idempotent": func(c stepCase) bool {
+			for _, mode := range []RoundingMode{RoundDown, RoundUp, RoundNearest} {
+				once := roundToStep(c.Value, c.Step, mode)
+				if !roundToStep(once, c.Step, mode).Equal(once) {
+					return false
+				}
+			}
+			return true
+		},
+		"rounding is symmetric around zero": func(c stepCase) bool {
+			return roundToStep(c.Value.Neg(), c.Step, RoundDown).Equal(roundToStep(c.Value, c.Step, RoundUp).Neg()) &&
+				roundToStep(c.Value.Neg(), c.Step, RoundNearest).Equal(roundToStep(c.Value, c.Step, RoundNearest).Neg())
+		},
+		"qty rounds toward zero": func(c stepCase) bool {
+			instrument := Instrument{QtyTickSize: c.Step}
+			qty := instrument.RoundQty(c.Value)
+			return qty.Abs().LessThanOrEqual(c.Value.Abs()) && c.Value.Abs().Sub(qty.Abs()).LessThan(c.Step) &&
+				(qty.IsZero() || qty.Sign() == c.Value.Sign())
+		},
+		"side-aware prices never cross the limit": func(c stepCase) bool {
+			instrument := Instrument{PriceTickSize: c.Step}
+			return instrument.RoundBuyPrice(c.Value).LessThanOrEqual(c.Value) &&
+				instrument.RoundSellPrice(c.Value).GreaterThanOrEqual(c.Value)
+		},
+		"formatted rounded values parse back exactly": func(c stepCase) bool {
+			instrument := Instrument{PriceTickSize: c.Step}
+			price := instrument.RoundPrice(c.Value, RoundNearest)
+			parsed, err := decimal.NewFromString(instrument.FormatPrice(price))
+			return err == nil && parsed.Equal(price)
+		},
+	}
+	for name, property := range properties {
+		t.Run(name, func(t *testing.T) {
+			if err := quick.Check(property, config); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestInstrument_RoundPrice(t *testing.T) {
+	instrument := Instrument{PriceTickSize: dec("0.05")}
+	tests := []struct {
+		price string
+		mode  RoundingMode
+		want  string
+	}{
+		{"101.23", RoundDown, "101.2"},
+		{"101.23", RoundUp, "101.25"},
+		{"101.23", RoundNearest, "101.25"},
+		{"101.225", RoundNearest, "101.25"},
+		{"101.2249", RoundNearest, "101.2"},
+		{"-101.23", RoundDown, "-101.25"},
+		{"101.25", RoundUp, "101.25"},
+	}
+	for _, tt := range tests {
+		if got := instrument.RoundPrice(dec(tt.price), tt.mode); !got.Equal(dec(tt.want)) {
+			t.Errorf("Expected RoundPrice(%s, %d) = %s, got %s", tt.price, tt.mode, tt.want, got)
+		}
+	}
+	if got := (Instrument{}).RoundPrice(dec("1.2345"), RoundDown); !got.Equal(dec("1.2345")) {
+		t.Errorf("Expected zero tick to leave price unchanged, got %s", got)
+	}
+}
+
+func TestInstrument_Format(t *testing.T) {
+	instrument := Instrument{PriceTickSize: dec("0.01000000"), QtyTickSize: dec("10")}
+	if got := instrument.FormatPrice(dec("101.5")); got != "101.50" {
+		t.Errorf("Expected 101.50, got %s", got)
+	}
+	if got := instrument.FormatQty(dec("120")); got != "120" {
+		t.Errorf("Expected 120, got %s", got)
+	}
+	if got := (Instrument{QtyTickSize: dec("0.00001")}).FormatQty(dec("0.1")); got != "0.10000" {
+		t.Errorf("Expected 0.10000, got %s", got)
+	}
+	if got := (Instrument{}).FormatPrice(dec("1.230")); got != "1.23" {
+		t.Errorf("Expected 1.23 without a tick, got %s", got)
+	}
+}
+
+func TestInstrument_CheckMinimums(t *testing.T) {
+	instrument := Instrument{Exchange: "binance", Symbol: "BTCUSDT", MinQty: dec("0.0001"), MinNotional: dec("5")}
+	if err := instrument.CheckMinQty(dec("0.00009")); !errors.Is(err, ErrBelowMinQty) {
+		t.Errorf("Expected ErrBelowMinQty, got %v", err)
+	}
+	if err := instrument.CheckMinQty(dec("-0.0001")); err != nil {
+		t.Errorf("Expected short of minimum size to pass, got %v", err)
+	}
+	if err := instrument.CheckMinNotional(dec("0.0001"), dec("49999")); !errors.Is(err, ErrBelowMinNotional) {
+		t.Errorf("Expected ErrBelowMinNotional, got %v", err)
+	}
+	if err := instrument.CheckMinNotional(dec("0.0001"), dec("50000")); err != nil {
+		t.Errorf("Expected notional of exactly 5 to pass, got %v", err)
+	}
+
+	inverse := Instrument{ContractSize: dec("100"), Inverse: true, MinNotional: dec("0.001")}
+	if err := inverse.CheckMinNotional(dec("1"), dec("50000")); err != nil {
+		t.Errorf("Expected 0.002 BTC notional to pass, got %v", err)
+	}
+	if err := inverse.CheckMinNotional(dec("1"), dec("200000")); !errors.Is(err, ErrBelowMinNotional) {
+		t.Errorf("Expected 0.0005 BTC notional to fail, got %v", err)
+	}
+}
@@ -0,0 +1,286 @@
+package pms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	QueryAllInstruments = `
+		SELECT * FROM instruments
+	`
+	InsertInstrument = `
+		INSERT INTO instruments (symbol_id, exchange, venue, type, symbol, base_ccy, base_ccy_id, quote_ccy, quote_ccy_id, price_tick_size, qty_tick_size, min_qty, min_notional, active, calendar_id,
+			contract_size, settle_ccy, settle_ccy_id, inverse, expiry, strike, option_type, underlying_id, funding_interval_sec)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	UpdateInstrumentTradingRules = `
+		UPDATE instruments SET price_tick_size = ?, qty_tick_size = ?, min_qty = ?, min_notional = ?, active = ?
+		WHERE symbol_id = ?
+	`
+	DeactivateInstrument = `
+		UPDATE instruments SET active = false WHERE symbol_id = ?
+	`
+)
+
+// SyncPlan is the set of changes bringing an exchange's rows in the
+// instruments table in line with its published metadata. ChangeRemoved
+// entries deactivate the instrument; rows are never deleted.
+type SyncPlan struct {
+	Exchange string
+	Changes  []InstrumentChange
+	Skipped  []SkippedInstrument // Listed instruments that cannot be added
+}
+
+// SkippedInstrument is a listed instrument left out of a SyncPlan, e.g.
+// because a currency of it is missing from the currencies table.
+type SkippedInstrument struct {
+	Instrument Instrument
+	Err        error
+}
+
+// InstrumentSyncer keeps the instruments of one exchange in sync with the
+// metadata published by its MetadataSource. The currencies of new
+// instruments are resolved through currencies.
+type InstrumentSyncer struct {
+	db         *gorm.DB
+	source     MetadataSource
+	currencies *CurrencyRegistry
+}
+
+func NewInstrumentSyncer(db *gorm.DB, source MetadataSource, currencies *CurrencyRegistry) *InstrumentSyncer {
+	return &InstrumentSyncer{db: db, source: source, currencies: currencies}
+}
+
+// Plan fetches the exchange metadata and diffs it against the database
+// without writing anything. The currency registry is reloaded first, so
+// currencies added since it was created are known.
+func (s *InstrumentSyncer) Plan(ctx context.Context) (SyncPlan, error) {
+	listed, err := s.source.FetchInstruments(ctx)
+	if err != nil {
+		return SyncPlan{}, err
+	}
+	if err := s.currencies.Reload(); err != nil {
+		return SyncPlan{}, fmt.Errorf("failed to load currencies: %w", err)
+	}
+	existing, err := queryInstruments(s.db.WithContext(ctx), QueryAllInstruments)
+	if err != nil {
+		return SyncPlan{}, fmt.Errorf("failed to load instruments: %w", err)
+	}
+	return planSync(s.source.Exchange(), s.currencies, existing, listed), nil
+}
+
+// Apply writes plan in a single transaction. Live catalogs pick the changes
+// up through the instruments notification trigger.
+func (s *InstrumentSyncer) Apply(ctx context.Context, plan SyncPlan) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, change := range plan.Changes {
+			var err error
+			switch change.Kind {
+			case ChangeAdded:
+				err = insertInstrument(tx, change.After)
+			case ChangeUpdated:
+				i := change.After
+				err = tx.Exec(UpdateInstrumentTradingRules, i.PriceTickSize, i.QtyTickSize, i.MinQty, i.MinNotional, i.Active, i.SymbolID).Error
+			case ChangeRemoved:
+				err = tx.Exec(DeactivateInstrument, change.SymbolID).Error
+			}
+			if err != nil {
+				return fmt.Errorf("failed to sync %s %s: %w", plan.Exchange, changeSymbol(change), err)
+			}
+		}
+		return nil
+	})
+}
+
+func insertInstrument(tx *gorm.DB, i Instrument) error {
+	return execInstrument(tx, InsertInstrument, i)
+}
+
+// execInstrument runs query, which takes the InsertInstrument arguments.
+func execInstrument(tx *gorm.DB, query string, i Instrument) error {
+	return tx.Exec(query, i.SymbolID, i.Exchange, i.Venue, i.Type, i.Symbol, i.BaseCcy, i.BaseCcyID,
+		i.QuoteCcy, i.QuoteCcyID, i.PriceTickSize, i.QtyTickSize, i.MinQty, i.MinNotional, i.Active, i.CalendarID,
+		i.Multiplier(), i.SettleCcy, nullableID(i.SettleCcyID), i.Inverse, nullableTime(i.Expiry), i.Strike, string(i.OptionType),
+		i.UnderlyingID, int64(i.FundingInterval/time.Second)).Error
+}
+
+// planSync diffs the instruments listed by exchange against every existing
+// row. Listed instruments are matched on (type, symbol). New instruments get
+// the next free SymbolID and the currency IDs currencies resolves their
+// currency codes on exchange to; new instruments with an unknown currency
+// are skipped. Existing active rows of a listed type that are no longer
+// listed are deactivated.
+func planSync(exchange string, currencies *CurrencyRegistry, existing, listed []Instrument) SyncPlan {
+	type key struct{ instrumentType, symbol string }
+
+	nextSymbolID := 1
+	current := make(map[key]Instrument)
+	for _, instrument := range existing {
+		nextSymbolID = max(nextSymbolID, instrument.SymbolID+1)
+		if instrument.Exchange == exchange {
+			current[key{instrument.Type, instrument.Symbol}] = instrument
+		}
+	}
+	resolve := func(instrument *Instrument) error {
+		var problems []error
+		for _, ccy := range []struct {
+			code string
+			id   *int
+		}{
+			{instrument.BaseCcy, &instrument.BaseCcyID},
+			{instrument.QuoteCcy, &instrument.QuoteCcyID},
+			{instrument.SettleCcy, &instrument.SettleCcyID},
+		} {
+			if ccy.code == "" {
+				continue
+			}
+			currency, err := currencies.GetCurrencyByAlias(exchange, ccy.code)
+			if err != nil {
+				problems = append(problems, err)
+				continue
+			}
+			*ccy.id = currency.CcyID
+		}
+		return errors.Join(problems...)
+	}
+
+	listed = append([]Instrument(nil), listed...)
+	sort.Slice(listed, func(i, j int) bool {
+		if listed[i].Type != listed[j].Type {
+			return listed[i].Type < listed[j].Type
+		}
+		return listed[i].Symbol < listed[j].Symbol
+	})
+
+	plan := SyncPlan{Exchange: exchange}
+	seen := make(map[key]bool, len(listed))
+	listedTypes := make(map[string]bool)
+	for _, instrument := range listed {
+		k := key{instrument.Type, instrument.Symbol}
+		seen[k] = true
+		listedTypes[instrument.Type] = true
+		before, ok := current[k]
+		switch {
+		case !ok && instrument.Active:
+			if err := resolve(&instrument); err != nil {
+				plan.Skipped = append(plan.Skipped, SkippedInstrument{Instrument: instrument, Err: err})
+				continue
+			}
+			instrument.SymbolID = nextSymbolID
+			nextSymbolID++
+			plan.Changes = append(plan.Changes, InstrumentChange{Kind: ChangeAdded, SymbolID: instrument.SymbolID, After: instrument})
+		case !ok:
+			// Never add instruments that are not trading.
+		case before.Active && !instrument.Active:
+			plan.Changes = append(plan.Changes, InstrumentChange{Kind: ChangeRemoved, SymbolID: before.SymbolID, Before: before})
+		default:
+			after := before
+			after.PriceTickSize = instrument.PriceTickSize
+			after.QtyTickSize = instrument.QtyTickSize
+			after.MinQty = instrument.MinQty
+			after.MinNotional = instrument.MinNotional
+			after.Active = instrument.Active
+			if !before.Equal(after) {
+				plan.Changes = append(plan.Changes, InstrumentChange{Kind: ChangeUpdated, SymbolID: before.SymbolID, Before: before, After: after})
+			}
+		}
+	}
+
+	var delisted []InstrumentChange
+	for k, before := range current {
+		if before.Active && listedTypes[k.instrumentType] && !seen[k] {
+			delisted = append(delisted, InstrumentChange{Kind: ChangeRemoved, SymbolID: before.SymbolID, Before: before})
+		}
+	}
+	sort.Slice(delisted, func(i, j int) bool { return delisted[i].SymbolID < delisted[j].SymbolID })
+	plan.Changes = append(plan.Changes, delisted...)
+	return plan
+}
+
+// Counts returns the number of added, updated and deactivated instruments.
+func (p SyncPlan) Counts() (added, updated, removed int) {
+	for _, change := range p.Changes {
+		switch change.Kind {
+		case ChangeAdded:
+			added++
+		case ChangeUpdated:
+			updated++
+		case ChangeRemoved:
+			removed++
+		}
+	}
+	return added, updated, removed
+}
+
+// Print writes a human-readable diff of the plan to w, one line per change:
+// "+" for additions, "~" for updates and "-" for deactivations, followed by
+// one "!" line per skipped instrument.
+func (p SyncPlan) Print(w io.Writer) error {
+	added, updated, removed := p.Counts()
+	if _, err := fmt.Fprintf(w, "%s: %d added, %d updated, %d deactivated, %d skipped\n",
+		p.Exchange, added, updated, removed, len(p.Skipped)); err != nil {
+		return err
+	}
+	for _, change := range p.Changes {
+		var line string
+		switch change.Kind {
+		case ChangeAdded:
+			i := change.After
+			line = fmt.Sprintf("+ %d %s %s %s-%s price_tick_size=%s qty_tick_size=%s min_qty=%s min_notional=%s",
+				i.SymbolID, i.Type, i.Symbol, i.BaseCcy, i.QuoteCcy, i.PriceTickSize, i.QtyTickSize, i.MinQty, i.MinNotional)
+		case ChangeUpdated:
+			line = fmt.Sprintf("~ %d %s %s %s", change.SymbolID, change.After.Type, change.After.Symbol,
+				strings.Join(tradingRuleDiff(change.Before, change.After), " "))
+		case ChangeRemoved:
+			line = fmt.Sprintf("- %d %s %s", change.SymbolID, change.Before.Type, change.Before.Symbol)
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	for _, skipped := range p.Skipped {
+		i := skipped.Instrument
+		reason := strings.ReplaceAll(skipped.Err.Error(), "\n", "; ")
+		if _, err := fmt.Fprintf(w, "! %s %s %s-%s: %s\n", i.Type, i.Symbol, i.BaseCcy, i.QuoteCcy, reason); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tradingRuleDiff lists the synced fields that differ as "field=old->new".
+func tradingRuleDiff(before, after Instrument) []string {
+	var diff []string
+	for _, field := range []struct {
+		name          string
+		before, after fmt.Stringer
+	}{
+		{"price_tick_size", before.PriceTickSize, after.PriceTickSize},
+		{"qty_tick_size", before.QtyTickSize, after.QtyTickSize},
+		{"min_qty", before.MinQty, after.MinQty},
+		{"min_notional", before.MinNotional, after.MinNotional},
+	} {
+		if b, a := field.before.String(), field.after.String(); b != a {
+			diff = append(diff, fmt.Sprintf("%s=%s->%s", field.name, b, a))
+		}
+	}
+	if before.Active != after.Active {
+		diff = append(diff, fmt.Sprintf("active=%t->%t", before.Active, after.Active))
+	}
+	return diff
+}
+
+func changeSymbol(change InstrumentChange) string {
+	if change.Kind == ChangeRemoved {
+		return change.Before.Symbol
+	}
+	return change.After.Symbol
+}
@@ -0,0 +1,131 @@
+package pms
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/BullionBear/seq/internal/db/dbtest"
+	"github.com/shopspring/decimal"
+)
+
+func spot(symbolID int, symbol, base, quote string, tick string, active bool) Instrument {
+	return Instrument{
+		SymbolID: symbolID, Exchange: "binance", Venue: "spot", Type: "spot", Symbol: symbol,
+		BaseCcy: base, QuoteCcy: quote,
+		PriceTickSize: decimal.RequireFromString(tick), QtyTickSize: decimal.RequireFromString("0.001"),
+		Active: active,
+	}
+}
+
+func TestPlanSync(t *testing.T) {
+	existing := []Instrument{
+		spot(1, "BTCUSDT", "BTC", "USDT", "0.01", true),
+		spot(2, "ETHUSDT", "ETH", "USDT", "0.01", true),
+		spot(3, "XRPUSDT", "XRP", "USDT", "0.0001", true),
+		spot(4, "LTCUSDT", "LTC", "USDT", "0.01", false),
+		spot(5, "BNBUSDT", "BNB", "USDT", "0.1", true),
+		{SymbolID: 7, Exchange: "binance", Type: "perp", Symbol: "BTCUSDT", BaseCcy: "BTC", QuoteCcy: "USDT", Active: true},
+		{SymbolID: 9, Exchange: "okx", Type: "spot", Symbol: "SOL-USDT", BaseCcy: "SOL", BaseCcyID: 8, QuoteCcy: "USDT", Active: true},
+	}
+	for i := range existing[:5] {
+		existing[i].BaseCcyID, existing[i].QuoteCcyID = i+3, 2
+	}
+	currencies := &CurrencyRegistry{}
+	currencies.index.Store(newCurrencyIndex([]Currency{
+		{CcyID: 2, Code: "USDT"}, {CcyID: 3, Code: "BTC"}, {CcyID: 4, Code: "ETH"}, {CcyID: 5, Code: "XRP"},
+		{CcyID: 6, Code: "LTC"}, {CcyID: 7, Code: "BNB"}, {CcyID: 8, Code: "SOL"}, {CcyID: 9, Code: "DOGE"},
+		{CcyID: 10, Code: "BCH"},
+	}, map[exchangeAliasKey]int{{"binance", "BCHABC"}: 10}))
+	listed := []Instrument{
+		spot(0, "ETHUSDT", "ETH", "USDT", "0.1", true),
+		spot(0, "BTCUSDT", "BTC", "USDT", "0.01", true),
+		spot(0, "LTCUSDT", "LTC", "USDT", "0.01", true),
+		spot(0, "BNBUSDT", "BNB", "USDT", "0.1", false),
+		spot(0, "SOLUSDT", "SOL", "USDT", "0.01", true),
+		spot(0, "DOGEUSDT", "DOGE", "USDT", "0.00001", true),
+		spot(0, "BCCUSDT", "BCC", "USDT", "0.01", false),
+		spot(0, "BCHABCUSDT", "BCHABC", "USDT", "0.01", true),
+		spot(0, "NEWUSDT", "NEW", "USDT", "0.01", true),
+	}
+
+	plan := planSync("binance", currencies, existing, listed)
+	if added, updated, removed := plan.Counts(); added != 3 || updated != 2 || removed != 2 || len(plan.Skipped) != 1 {
+		t.Fatalf("Expected 3 added, 2 updated, 2 deactivated, 1 skipped, got %d, %d, %d, %d: %+v", added, updated, removed, len(plan.Skipped), plan)
+	}
+
+	var out bytes.Buffer
+	if err := plan.Print(&out); err != nil {
+		t.Fatalf("Print failed: %v", err)
+	}
+	want := `binance: 3 added, 2 updated, 2 deactivated, 1 skipped
++ 10 spot BCHABCUSDT BCHABC-USDT price_tick_size=0.01 qty_tick_size=0.001 min_qty=0 min_notional=0
+- 5 spot BNBUSDT
++ 11 spot DOGEUSDT DOGE-USDT price_tick_size=0.00001 qty_tick_size=0.001 min_qty=0 min_notional=0
+~ 2 spot ETHUSDT price_tick_size=0.01->0.1
+~ 4 spot LTCUSDT active=false->true
++ 12 spot SOLUSDT SOL-USDT price_tick_size=0.01 qty_tick_size=0.001 min_qty=0 min_notional=0
+- 3 spot XRPUSDT
+! spot NEWUSDT NEW-USDT: currency not found for binance alias: NEW
+`
+	if out.String() != want {
+		t.Errorf("Expected plan:\n%s\ngot:\n%s", want, out.String())
+	}
+
+	bch, doge := plan.Changes[0].After, plan.Changes[2].After
+	if doge.BaseCcyID != 9 || doge.QuoteCcyID != 2 {
+		t.Errorf("Expected DOGE-USDT to resolve to currency IDs 9/2, got %d/%d", doge.BaseCcyID, doge.QuoteCcyID)
+	}
+	if bch.BaseCcyID != 10 {
+		t.Errorf("Expected the binance alias BCHABC to resolve to BCH, got %d", bch.BaseCcyID)
+	}
+
+	if replan := planSync("binance", currencies, existing, nil); len(replan.Changes) != 0 {
+		t.Errorf("Expected an empty listing to change nothing, got %+v", replan.Changes)
+	}
+}
+
+func TestInstrumentSyncer_Postgres(t *testing.T) {
+	pg := dbtest.Postgres(t)
+	insertTestInstruments(t, pg.DB)
+	currencies, err := NewCurrencyRegistry(pg.DB)
+	if err != nil {
+		t.Fatalf("NewCurrencyRegistry failed: %v", err)
+	}
+	syncer := NewInstrumentSyncer(pg.DB, newBinanceFixtureSource(t), currencies)
+	ctx := context.Background()
+
+	plan, err := syncer.Plan(ctx)
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	if added, updated, removed := plan.Counts(); added != 1 || updated != 2 || removed != 0 {
+		t.Fatalf("Expected 1 added and 2 updated, got %d, %d, %d: %+v", added, updated, removed, plan.Changes)
+	}
+	if err := syncer.Apply(ctx, plan); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	catalog, err := NewInstrumentCatalog(pg.DB)
+	if err != nil {
+		t.Fatalf("NewInstrumentCatalog failed: %v", err)
+	}
+	sol, err := catalog.GetInstrumentByVenueSymbol("binance", "SOLBTC")
+	if err != nil {
+		t.Fatalf("Expected SOLBTC to be added: %v", err)
+	}
+	if sol.SymbolID != 3 || sol.BaseCcyID != 4 || sol.QuoteCcyID != 1 || sol.PriceTickSize.String() != "0.000001" {
+		t.Errorf("Unexpected SOLBTC row: %+v", sol)
+	}
+	eth, err := catalog.GetInstrument(2)
+	if err != nil {
+		t.Fatalf("Expected ETHUSDT to be reactivated: %v", err)
+	}
+	if eth.MinNotional.String() != "5" || eth.MinQty.String() != "0.0001" {
+		t.Errorf("Expected ETHUSDT min qty 0.0001 and min notional 5, got %s/%s", eth.MinQty, eth.MinNotional)
+	}
+
+	if plan, err := syncer.Plan(ctx); err != nil || len(plan.Changes) != 0 {
+		t.Errorf("Expected no changes after sync, got %+v (%v)", plan.Changes, err)
+	}
+}
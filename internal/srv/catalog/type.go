@@ -1,6 +1,10 @@
 package pms
 
-import "github.com/shopspring/decimal"
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
 
 type Instrument struct {
 	SymbolID      int
@@ -9,8 +13,60 @@ type Instrument struct {
 	Type          string
 	Symbol        string
 	BaseCcy       string
+	BaseCcyID     int
 	QuoteCcy      string
+	QuoteCcyID    int
 	PriceTickSize decimal.Decimal
 	QtyTickSize   decimal.Decimal
+	MinQty        decimal.Decimal
+	MinNotional   decimal.Decimal
 	Active        bool
+	CalendarID    int // Trading calendar, 0 if always tradable
+
+	// Contract specification of perpetuals, futures and options. Spot
+	// instruments leave these at their zero values.
+	ContractSize    decimal.Decimal // Base units (linear) or quote units (inverse) per contract; zero means 1
+	SettleCcy       string
+	SettleCcyID     int
+	Inverse         bool      // Margined and settled in the base currency
+	Expiry          time.Time // Zero for spot and perpetuals
+	Strike          decimal.Decimal
+	OptionType      OptionType
+	UnderlyingID    int           // SymbolID of the underlying, 0 if none
+	FundingInterval time.Duration // Perpetuals only
+}
+
+type OptionType string
+
+const (
+	OptionCall OptionType = "call"
+	OptionPut  OptionType = "put"
+)
+
+// Equal reports whether i and o describe the same instrument definition.
+func (i Instrument) Equal(o Instrument) bool {
+	return i.SymbolID == o.SymbolID &&
+		i.Exchange == o.Exchange &&
+		i.Venue == o.Venue &&
+		i.Type == o.Type &&
+		i.Symbol == o.Symbol &&
+		i.BaseCcy == o.BaseCcy &&
+		i.BaseCcyID == o.BaseCcyID &&
+		i.QuoteCcy == o.QuoteCcy &&
+		i.QuoteCcyID == o.QuoteCcyID &&
+		i.PriceTickSize.Equal(o.PriceTickSize) &&
+		i.QtyTickSize.Equal(o.QtyTickSize) &&
+		i.MinQty.Equal(o.MinQty) &&
+		i.MinNotional.Equal(o.MinNotional) &&
+		i.Active == o.Active &&
+		i.CalendarID == o.CalendarID &&
+		i.ContractSize.Equal(o.ContractSize) &&
+		i.SettleCcy == o.SettleCcy &&
+		i.SettleCcyID == o.SettleCcyID &&
+		i.Inverse == o.Inverse &&
+		i.Expiry.Equal(o.Expiry) &&
+		i.Strike.Equal(o.Strike) &&
+		i.OptionType == o.OptionType &&
+		i.UnderlyingID == o.UnderlyingID &&
+		i.FundingInterval == o.FundingInterval
 }
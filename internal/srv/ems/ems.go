@@ -1,6 +1,8 @@
 package ems
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/BullionBear/seq/internal/srv/sms"
@@ -9,25 +11,92 @@ import (
 
 type ExecutionManager struct {
 	sms                *sms.SecretManager
+	clock              evbus.Clock
 	clientOrderID      int
 	activeOrders       map[int]Order  // index by clientOrderID
 	client             map[int]Client // acctID to client
 	orderUpdateFactory *evbus.EventFactory[OrderUpdate]
 	orderFillFactory   *evbus.EventFactory[OrderFill]
+	orderUpdateBus     *evbus.Bus[OrderUpdate]
+	orderFillBus       *evbus.Bus[OrderFill]
+	sessions           SessionChecker
+}
+
+// ErrMarketClosed is returned for orders on instruments out of session.
+var ErrMarketClosed = errors.New("market closed")
+
+// SessionChecker reports whether an instrument is tradable at a time. It is
+// implemented by the instrument catalog.
+type SessionChecker interface {
+	IsOpen(symbolID int, t time.Time) (bool, error)
+}
+
+// OrderUpdateTopic returns the evbus topic carrying order updates for acctID.
+func OrderUpdateTopic(acctID int) string {
+	return fmt.Sprintf("orders.%d.updates", acctID)
+}
+
+// OrderFillTopic returns the evbus topic carrying fills for acctID.
+func OrderFillTopic(acctID int) string {
+	return fmt.Sprintf("orders.%d.fills", acctID)
 }
 
 func NewExecutionManager(sms *sms.SecretManager, orderSize int) *ExecutionManager {
+	return NewExecutionManagerWithClock(sms, orderSize, evbus.DefaultClock)
+}
+
+// NewExecutionManagerWithClock creates an ExecutionManager whose order and
+// event timestamps come from clock, so backtests and replays can drive time.
+func NewExecutionManagerWithClock(sms *sms.SecretManager, orderSize int, clock evbus.Clock) *ExecutionManager {
+	if clock == nil {
+		clock = evbus.DefaultClock
+	}
 	return &ExecutionManager{
 		sms:           sms,
+		clock:         clock,
 		clientOrderID: 0,
 		activeOrders:  make(map[int]Order, orderSize),
-		orderUpdateFactory: evbus.NewEventFactory(func(o *OrderUpdate) {
+		orderUpdateFactory: evbus.NewEventFactoryWithClock(clock, func(o *OrderUpdate) {
 			o.Reset()
 		}),
-		orderFillFactory: evbus.NewEventFactory(func(f *OrderFill) {
+		orderFillFactory: evbus.NewEventFactoryWithClock(clock, func(f *OrderFill) {
 			f.Reset()
 		}),
+		orderUpdateBus: evbus.NewBus[OrderUpdate](),
+		orderFillBus:   evbus.NewBus[OrderFill](),
+	}
+}
+
+// OrderUpdateBus returns the bus order updates are published on, e.g. for
+// bridging them to other processes.
+func (e *ExecutionManager) OrderUpdateBus() *evbus.Bus[OrderUpdate] {
+	return e.orderUpdateBus
+}
+
+// OrderFillBus returns the bus fills are published on.
+func (e *ExecutionManager) OrderFillBus() *evbus.Bus[OrderFill] {
+	return e.orderFillBus
+}
+
+// SetSessionChecker makes the manager reject orders on instruments that are
+// out of session. A nil checker accepts orders at any time.
+func (e *ExecutionManager) SetSessionChecker(sessions SessionChecker) {
+	e.sessions = sessions
+}
+
+// checkSession returns ErrMarketClosed when symbolID is out of session at now.
+func (e *ExecutionManager) checkSession(symbolID int, now time.Time) error {
+	if e.sessions == nil {
+		return nil
+	}
+	open, err := e.sessions.IsOpen(symbolID, now)
+	if err != nil {
+		return err
 	}
+	if !open {
+		return fmt.Errorf("%w: symbolID %d at %s", ErrMarketClosed, symbolID, now.Format(time.RFC3339))
+	}
+	return nil
 }
 
 func (e *ExecutionManager) MakeLimitOrder(
@@ -37,6 +106,10 @@ func (e *ExecutionManager) MakeLimitOrder(
 	side Side,
 	price float64,
 	quantity float64) (int, error) {
+	now := e.clock.Now()
+	if err := e.checkSession(symbolID, now); err != nil {
+		return 0, err
+	}
 	e.clientOrderID++
 	order := Order{
 		StrategyID:    strategyID,
@@ -48,10 +121,11 @@ func (e *ExecutionManager) MakeLimitOrder(
 		Type:          TypeLimit,
 		Price:         price,
 		Quantity:      quantity,
-		CreatedAt:     time.Now(),
-		UpdatedAt:     time.Now(),
+		CreatedAt:     now,
+		UpdatedAt:     now,
 	}
 	e.activeOrders[e.clientOrderID] = order
+	e.publishOrderUpdate(&order, StatusUninitialized, 0)
 	return e.clientOrderID, nil
 }
 
@@ -61,6 +135,10 @@ func (e *ExecutionManager) MakeMarketOrder(
 	symbolID int,
 	side Side,
 	quantity float64) (int, error) {
+	now := e.clock.Now()
+	if err := e.checkSession(symbolID, now); err != nil {
+		return 0, err
+	}
 	e.clientOrderID++
 	order := Order{
 		StrategyID:    strategyID,
@@ -71,11 +149,12 @@ func (e *ExecutionManager) MakeMarketOrder(
 		Status:        StatusInitialized,
 		Type:          TypeMarket,
 		Quantity:      quantity,
-		CreatedAt:     time.Now(),
-		UpdatedAt:     time.Now(),
+		CreatedAt:     now,
+		UpdatedAt:     now,
 	}
 
 	e.activeOrders[e.clientOrderID] = order
+	e.publishOrderUpdate(&order, StatusUninitialized, 0)
 	return e.clientOrderID, nil
 }
 
@@ -88,11 +167,22 @@ func (e *ExecutionManager) CancelOrder(clientOrderID int) error {
 }
 
 func (e *ExecutionManager) SubscribeOrderUpdate(acctID int, callback func(*evbus.Event[OrderUpdate]) error, errCallback func(error)) (unsubscribe func(), err error) {
-	return func() {
-	}, nil
+	return e.orderUpdateBus.Subscribe(OrderUpdateTopic(acctID), callback, errCallback)
 }
 
 func (e *ExecutionManager) SubscribeOrderFill(acctID int, callback func(*evbus.Event[OrderFill]) error, errCallback func(error)) (unsubscribe func(), err error) {
-	return func() {
-	}, nil
+	return e.orderFillBus.Subscribe(OrderFillTopic(acctID), callback, errCallback)
+}
+
+// publishOrderUpdate emits an update for order on its account topic.
+func (e *ExecutionManager) publishOrderUpdate(order *Order, before Status, beforeQty float64) {
+	event := e.orderUpdateFactory.GetEvent()
+	event.Data.ClientOrderID = order.ClientOrderID
+	event.Data.BeforeStatus = before
+	event.Data.AfterStatus = order.Status
+	event.Data.BeforeExecutedQty = beforeQty
+	event.Data.AfterExecutedQty = order.ExecutedQty
+	event.Data.UpdatedAt = order.UpdatedAt
+	_ = e.orderUpdateBus.Publish(OrderUpdateTopic(order.AcctID), event)
+	e.orderUpdateFactory.PutEvent(event)
 }
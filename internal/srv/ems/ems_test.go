@@ -0,0 +1,80 @@
+package ems
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/BullionBear/seq/pkg/evbus"
+)
+
+func TestExecutionManager_UsesClock(t *testing.T) {
+	start := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	clock := evbus.NewManualClock(start)
+	em := NewExecutionManagerWithClock(nil, 16, clock)
+
+	var updates []OrderUpdate
+	var createdAt time.Time
+	_, err := em.SubscribeOrderUpdate(7, func(e *evbus.Event[OrderUpdate]) error {
+		updates = append(updates, e.Data)
+		createdAt = e.CreatedAt
+		return nil
+	}, nil)
+	if err != nil {
+		t.Fatalf("SubscribeOrderUpdate failed: %v", err)
+	}
+
+	clock.Advance(time.Second)
+	id, err := em.MakeLimitOrder(1, 7, 100, SideBuy, 10, 1)
+	if err != nil {
+		t.Fatalf("MakeLimitOrder failed: %v", err)
+	}
+
+	order := em.activeOrders[id]
+	if !order.CreatedAt.Equal(start.Add(time.Second)) {
+		t.Errorf("Expected order CreatedAt %v, got %v", start.Add(time.Second), order.CreatedAt)
+	}
+	if len(updates) != 1 || updates[0].ClientOrderID != id || updates[0].AfterStatus != StatusInitialized {
+		t.Fatalf("Expected one initialization update for order %d, got %+v", id, updates)
+	}
+	if !createdAt.Equal(start.Add(time.Second)) {
+		t.Errorf("Expected event CreatedAt %v, got %v", start.Add(time.Second), createdAt)
+	}
+}
+
+type sessionFunc func(symbolID int, t time.Time) (bool, error)
+
+func (f sessionFunc) IsOpen(symbolID int, t time.Time) (bool, error) {
+	return f(symbolID, t)
+}
+
+func TestExecutionManager_RejectsOrdersOutOfSession(t *testing.T) {
+	open := time.Date(2024, 3, 1, 14, 30, 0, 0, time.UTC)
+	clock := evbus.NewManualClock(open.Add(-time.Minute))
+	em := NewExecutionManagerWithClock(nil, 16, clock)
+	em.SetSessionChecker(sessionFunc(func(symbolID int, t time.Time) (bool, error) {
+		if symbolID == 404 {
+			return false, errors.New("instrument not found")
+		}
+		return !t.Before(open), nil
+	}))
+
+	if _, err := em.MakeLimitOrder(1, 7, 100, SideBuy, 10, 1); !errors.Is(err, ErrMarketClosed) {
+		t.Errorf("Expected ErrMarketClosed before the open, got %v", err)
+	}
+	if _, err := em.MakeMarketOrder(1, 7, 100, SideSell, 1); !errors.Is(err, ErrMarketClosed) {
+		t.Errorf("Expected ErrMarketClosed before the open, got %v", err)
+	}
+	if len(em.activeOrders) != 0 {
+		t.Errorf("Expected rejected orders not to be tracked, got %d", len(em.activeOrders))
+	}
+
+	clock.Set(open)
+	id, err := em.MakeMarketOrder(1, 7, 100, SideSell, 1)
+	if err != nil || id != 1 {
+		t.Errorf("Expected order 1 to be accepted at the open, got %d (%v)", id, err)
+	}
+	if _, err := em.MakeLimitOrder(1, 7, 404, SideBuy, 10, 1); err == nil || errors.Is(err, ErrMarketClosed) {
+		t.Errorf("Expected session lookup error, got %v", err)
+	}
+}
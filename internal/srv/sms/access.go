@@ -0,0 +1,254 @@
+package sms
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/BullionBear/seq/internal/config"
+)
+
+// SystemService is the caller recorded for calls made directly on a
+// SecretManager rather than through a SecretAccess. It is not subject to
+// the Policy.
+const SystemService = "system"
+
+var (
+	ErrPermissionDenied  = errors.New("permission denied")
+	ErrUnknownPermission = errors.New("unknown permission")
+)
+
+// Permission is a set of operations a service may perform on an account.
+type Permission uint8
+
+const (
+	PermRead     Permission = 1 << iota // Account details without key material
+	PermTrade                           // Sign trading requests
+	PermTransfer                        // Sign transfers, with the master account's credentials
+	PermReveal                          // Obtain raw key material with GetSecret or ResolveCredentials
+	PermManage                          // Add, update, enable, disable and delete secrets
+
+	PermAll = PermRead | PermTrade | PermTransfer | PermReveal | PermManage
+)
+
+var permissionNames = []struct {
+	perm Permission
+	name string
+}{
+	{PermRead, "read"},
+	{PermTrade, "trade"},
+	{PermTransfer, "transfer"},
+	{PermReveal, "reveal"},
+	{PermManage, "manage"},
+}
+
+func (p Permission) String() string {
+	var names []string
+	for _, pn := range permissionNames {
+		if p&pn.perm != 0 {
+			names = append(names, pn.name)
+		}
+	}
+	return strings.Join(names, "|")
+}
+
+// ParsePermission parses a permission name, or "all".
+func ParsePermission(name string) (Permission, error) {
+	if name == "all" {
+		return PermAll, nil
+	}
+	for _, pn := range permissionNames {
+		if pn.name == name {
+			return pn.perm, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %q", ErrUnknownPermission, name)
+}
+
+// Grant is what a service may do.
+type Grant struct {
+	Permissions Permission
+	AcctIDs     []int // Accounts the grant covers, every account if empty
+}
+
+// Policy maps services to their grants. Services without a grant may do
+// nothing.
+type Policy map[string]Grant
+
+// Allows reports whether service has every permission in perm on acctID.
+func (p Policy) Allows(service string, acctID int, perm Permission) bool {
+	if service == SystemService {
+		return true
+	}
+	grant, ok := p[service]
+	if !ok || grant.Permissions&perm != perm {
+		return false
+	}
+	if len(grant.AcctIDs) == 0 {
+		return true
+	}
+	for _, id := range grant.AcctIDs {
+		if id == acctID {
+			return true
+		}
+	}
+	return false
+}
+
+// NewPolicyFromConfig builds the Policy of cfg.Services.
+func NewPolicyFromConfig(cfg config.ConfigSMS) (Policy, error) {
+	policy := make(Policy, len(cfg.Services))
+	for service, access := range cfg.Services {
+		if service == SystemService {
+			return nil, fmt.Errorf("service name %q is reserved", SystemService)
+		}
+		grant := Grant{AcctIDs: access.Accounts}
+		for _, name := range access.Permissions {
+			perm, err := ParsePermission(name)
+			if err != nil {
+				return nil, fmt.Errorf("service %s: %w", service, err)
+			}
+			grant.Permissions |= perm
+		}
+		policy[service] = grant
+	}
+	return policy, nil
+}
+
+// SetPolicy replaces the policy SecretAccess calls are checked against.
+func (s *SecretManager) SetPolicy(policy Policy) {
+	s.mu.Lock()
+	s.policy = policy
+	s.mu.Unlock()
+}
+
+func (s *SecretManager) allows(service string, acctID int, perm Permission) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.policy.Allows(service, acctID, perm)
+}
+
+// Access returns the view of s for service: every call is checked against
+// the policy and recorded as an AccessEvent naming service.
+func (s *SecretManager) Access(service string) *SecretAccess {
+	return &SecretAccess{manager: s, service: service}
+}
+
+// SecretAccess is the SecretManager as seen by one calling service.
+type SecretAccess struct {
+	manager *SecretManager
+	service string
+}
+
+// Service returns the caller identity of a.
+func (a *SecretAccess) Service() string {
+	return a.service
+}
+
+// authorize checks that a may perform action on acctID, recording the
+// denial if not.
+func (a *SecretAccess) authorize(action AccessAction, acctID int, perm Permission) error {
+	if a.manager.allows(a.service, acctID, perm) {
+		return nil
+	}
+	err := fmt.Errorf("%w: %s may not %s acctID %d", ErrPermissionDenied, a.service, action, acctID)
+	a.manager.publishAccess(AccessEvent{Service: a.service, Action: action, AcctID: acctID, Denied: true, Err: err.Error()})
+	return err
+}
+
+// audit records action on acctID and returns err.
+func (a *SecretAccess) audit(action AccessAction, acctID int, err error) error {
+	event := AccessEvent{Service: a.service, Action: action, AcctID: acctID}
+	if err != nil {
+		event.Err = err.Error()
+	}
+	a.manager.publishAccess(event)
+	return err
+}
+
+// Account returns the details of acctID with APISecret, Passphrase and
+// PrivateKey cleared. The API key identifies the account and is kept.
+func (a *SecretAccess) Account(acctID int) (Secret, error) {
+	if err := a.authorize(AccessRead, acctID, PermRead); err != nil {
+		return Secret{}, err
+	}
+	secret, err := a.manager.lookup(acctID)
+	return withoutKeyMaterial(secret), a.audit(AccessRead, acctID, err)
+}
+
+func (a *SecretAccess) GetSecret(acctID int) (Secret, error) {
+	if err := a.authorize(AccessReveal, acctID, PermReveal); err != nil {
+		return Secret{}, err
+	}
+	secret, err := a.manager.getSecret(acctID)
+	return secret, a.audit(AccessReveal, acctID, err)
+}
+
+func (a *SecretAccess) ResolveCredentials(acctID int, purpose CredentialPurpose) (Secret, error) {
+	if err := a.authorize(AccessReveal, acctID, PermReveal); err != nil {
+		return Secret{}, err
+	}
+	secret, err := a.manager.resolveCredentials(acctID, purpose)
+	return secret, a.audit(AccessReveal, acctID, err)
+}
+
+// Signer returns the Signer of acctID for purpose, which needs PermTrade
+// for PurposeTrading and PermTransfer for PurposeTransfer.
+func (a *SecretAccess) Signer(acctID int, purpose CredentialPurpose) (Signer, error) {
+	perm, err := purposePermission(purpose)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.authorize(AccessSign, acctID, perm); err != nil {
+		return nil, err
+	}
+	if _, err := a.manager.resolveCredentials(acctID, purpose); err != nil {
+		return nil, a.audit(AccessSign, acctID, err)
+	}
+	return &accountSigner{access: a, acctID: acctID, purpose: purpose, perm: perm}, nil
+}
+
+func (a *SecretAccess) AddSecret(secret Secret) error {
+	if err := a.authorize(AccessAdd, secret.AcctID, PermManage); err != nil {
+		return err
+	}
+	return a.audit(AccessAdd, secret.AcctID, a.manager.addSecret(secret))
+}
+
+func (a *SecretAccess) UpdateSecret(secret Secret) error {
+	if err := a.authorize(AccessUpdate, secret.AcctID, PermManage); err != nil {
+		return err
+	}
+	return a.audit(AccessUpdate, secret.AcctID, a.manager.updateSecret(secret))
+}
+
+func (a *SecretAccess) DisableSecret(acctID int) error {
+	if err := a.authorize(AccessDisable, acctID, PermManage); err != nil {
+		return err
+	}
+	return a.audit(AccessDisable, acctID, a.manager.setActive(acctID, false))
+}
+
+func (a *SecretAccess) EnableSecret(acctID int) error {
+	if err := a.authorize(AccessEnable, acctID, PermManage); err != nil {
+		return err
+	}
+	return a.audit(AccessEnable, acctID, a.manager.setActive(acctID, true))
+}
+
+func (a *SecretAccess) DeleteSecret(acctID int) error {
+	if err := a.authorize(AccessDelete, acctID, PermManage); err != nil {
+		return err
+	}
+	return a.audit(AccessDelete, acctID, a.manager.deleteSecret(acctID))
+}
+
+func purposePermission(purpose CredentialPurpose) (Permission, error) {
+	switch purpose {
+	case PurposeTrading:
+		return PermTrade, nil
+	case PurposeTransfer:
+		return PermTransfer, nil
+	}
+	return 0, fmt.Errorf("%w: %d", ErrUnknownPurpose, purpose)
+}
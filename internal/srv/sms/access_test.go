@@ -0,0 +1,204 @@
+package sms
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/BullionBear/seq/internal/config"
+	"github.com/BullionBear/seq/internal/db/dbtest"
+	"github.com/BullionBear/seq/pkg/evbus"
+)
+
+func subscribeAccesses(t *testing.T, s *SecretManager) *[]AccessEvent {
+	t.Helper()
+	var accesses []AccessEvent
+	_, err := s.SubscribeAccessEvents(func(event *evbus.Event[AccessEvent]) error {
+		accesses = append(accesses, event.Data)
+		return nil
+	}, nil)
+	if err != nil {
+		t.Fatalf("SubscribeAccessEvents failed: %v", err)
+	}
+	return &accesses
+}
+
+func TestNewPolicyFromConfig(t *testing.T) {
+	policy, err := NewPolicyFromConfig(config.ConfigSMS{Services: map[string]config.ConfigServiceAccess{
+		"market-data": {Permissions: []string{"read"}},
+		"ems":         {Permissions: []string{"read", "trade"}, Accounts: []int{1, 2}},
+		"ops":         {Permissions: []string{"all"}},
+	}})
+	if err != nil {
+		t.Fatalf("NewPolicyFromConfig failed: %v", err)
+	}
+	tests := []struct {
+		service string
+		acctID  int
+		perm    Permission
+		want    bool
+	}{
+		{"market-data", 1, PermRead, true},
+		{"market-data", 1, PermTrade, false},
+		{"ems", 2, PermTrade, true},
+		{"ems", 3, PermTrade, false},
+		{"ems", 1, PermRead | PermTransfer, false},
+		{"ops", 9, PermReveal | PermManage, true},
+		{"unknown", 1, PermRead, false},
+		{SystemService, 1, PermAll, true},
+	}
+	for _, tt := range tests {
+		if got := policy.Allows(tt.service, tt.acctID, tt.perm); got != tt.want {
+			t.Errorf("Expected Allows(%s, %d, %s) = %v", tt.service, tt.acctID, tt.perm, tt.want)
+		}
+	}
+
+	for name, services := range map[string]map[string]config.ConfigServiceAccess{
+		"unknown permission": {"ems": {Permissions: []string{"withdraw"}}},
+		"reserved name":      {SystemService: {Permissions: []string{"read"}}},
+	} {
+		if _, err := NewPolicyFromConfig(config.ConfigSMS{Services: services}); err == nil {
+			t.Errorf("Expected %s to fail", name)
+		}
+	}
+}
+
+func TestSecretAccess_Permissions(t *testing.T) {
+	s, _ := newTestManager(t, Secret{AcctID: 1, Exchange: "okx", APIKey: "key", APISecret: "secret", Passphrase: "pass", Active: true})
+	s.SetPolicy(Policy{
+		"market-data": {Permissions: PermRead},
+		"ems":         {Permissions: PermTrade},
+	})
+	accesses := subscribeAccesses(t, s)
+
+	marketData := s.Access("market-data")
+	if account, err := marketData.Account(1); err != nil || account.APIKey != "key" || account.APISecret != "" {
+		t.Errorf("Expected account details without the secret, got %+v (%v)", account, err)
+	}
+	if _, err := marketData.GetSecret(1); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("Expected ErrPermissionDenied for GetSecret, got %v", err)
+	}
+	if _, err := marketData.Signer(1, PurposeTrading); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("Expected ErrPermissionDenied for Signer, got %v", err)
+	}
+	if err := marketData.DeleteSecret(1); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("Expected ErrPermissionDenied for DeleteSecret, got %v", err)
+	}
+
+	ems := s.Access("ems")
+	signer, err := ems.Signer(1, PurposeTrading)
+	if err != nil {
+		t.Fatalf("Signer failed: %v", err)
+	}
+	if _, err := signer.Sign(SignHMACSHA256, []byte("payload")); err != nil {
+		t.Errorf("Sign failed: %v", err)
+	}
+	if passphrase, err := signer.Passphrase(); err != nil || passphrase != "pass" {
+		t.Errorf("Expected the passphrase, got %q (%v)", passphrase, err)
+	}
+	if _, err := ems.Signer(1, PurposeTransfer); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("Expected ErrPermissionDenied for transfers, got %v", err)
+	}
+
+	// Revoking the grant stops an existing Signer.
+	s.SetPolicy(Policy{})
+	if _, err := signer.Sign(SignHMACSHA256, []byte("payload")); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("Expected ErrPermissionDenied after revocation, got %v", err)
+	}
+	if _, err := signer.Passphrase(); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("Expected ErrPermissionDenied for the passphrase after revocation, got %v", err)
+	}
+	if _, err := s.GetSecret(1); err != nil {
+		t.Errorf("Expected the system caller to be allowed, got %v", err)
+	}
+
+	want := []AccessEvent{
+		{Service: "market-data", Action: AccessRead, AcctID: 1},
+		{Service: "market-data", Action: AccessReveal, AcctID: 1, Denied: true},
+		{Service: "market-data", Action: AccessSign, AcctID: 1, Denied: true},
+		{Service: "market-data", Action: AccessDelete, AcctID: 1, Denied: true},
+		{Service: "ems", Action: AccessSign, AcctID: 1},
+		{Service: "ems", Action: AccessCredentials, AcctID: 1},
+		{Service: "ems", Action: AccessSign, AcctID: 1, Denied: true},
+		{Service: "ems", Action: AccessSign, AcctID: 1, Denied: true},
+		{Service: "ems", Action: AccessCredentials, AcctID: 1, Denied: true},
+		{Service: SystemService, Action: AccessReveal, AcctID: 1},
+	}
+	if len(*accesses) != len(want) {
+		t.Fatalf("Expected %d accesses, got %+v", len(want), *accesses)
+	}
+	for n, access := range *accesses {
+		if access.Service != want[n].Service || access.Action != want[n].Action || access.AcctID != want[n].AcctID || access.Denied != want[n].Denied {
+			t.Errorf("Expected access %d to be %+v, got %+v", n, want[n], access)
+		}
+		if access.Denied && access.Err == "" {
+			t.Errorf("Expected denied access %d to carry an error", n)
+		}
+	}
+}
+
+func TestSecretAccess_AuditsFailures(t *testing.T) {
+	s, _ := newTestManager(t)
+	accesses := subscribeAccesses(t, s)
+	if err := s.AddSecret(Secret{AcctID: 1, Exchange: "okx"}); err == nil {
+		t.Fatal("Expected AddSecret to fail without a database")
+	}
+	if len(*accesses) != 1 || (*accesses)[0].Action != AccessAdd || (*accesses)[0].Err == "" {
+		t.Errorf("Expected a failed add to be recorded, got %+v", *accesses)
+	}
+	if _, err := s.Access("ems").Signer(1, PurposeTrading); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("Expected ErrPermissionDenied, got %v", err)
+	}
+	if _, err := s.Signer(1, PurposeTrading); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("Expected ErrSecretNotFound, got %v", err)
+	}
+	if last := (*accesses)[len(*accesses)-1]; len(*accesses) != 3 || last.Action != AccessSign || last.Denied || last.Err == "" {
+		t.Errorf("Expected a failed Signer to be recorded, got %+v", *accesses)
+	}
+}
+
+func TestAuditLog_KeepsFailedFlush(t *testing.T) {
+	s, _ := newTestManager(t, Secret{AcctID: 1, Exchange: "okx", Active: true})
+	auditLog := NewAuditLog(s.backend.(*PostgresBackend).db)
+	if _, err := s.SubscribeAccessEvents(auditLog.Record, nil); err != nil {
+		t.Fatalf("SubscribeAccessEvents failed: %v", err)
+	}
+	_, _ = s.GetSecret(1)
+	_, _ = s.GetSecret(2)
+	if err := auditLog.Flush(context.Background()); err == nil {
+		t.Fatal("Expected Flush to fail without a database")
+	}
+	if auditLog.Pending() != 2 {
+		t.Errorf("Expected 2 pending records, got %d", auditLog.Pending())
+	}
+}
+
+func TestAuditLog_Postgres(t *testing.T) {
+	pg := dbtest.Postgres(t)
+	insertTestUser(t, pg.DB)
+	ring, _ := NewKeyRing(testMasterKey(t, 1))
+	s, err := NewSecretManager(pg.DB, ring)
+	if err != nil {
+		t.Fatalf("NewSecretManager failed: %v", err)
+	}
+	auditLog := NewAuditLog(pg.DB)
+	if _, err := s.SubscribeAccessEvents(auditLog.Record, nil); err != nil {
+		t.Fatalf("SubscribeAccessEvents failed: %v", err)
+	}
+	if err := s.AddSecret(Secret{AcctID: 1, UserID: 1, AcctName: "main", Exchange: "okx", APISecret: "secret"}); err != nil {
+		t.Fatalf("AddSecret failed: %v", err)
+	}
+	_, _ = s.Access("market-data").GetSecret(1)
+	if err := auditLog.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	records, err := QueryAccessHistory(context.Background(), pg.DB, 1, time.Time{})
+	if err != nil {
+		t.Fatalf("QueryAccessHistory failed: %v", err)
+	}
+	if len(records) != 2 || records[0].Action != "add" || records[1].Service != "market-data" || !records[1].Denied {
+		t.Errorf("Unexpected access history: %+v", records)
+	}
+}
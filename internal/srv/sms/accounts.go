@@ -0,0 +1,85 @@
+package sms
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrInvalidMaster  = errors.New("invalid master account")
+	ErrHasSubAccounts = errors.New("account has sub-accounts")
+	ErrUnknownPurpose = errors.New("unknown credential purpose")
+)
+
+// CredentialPurpose is what credentials are resolved for.
+type CredentialPurpose int
+
+const (
+	PurposeTrading  CredentialPurpose = iota // Orders and queries on the account itself
+	PurposeTransfer                          // Moving funds between a master and its sub-accounts
+)
+
+// ListSubAccounts returns the sub-accounts of masterAcctID, including
+// disabled ones, ordered by AcctID, without key material.
+func (s *SecretManager) ListSubAccounts(masterAcctID int) []Secret {
+	return s.listAccounts(func(secret Secret) bool { return secret.MasterAcctID == masterAcctID && masterAcctID != 0 })
+}
+
+// ResolveCredentials returns the credentials to use on acctID for purpose.
+// Trading uses the account's own credentials. Transfers use those of its
+// master account, since exchanges only let the master move funds between
+// sub-accounts; a master account transfers with its own.
+func (s *SecretManager) ResolveCredentials(acctID int, purpose CredentialPurpose) (Secret, error) {
+	return s.system.ResolveCredentials(acctID, purpose)
+}
+
+func (s *SecretManager) resolveCredentials(acctID int, purpose CredentialPurpose) (Secret, error) {
+	switch purpose {
+	case PurposeTrading:
+		return s.getSecret(acctID)
+	case PurposeTransfer:
+		secret, err := s.lookup(acctID)
+		if err != nil {
+			return Secret{}, err
+		}
+		if secret.IsMaster() {
+			return s.getSecret(acctID)
+		}
+		return s.getSecret(secret.MasterAcctID)
+	}
+	return Secret{}, fmt.Errorf("%w: %d", ErrUnknownPurpose, purpose)
+}
+
+// validateMaster checks that secret may be written with its MasterAcctID:
+// the master must be an existing master account on the same exchange, and an
+// account with sub-accounts of its own cannot become a sub-account.
+func (s *SecretManager) validateMaster(secret Secret) error {
+	if secret.IsMaster() {
+		return nil
+	}
+	if secret.MasterAcctID == secret.AcctID {
+		return fmt.Errorf("%w: acctID %d cannot be its own master", ErrInvalidMaster, secret.AcctID)
+	}
+	master, err := s.lookup(secret.MasterAcctID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMaster, err)
+	}
+	if !master.IsMaster() {
+		return fmt.Errorf("%w: acctID %d is itself a sub-account", ErrInvalidMaster, master.AcctID)
+	}
+	if master.Exchange != secret.Exchange {
+		return fmt.Errorf("%w: master acctID %d is on %s, not %s", ErrInvalidMaster, master.AcctID, master.Exchange, secret.Exchange)
+	}
+	if subAccounts := s.ListSubAccounts(secret.AcctID); len(subAccounts) > 0 {
+		return fmt.Errorf("%w: acctID %d has %d sub-accounts", ErrInvalidMaster, secret.AcctID, len(subAccounts))
+	}
+	return nil
+}
+
+// nullableAcctID maps the 0 of a master account's MasterAcctID to NULL.
+func nullableAcctID(acctID int) any {
+	if acctID == 0 {
+		return nil
+	}
+	return acctID
+}
@@ -0,0 +1,60 @@
+package sms
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSecretManager_SubAccounts(t *testing.T) {
+	s, _ := newTestManager(t,
+		Secret{AcctID: 1, Exchange: "okx", APISecret: "master", Active: true},
+		Secret{AcctID: 2, Exchange: "okx", APISecret: "sub", MasterAcctID: 1, Active: true},
+		Secret{AcctID: 3, Exchange: "okx", APISecret: "disabled", MasterAcctID: 1},
+		Secret{AcctID: 4, Exchange: "binance", APISecret: "other", Active: true},
+	)
+	if subs := s.ListSubAccounts(1); len(subs) != 2 || subs[0].AcctID != 2 || subs[1].AcctID != 3 || subs[0].APISecret != "" {
+		t.Errorf("Expected sub-accounts 2 and 3, got %+v", subs)
+	}
+	if subs := s.ListSubAccounts(0); len(subs) != 0 {
+		t.Errorf("Expected no sub-accounts of 0, got %+v", subs)
+	}
+
+	tests := []struct {
+		acctID  int
+		purpose CredentialPurpose
+		want    string
+		err     error
+	}{
+		{2, PurposeTrading, "sub", nil},
+		{2, PurposeTransfer, "master", nil},
+		{1, PurposeTransfer, "master", nil},
+		{3, PurposeTrading, "", ErrSecretDisabled},
+		{3, PurposeTransfer, "master", nil},
+		{5, PurposeTransfer, "", ErrSecretNotFound},
+		{1, CredentialPurpose(9), "", ErrUnknownPurpose},
+	}
+	for _, tt := range tests {
+		secret, err := s.ResolveCredentials(tt.acctID, tt.purpose)
+		if !errors.Is(err, tt.err) || secret.APISecret.Reveal() != tt.want {
+			t.Errorf("Expected ResolveCredentials(%d, %d) = %q, %v; got %q, %v", tt.acctID, tt.purpose, tt.want, tt.err, secret.APISecret.Reveal(), err)
+		}
+	}
+
+	for name, secret := range map[string]Secret{
+		"own master":         {AcctID: 5, Exchange: "okx", MasterAcctID: 5},
+		"unknown master":     {AcctID: 5, Exchange: "okx", MasterAcctID: 9},
+		"nested sub-account": {AcctID: 5, Exchange: "okx", MasterAcctID: 2},
+		"other exchange":     {AcctID: 5, Exchange: "binance", MasterAcctID: 1},
+		"master with subs":   {AcctID: 1, Exchange: "okx", MasterAcctID: 4},
+	} {
+		if err := s.validateMaster(secret); !errors.Is(err, ErrInvalidMaster) {
+			t.Errorf("Expected ErrInvalidMaster for %s, got %v", name, err)
+		}
+	}
+	if err := s.validateMaster(Secret{AcctID: 5, Exchange: "okx", MasterAcctID: 1}); err != nil {
+		t.Errorf("Expected valid sub-account, got %v", err)
+	}
+	if err := s.DeleteSecret(1); !errors.Is(err, ErrHasSubAccounts) {
+		t.Errorf("Expected ErrHasSubAccounts, got %v", err)
+	}
+}
@@ -0,0 +1,206 @@
+package sms
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BullionBear/seq/pkg/evbus"
+	"gorm.io/gorm"
+)
+
+const (
+	InsertAccessLog = `
+		INSERT INTO secret_access_log (acct_id, service, action, denied, error, accessed_at) VALUES
+	`
+	QueryAccessLog = `
+		SELECT acct_id, service, action, denied, error, accessed_at FROM secret_access_log
+		WHERE acct_id = ? AND accessed_at >= ? ORDER BY accessed_at, access_id
+	`
+)
+
+// AccessEventsPattern matches the topics of all secret accesses.
+const AccessEventsPattern = "sms.access.*"
+
+// AccessEventTopic returns the evbus topic carrying accesses to acctID.
+func AccessEventTopic(acctID int) string {
+	return fmt.Sprintf("sms.access.%d", acctID)
+}
+
+type AccessAction int
+
+const (
+	AccessRead AccessAction = iota + 1
+	AccessReveal
+	AccessSign
+	AccessAdd
+	AccessUpdate
+	AccessDisable
+	AccessEnable
+	AccessDelete
+	AccessCredentials // A Signer handing out the API key, passphrase or key type
+)
+
+func (a AccessAction) String() string {
+	switch a {
+	case AccessRead:
+		return "read"
+	case AccessReveal:
+		return "reveal"
+	case AccessSign:
+		return "sign"
+	case AccessAdd:
+		return "add"
+	case AccessUpdate:
+		return "update"
+	case AccessDisable:
+		return "disable"
+	case AccessEnable:
+		return "enable"
+	case AccessDelete:
+		return "delete"
+	case AccessCredentials:
+		return "credentials"
+	}
+	return fmt.Sprintf("AccessAction(%d)", int(a))
+}
+
+// AccessEvent records one call on the credentials of an account: who made
+// it, what it was and how it ended. The event's CreatedAt is when it
+// happened.
+type AccessEvent struct {
+	Service string
+	Action  AccessAction
+	AcctID  int
+	Denied  bool   // Refused by the policy
+	Err     string // Empty on success
+}
+
+// SubscribeAccessEvents registers callback for every access made through
+// the SecretManager, including denied ones.
+func (s *SecretManager) SubscribeAccessEvents(callback func(*evbus.Event[AccessEvent]) error, errCallback func(error)) (unsubscribe func(), err error) {
+	return s.accesses.Subscribe(AccessEventsPattern, callback, errCallback)
+}
+
+func (s *SecretManager) publishAccess(access AccessEvent) {
+	event := s.accessFactory.GetEvent()
+	event.Data = access
+	_ = s.accesses.Publish(AccessEventTopic(access.AcctID), event)
+	s.accessFactory.PutEvent(event)
+}
+
+// AccessRecord is one row of secret_access_log.
+type AccessRecord struct {
+	AcctID     int
+	Service    string
+	Action     string
+	Denied     bool
+	Err        string
+	AccessedAt time.Time
+}
+
+// QueryAccessHistory returns the accesses to acctID since since, oldest
+// first.
+func QueryAccessHistory(ctx context.Context, db *gorm.DB, acctID int, since time.Time) ([]AccessRecord, error) {
+	rows, err := db.WithContext(ctx).Raw(QueryAccessLog, acctID, since).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var records []AccessRecord
+	for rows.Next() {
+		var record AccessRecord
+		if err := rows.Scan(&record.AcctID, &record.Service, &record.Action, &record.Denied, &record.Err, &record.AccessedAt); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
+
+// AuditLog persists AccessEvents to secret_access_log. Events are buffered
+// and written in batches by Flush, so recording stays off the signing
+// path.
+type AuditLog struct {
+	db      *gorm.DB
+	mu      sync.Mutex
+	pending []auditRecord
+}
+
+type auditRecord struct {
+	access     AccessEvent
+	accessedAt time.Time
+}
+
+func NewAuditLog(db *gorm.DB) *AuditLog {
+	return &AuditLog{db: db}
+}
+
+// Record buffers event. It is an AccessEvent callback for
+// SubscribeAccessEvents.
+func (l *AuditLog) Record(event *evbus.Event[AccessEvent]) error {
+	l.mu.Lock()
+	l.pending = append(l.pending, auditRecord{access: event.Data, accessedAt: event.CreatedAt})
+	l.mu.Unlock()
+	return nil
+}
+
+// Pending returns the number of buffered events.
+func (l *AuditLog) Pending() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.pending)
+}
+
+// Flush writes the buffered events in a single statement. On failure the
+// events stay buffered for the next Flush.
+func (l *AuditLog) Flush(ctx context.Context) error {
+	l.mu.Lock()
+	records := l.pending
+	l.pending = nil
+	l.mu.Unlock()
+	if len(records) == 0 {
+		return nil
+	}
+
+	var query strings.Builder
+	query.WriteString(InsertAccessLog)
+	args := make([]any, 0, 6*len(records))
+	for n, record := range records {
+		if n > 0 {
+			query.WriteString(", ")
+		}
+		query.WriteString("(?, ?, ?, ?, ?, ?)")
+		args = append(args, record.access.AcctID, record.access.Service, record.access.Action.String(),
+			record.access.Denied, record.access.Err, record.accessedAt)
+	}
+	if err := l.db.WithContext(ctx).Exec(query.String(), args...).Error; err != nil {
+		l.mu.Lock()
+		l.pending = append(records, l.pending...)
+		l.mu.Unlock()
+		return fmt.Errorf("failed to write %d access records: %w", len(records), err)
+	}
+	return nil
+}
+
+// Run flushes every interval until ctx is done, then flushes once more.
+// errHandler receives failed flushes.
+func (l *AuditLog) Run(ctx context.Context, interval time.Duration, errHandler func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if err := l.Flush(context.WithoutCancel(ctx)); err != nil && errHandler != nil {
+				errHandler(err)
+			}
+			return
+		case <-ticker.C:
+			if err := l.Flush(ctx); err != nil && errHandler != nil {
+				errHandler(err)
+			}
+		}
+	}
+}
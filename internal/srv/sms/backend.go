@@ -0,0 +1,165 @@
+package sms
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BullionBear/seq/internal/config"
+	"gorm.io/gorm"
+)
+
+// DefaultEnvPrefix prefixes the variables read by EnvBackend.
+const DefaultEnvPrefix = "SEQ_SECRET_"
+
+var ErrReadOnlyBackend = errors.New("secret backend is read-only")
+
+// Backend stores secrets for a SecretManager, which serializes writes and
+// only calls Update, SetActive, SetPermissions and Delete for secrets Load
+// or Insert returned. Insert and Update store the Permissions of the secret
+// along with it. A write that fails must leave the stored secrets unchanged.
+type Backend interface {
+	Load() ([]Secret, error)
+	Insert(secret Secret) error
+	Update(secret Secret) error
+	SetActive(acctID int, active bool) error
+	SetPermissions(acctID int, perms KeyPermissions) error
+	Delete(acctID int) error
+}
+
+// NewBackendFromConfig returns the Backend selected by cfg.Backend.Type:
+// "postgres" (the default) stores secrets in db, "file" in an encrypted
+// local file, and "env" reads them from environment variables. The master
+// keys of cfg are only loaded for the backends that encrypt.
+func NewBackendFromConfig(cfg config.ConfigSMS, db *gorm.DB) (Backend, error) {
+	switch cfg.Backend.Type {
+	case "", "postgres":
+		if db == nil {
+			return nil, errors.New("postgres secret backend needs a database")
+		}
+		keys, err := NewKeyRingFromConfig(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return NewPostgresBackend(db, keys), nil
+	case "file":
+		keys, err := NewKeyRingFromConfig(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return NewFileBackend(cfg.Backend.Path, keys), nil
+	case "env":
+		prefix := cfg.Backend.EnvPrefix
+		if prefix == "" {
+			prefix = DefaultEnvPrefix
+		}
+		return NewEnvBackend(prefix), nil
+	}
+	return nil, fmt.Errorf("unknown secret backend %q", cfg.Backend.Type)
+}
+
+// EnvBackend reads secrets from environment variables named
+// <prefix><acct_id>_<field>, e.g. SEQ_SECRET_1_API_KEY, for development and
+// CI. Fields are EXCHANGE, ACCT_NAME, USER_ID, API_KEY, API_SECRET,
+// PASSPHRASE, KEY_TYPE, PRIVATE_KEY, MASTER_ACCT_ID and ACTIVE, which
+// defaults to true. It is read-only, except that key permissions are
+// accepted and kept by the SecretManager only.
+type EnvBackend struct {
+	prefix  string
+	environ func() []string
+}
+
+func NewEnvBackend(prefix string) *EnvBackend {
+	return &EnvBackend{prefix: prefix, environ: os.Environ}
+}
+
+func (b *EnvBackend) Load() ([]Secret, error) {
+	secrets := make(map[int]*Secret)
+	for _, variable := range b.environ() {
+		name, value, _ := strings.Cut(variable, "=")
+		rest, ok := strings.CutPrefix(name, b.prefix)
+		if !ok {
+			continue
+		}
+		id, field, ok := strings.Cut(rest, "_")
+		if !ok {
+			return nil, fmt.Errorf("invalid secret variable %s: expected %s<acct_id>_<field>", name, b.prefix)
+		}
+		acctID, err := strconv.Atoi(id)
+		if err != nil {
+			return nil, fmt.Errorf("invalid secret variable %s: account %q is not a number", name, id)
+		}
+		secret, ok := secrets[acctID]
+		if !ok {
+			secret = &Secret{AcctID: acctID, Active: true}
+			secrets[acctID] = secret
+		}
+		if err := setEnvField(secret, field, value); err != nil {
+			return nil, fmt.Errorf("invalid secret variable %s: %w", name, err)
+		}
+	}
+
+	loaded := make([]Secret, 0, len(secrets))
+	for _, secret := range secrets {
+		loaded = append(loaded, *secret)
+	}
+	sort.Slice(loaded, func(i, j int) bool { return loaded[i].AcctID < loaded[j].AcctID })
+	for _, secret := range loaded {
+		if err := validateKey(secret); err != nil {
+			return nil, err
+		}
+	}
+	return loaded, nil
+}
+
+func setEnvField(secret *Secret, field, value string) error {
+	var err error
+	switch field {
+	case "EXCHANGE":
+		secret.Exchange = value
+	case "ACCT_NAME":
+		secret.AcctName = value
+	case "USER_ID":
+		secret.UserID, err = strconv.Atoi(value)
+	case "API_KEY":
+		secret.APIKey = SecretString(value)
+	case "API_SECRET":
+		secret.APISecret = SecretString(value)
+	case "PASSPHRASE":
+		secret.Passphrase = SecretString(value)
+	case "KEY_TYPE":
+		secret.KeyType, err = ParseKeyType(value)
+	case "PRIVATE_KEY":
+		secret.PrivateKey = SecretString(value)
+	case "MASTER_ACCT_ID":
+		secret.MasterAcctID, err = strconv.Atoi(value)
+	case "ACTIVE":
+		secret.Active, err = strconv.ParseBool(value)
+	default:
+		return fmt.Errorf("unknown field %s", field)
+	}
+	return err
+}
+
+func (b *EnvBackend) Insert(secret Secret) error {
+	return ErrReadOnlyBackend
+}
+
+func (b *EnvBackend) Update(secret Secret) error {
+	return ErrReadOnlyBackend
+}
+
+func (b *EnvBackend) SetActive(acctID int, active bool) error {
+	return ErrReadOnlyBackend
+}
+
+func (b *EnvBackend) SetPermissions(acctID int, perms KeyPermissions) error {
+	return nil
+}
+
+func (b *EnvBackend) Delete(acctID int) error {
+	return ErrReadOnlyBackend
+}
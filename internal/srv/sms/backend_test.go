@@ -0,0 +1,214 @@
+package sms
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/BullionBear/seq/internal/config"
+)
+
+func TestEnvBackend(t *testing.T) {
+	backend := NewEnvBackend(DefaultEnvPrefix)
+	backend.environ = func() []string {
+		return []string{
+			"PATH=/usr/bin",
+			"SEQ_SECRET_2_EXCHANGE=binance",
+			"SEQ_SECRET_2_API_KEY=sub-key",
+			"SEQ_SECRET_2_API_SECRET=sub-secret",
+			"SEQ_SECRET_2_MASTER_ACCT_ID=1",
+			"SEQ_SECRET_1_EXCHANGE=binance",
+			"SEQ_SECRET_1_ACCT_NAME=main",
+			"SEQ_SECRET_1_USER_ID=7",
+			"SEQ_SECRET_1_API_KEY=master-key",
+			"SEQ_SECRET_1_API_SECRET=master-secret",
+			"SEQ_SECRET_1_ACTIVE=false",
+		}
+	}
+	s, err := NewSecretManagerWithBackend(backend)
+	if err != nil {
+		t.Fatalf("NewSecretManagerWithBackend failed: %v", err)
+	}
+
+	master, err := s.lookup(1)
+	if err != nil {
+		t.Fatalf("lookup failed: %v", err)
+	}
+	if master.AcctName != "main" || master.UserID != 7 || master.APISecret.Reveal() != "master-secret" || master.Active || master.KeyType != KeyTypeHMAC {
+		t.Errorf("Unexpected master account %+v", master)
+	}
+	sub, err := s.GetSecret(2)
+	if err != nil {
+		t.Fatalf("GetSecret failed: %v", err)
+	}
+	if sub.MasterAcctID != 1 || !sub.Active {
+		t.Errorf("Unexpected sub-account %+v", sub)
+	}
+
+	if err := s.DisableSecret(2); !errors.Is(err, ErrReadOnlyBackend) {
+		t.Errorf("Expected ErrReadOnlyBackend, got %v", err)
+	}
+	if sub, _ := s.GetSecret(2); !sub.Active {
+		t.Error("Expected a failed write to leave the cache unchanged")
+	}
+
+	for name, variable := range map[string]string{
+		"unknown field":   "SEQ_SECRET_1_COLOR=red",
+		"missing field":   "SEQ_SECRET_1=x",
+		"non-numeric id":  "SEQ_SECRET_main_API_KEY=x",
+		"invalid boolean": "SEQ_SECRET_1_ACTIVE=maybe",
+	} {
+		backend.environ = func() []string { return []string{variable} }
+		if _, err := backend.Load(); err == nil {
+			t.Errorf("Expected %s to fail", name)
+		}
+	}
+}
+
+func TestFileBackend_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.enc.yml")
+	ring, _ := NewKeyRing(testMasterKey(t, 1))
+	s, err := NewSecretManagerWithBackend(NewFileBackend(path, ring))
+	if err != nil {
+		t.Fatalf("NewSecretManagerWithBackend failed: %v", err)
+	}
+	if err := s.AddSecret(Secret{AcctID: 1, Exchange: "okx", APIKey: "key", APISecret: "file-secret", Passphrase: "pass"}); err != nil {
+		t.Fatalf("AddSecret failed: %v", err)
+	}
+	if err := s.AddSecret(Secret{AcctID: 2, Exchange: "okx", APISecret: "sub-secret", MasterAcctID: 1}); err != nil {
+		t.Fatalf("AddSecret failed: %v", err)
+	}
+	if err := s.DisableSecret(2); err != nil {
+		t.Fatalf("DisableSecret failed: %v", err)
+	}
+	if err := s.AddSecret(Secret{AcctID: 1, Exchange: "okx"}); !errors.Is(err, ErrSecretExists) {
+		t.Errorf("Expected ErrSecretExists, got %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read secrets file: %v", err)
+	}
+	for _, plaintext := range []string{"file-secret", "sub-secret", "pass"} {
+		if bytes.Contains(data, []byte(plaintext)) {
+			t.Errorf("Expected %q to be encrypted in the secrets file", plaintext)
+		}
+	}
+
+	reopened, err := NewSecretManagerWithBackend(NewFileBackend(path, ring))
+	if err != nil {
+		t.Fatalf("NewSecretManagerWithBackend failed on reopen: %v", err)
+	}
+	master, err := reopened.GetSecret(1)
+	if err != nil || master.APISecret.Reveal() != "file-secret" || master.Passphrase.Reveal() != "pass" || !master.Active {
+		t.Errorf("Unexpected reloaded master account %+v (%v)", master, err)
+	}
+	sub, err := reopened.lookup(2)
+	if err != nil || sub.MasterAcctID != 1 || sub.Active {
+		t.Errorf("Unexpected reloaded sub-account %+v (%v)", sub, err)
+	}
+
+	otherKey, _ := NewMasterKey(bytes.Repeat([]byte{9}, KeySize), 1)
+	other, _ := NewKeyRing(otherKey)
+	if _, err := NewFileBackend(path, other).Load(); err == nil {
+		t.Error("Expected loading with another master key to fail")
+	}
+}
+
+func TestFileBackend_Replace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.enc.yml")
+	ring, _ := NewKeyRing(testMasterKey(t, 1))
+	secrets, err := ParseSecretsYAML([]byte(`
+- acct_id: 1
+  exchange: binance
+  api_key: key
+  api_secret: secret
+- acct_id: 2
+  exchange: binance
+  api_secret: sub
+  master_acct_id: 1
+  active: false
+`))
+	if err != nil {
+		t.Fatalf("ParseSecretsYAML failed: %v", err)
+	}
+	if err := NewFileBackend(path, ring).Replace(secrets); err != nil {
+		t.Fatalf("Replace failed: %v", err)
+	}
+	loaded, err := NewFileBackend(path, ring).Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(loaded) != 2 || !loaded[0].Active || loaded[1].Active || loaded[1].MasterAcctID != 1 {
+		t.Errorf("Unexpected secrets %+v", loaded)
+	}
+
+	if _, err := ParseSecretsYAML([]byte("- acct_id: 1\n  key_type: dsa\n")); err == nil {
+		t.Error("Expected an unknown key type to fail")
+	}
+}
+
+func TestFileBackend_Reseal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.enc.yml")
+	v1, v2 := testMasterKey(t, 1), testMasterKey(t, 2)
+	ring, _ := NewKeyRing(v1)
+	if version, err := NewFileBackend(path, ring).Reseal(); err != nil || version != 0 {
+		t.Errorf("Expected a missing file to be left alone, got version %d (%v)", version, err)
+	}
+	if err := NewFileBackend(path, ring).Replace([]Secret{{AcctID: 1, Exchange: "okx", APISecret: "secret"}}); err != nil {
+		t.Fatalf("Replace failed: %v", err)
+	}
+
+	rotated, _ := NewKeyRing(v2, v1)
+	if version, err := NewFileBackend(path, rotated).Reseal(); err != nil || version != 1 {
+		t.Fatalf("Expected to re-seal a version 1 file, got version %d (%v)", version, err)
+	}
+	v2Only, _ := NewKeyRing(v2)
+	loaded, err := NewFileBackend(path, v2Only).Load()
+	if err != nil || len(loaded) != 1 || loaded[0].APISecret.Reveal() != "secret" {
+		t.Errorf("Expected the re-sealed file to load with version 2 alone, got %+v (%v)", loaded, err)
+	}
+	v1Only, _ := NewKeyRing(v1)
+	if _, err := NewFileBackend(path, v1Only).Load(); err == nil {
+		t.Error("Expected the re-sealed file not to load with version 1")
+	}
+}
+
+func TestNewBackendFromConfig(t *testing.T) {
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	t.Setenv("SEQ_TEST_MASTER_KEY", key)
+	keyConfig := config.ConfigMasterKey{Source: "env", Env: "SEQ_TEST_MASTER_KEY", Version: 1}
+
+	backend, err := NewBackendFromConfig(config.ConfigSMS{
+		Backend:   config.ConfigSecretBackend{Type: "file", Path: filepath.Join(t.TempDir(), "secrets.enc.yml")},
+		MasterKey: keyConfig,
+	}, nil)
+	if err != nil {
+		t.Fatalf("NewBackendFromConfig failed: %v", err)
+	}
+	if _, ok := backend.(*FileBackend); !ok {
+		t.Errorf("Expected a FileBackend, got %T", backend)
+	}
+	backend, err = NewBackendFromConfig(config.ConfigSMS{Backend: config.ConfigSecretBackend{Type: "env"}}, nil)
+	if err != nil {
+		t.Fatalf("NewBackendFromConfig failed: %v", err)
+	}
+	if env, ok := backend.(*EnvBackend); !ok || env.prefix != DefaultEnvPrefix {
+		t.Errorf("Expected an EnvBackend with the default prefix, got %#v", backend)
+	}
+
+	for name, cfg := range map[string]config.ConfigSMS{
+		"unknown type":        {Backend: config.ConfigSecretBackend{Type: "vault"}},
+		"postgres without db": {MasterKey: keyConfig},
+		"file without key":    {Backend: config.ConfigSecretBackend{Type: "file"}},
+	} {
+		if _, err := NewBackendFromConfig(cfg, nil); err == nil {
+			t.Errorf("Expected %s to fail", name)
+		}
+	}
+}
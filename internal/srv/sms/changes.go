@@ -0,0 +1,69 @@
+package sms
+
+import (
+	"fmt"
+
+	"github.com/BullionBear/seq/pkg/evbus"
+)
+
+// SecretChangesPattern matches the topics of all credential changes.
+const SecretChangesPattern = "sms.secrets.*"
+
+// SecretChangeTopic returns the evbus topic carrying changes of acctID.
+func SecretChangeTopic(acctID int) string {
+	return fmt.Sprintf("sms.secrets.%d", acctID)
+}
+
+type ChangeKind int
+
+const (
+	SecretAdded ChangeKind = iota + 1
+	SecretUpdated
+	SecretDisabled
+	SecretEnabled
+	SecretDeleted
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case SecretAdded:
+		return "added"
+	case SecretUpdated:
+		return "updated"
+	case SecretDisabled:
+		return "disabled"
+	case SecretEnabled:
+		return "enabled"
+	case SecretDeleted:
+		return "deleted"
+	}
+	return fmt.Sprintf("ChangeKind(%d)", int(k))
+}
+
+// SecretChange tells subscribers, such as venue clients, that the
+// credentials of an account changed and connections using them should be
+// re-established. It carries no key material: a Signer picks up the new
+// credentials by itself.
+type SecretChange struct {
+	Kind     ChangeKind
+	AcctID   int
+	Exchange string
+}
+
+// SubscribeSecretChanges registers callback for every change committed
+// through the SecretManager.
+func (s *SecretManager) SubscribeSecretChanges(callback func(*evbus.Event[SecretChange]) error, errCallback func(error)) (unsubscribe func(), err error) {
+	return s.changes.Subscribe(SecretChangesPattern, callback, errCallback)
+}
+
+// SecretChanges returns the bus credential changes are published on.
+func (s *SecretManager) SecretChanges() *evbus.Bus[SecretChange] {
+	return s.changes
+}
+
+func (s *SecretManager) publishChange(change SecretChange) {
+	event := s.changeFactory.GetEvent()
+	event.Data = change
+	_ = s.changes.Publish(SecretChangeTopic(change.AcctID), event)
+	s.changeFactory.PutEvent(event)
+}
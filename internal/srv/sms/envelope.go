@@ -0,0 +1,100 @@
+package sms
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// credentials is the part of a Secret that is encrypted at rest.
+type credentials struct {
+	APIKey     string `json:"api_key"`
+	APISecret  string `json:"api_secret"`
+	Passphrase string `json:"passphrase"`
+	PrivateKey string `json:"private_key,omitempty"`
+}
+
+// sealedSecret is the envelope stored in the secrets table: credentials
+// encrypted with a random data key, and the data key wrapped by the master
+// key of version KeyVersion.
+type sealedSecret struct {
+	Ciphertext []byte
+	Nonce      []byte
+	WrappedKey []byte
+	KeyVersion int
+}
+
+// secretAAD binds ciphertexts and wrapped keys to acctID, so a row copied
+// onto another account fails to decrypt.
+func secretAAD(acctID int) []byte {
+	return []byte("seq/secrets/acct_id=" + strconv.Itoa(acctID))
+}
+
+// encryptSecret seals the credentials of secret with AES-256-GCM under a
+// fresh data key wrapped by keys.
+func encryptSecret(keys KeyProvider, secret Secret) (sealedSecret, error) {
+	plaintext, err := json.Marshal(credentials{
+		APIKey:     secret.APIKey.Reveal(),
+		APISecret:  secret.APISecret.Reveal(),
+		Passphrase: secret.Passphrase.Reveal(),
+		PrivateKey: secret.PrivateKey.Reveal(),
+	})
+	if err != nil {
+		return sealedSecret{}, err
+	}
+	dataKey, err := randomBytes(KeySize)
+	if err != nil {
+		return sealedSecret{}, err
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return sealedSecret{}, err
+	}
+	nonce, err := randomBytes(aead.NonceSize())
+	if err != nil {
+		return sealedSecret{}, err
+	}
+	aad := secretAAD(secret.AcctID)
+	wrapped, err := keys.Wrap(dataKey, aad)
+	if err != nil {
+		return sealedSecret{}, fmt.Errorf("failed to wrap data key: %w", err)
+	}
+	return sealedSecret{
+		Ciphertext: aead.Seal(nil, nonce, plaintext, aad),
+		Nonce:      nonce,
+		WrappedKey: wrapped,
+		KeyVersion: keys.Version(),
+	}, nil
+}
+
+// decryptSecret fills the credentials of secret from sealed, unwrapping its
+// data key with the key of sealed.KeyVersion in ring.
+func decryptSecret(ring *KeyRing, secret *Secret, sealed sealedSecret) error {
+	keys, err := ring.Key(sealed.KeyVersion)
+	if err != nil {
+		return fmt.Errorf("cannot decrypt secret for acctID %d: %w", secret.AcctID, err)
+	}
+	aad := secretAAD(secret.AcctID)
+	dataKey, err := keys.Unwrap(sealed.WrappedKey, aad)
+	if err != nil {
+		return fmt.Errorf("failed to unwrap data key for acctID %d: %w", secret.AcctID, err)
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return err
+	}
+	if len(sealed.Nonce) != aead.NonceSize() {
+		return fmt.Errorf("invalid nonce for acctID %d", secret.AcctID)
+	}
+	plaintext, err := aead.Open(nil, sealed.Nonce, sealed.Ciphertext, aad)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt secret for acctID %d: %w", secret.AcctID, err)
+	}
+	var c credentials
+	if err := json.Unmarshal(plaintext, &c); err != nil {
+		return fmt.Errorf("invalid secret for acctID %d: %w", secret.AcctID, err)
+	}
+	secret.APIKey, secret.APISecret, secret.Passphrase = SecretString(c.APIKey), SecretString(c.APISecret), SecretString(c.Passphrase)
+	secret.PrivateKey = SecretString(c.PrivateKey)
+	return nil
+}
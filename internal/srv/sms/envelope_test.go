@@ -0,0 +1,143 @@
+package sms
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/BullionBear/seq/internal/config"
+)
+
+func testMasterKey(t *testing.T, version int) *MasterKey {
+	t.Helper()
+	key, err := NewMasterKey(bytes.Repeat([]byte{byte(version)}, KeySize), version)
+	if err != nil {
+		t.Fatalf("NewMasterKey failed: %v", err)
+	}
+	return key
+}
+
+func testSecret() Secret {
+	return Secret{AcctID: 7, AcctName: "main", Exchange: "okx", APIKey: "key", APISecret: "secret", Passphrase: "pass"}
+}
+
+func TestEncryptSecret_RoundTrip(t *testing.T) {
+	kms := NewLocalKMS()
+	if err := kms.CreateKey("secrets"); err != nil {
+		t.Fatalf("CreateKey failed: %v", err)
+	}
+	for name, keys := range map[string]KeyProvider{
+		"master key": testMasterKey(t, 1),
+		"kms":        NewKMSKeyProvider(kms, "secrets", 3),
+	} {
+		t.Run(name, func(t *testing.T) {
+			secret := testSecret()
+			sealed, err := encryptSecret(keys, secret)
+			if err != nil {
+				t.Fatalf("encryptSecret failed: %v", err)
+			}
+			if sealed.KeyVersion != keys.Version() {
+				t.Errorf("Expected key version %d, got %d", keys.Version(), sealed.KeyVersion)
+			}
+			if bytes.Contains(sealed.Ciphertext, []byte("secret")) || bytes.Contains(sealed.Ciphertext, []byte("pass")) {
+				t.Error("Expected ciphertext not to contain the plaintext")
+			}
+
+			ring, _ := NewKeyRing(keys)
+			decrypted := Secret{AcctID: secret.AcctID}
+			if err := decryptSecret(ring, &decrypted, sealed); err != nil {
+				t.Fatalf("decryptSecret failed: %v", err)
+			}
+			if decrypted.APIKey != "key" || decrypted.APISecret != "secret" || decrypted.Passphrase != "pass" {
+				t.Errorf("Expected original credentials, got %+v", decrypted)
+			}
+		})
+	}
+}
+
+func TestDecryptSecret_Rejects(t *testing.T) {
+	keys := testMasterKey(t, 1)
+	sealed, err := encryptSecret(keys, testSecret())
+	if err != nil {
+		t.Fatalf("encryptSecret failed: %v", err)
+	}
+	ring, _ := NewKeyRing(keys)
+
+	if err := decryptSecret(ring, &Secret{AcctID: 8}, sealed); err == nil {
+		t.Error("Expected error decrypting with another acctID")
+	}
+	tampered := sealed
+	tampered.Ciphertext = append([]byte(nil), sealed.Ciphertext...)
+	tampered.Ciphertext[0] ^= 1
+	if err := decryptSecret(ring, &Secret{AcctID: 7}, tampered); err == nil {
+		t.Error("Expected error decrypting a tampered ciphertext")
+	}
+	newRing, _ := NewKeyRing(testMasterKey(t, 2))
+	if err := decryptSecret(newRing, &Secret{AcctID: 7}, sealed); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Expected ErrKeyNotFound without key version 1, got %v", err)
+	}
+	other, _ := NewMasterKey(bytes.Repeat([]byte{9}, KeySize), 1)
+	otherRing, _ := NewKeyRing(other)
+	if err := decryptSecret(otherRing, &Secret{AcctID: 7}, sealed); err == nil {
+		t.Error("Expected error decrypting with another master key")
+	}
+}
+
+func TestNewKeyProvider(t *testing.T) {
+	encoded, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "master.key")
+	if err := os.WriteFile(keyFile, []byte(encoded+"\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write key file: %v", err)
+	}
+	kmsFile := filepath.Join(dir, "kms.json")
+	kmsKeys, _ := json.Marshal(map[string]string{"secrets": encoded})
+	if err := os.WriteFile(kmsFile, kmsKeys, 0o600); err != nil {
+		t.Fatalf("Failed to write KMS file: %v", err)
+	}
+	t.Setenv("SEQ_TEST_MASTER_KEY", encoded)
+
+	for _, cfg := range []config.ConfigMasterKey{
+		{Source: "file", Path: keyFile},
+		{Source: "env", Env: "SEQ_TEST_MASTER_KEY", Version: 2},
+		{Source: "kms", Path: kmsFile, KeyID: "secrets"},
+	} {
+		keys, err := NewKeyProvider(cfg)
+		if err != nil {
+			t.Fatalf("NewKeyProvider(%s) failed: %v", cfg.Source, err)
+		}
+		if want := max(cfg.Version, 1); keys.Version() != want {
+			t.Errorf("Expected %s key version %d, got %d", cfg.Source, want, keys.Version())
+		}
+		wrapped, err := keys.Wrap([]byte("data key"), []byte("aad"))
+		if err != nil {
+			t.Fatalf("Wrap failed: %v", err)
+		}
+		if unwrapped, err := keys.Unwrap(wrapped, []byte("aad")); err != nil || string(unwrapped) != "data key" {
+			t.Errorf("Expected %s to unwrap its own key, got %q (%v)", cfg.Source, unwrapped, err)
+		}
+	}
+
+	for name, cfg := range map[string]config.ConfigMasterKey{
+		"unknown source":  {Source: "vault"},
+		"missing env":     {Source: "env", Env: "SEQ_TEST_MISSING_KEY"},
+		"missing kms key": {Source: "kms", Path: kmsFile, KeyID: "other"},
+	} {
+		if _, err := NewKeyProvider(cfg); err == nil {
+			t.Errorf("Expected error for %s", name)
+		}
+	}
+	t.Setenv("SEQ_TEST_SHORT_KEY", "c2hvcnQ=")
+	if _, err := NewKeyProvider(config.ConfigMasterKey{Source: "env", Env: "SEQ_TEST_SHORT_KEY"}); err == nil {
+		t.Error("Expected error for a short key")
+	}
+	if _, err := NewLocalKMS().Encrypt("none", nil, nil); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Expected ErrKeyNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,379 @@
+package sms
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// secretsFileAAD binds a sealed secrets file to its purpose.
+var secretsFileAAD = []byte("seq/secrets/file")
+
+// FileBackend stores secrets in a local YAML file for development. The
+// whole list of secrets is sealed with AES-256-GCM under a data key wrapped
+// by the primary master key of keys, and every write rewrites the file
+// atomically. A missing file holds no secrets.
+type FileBackend struct {
+	path    string
+	keys    *KeyRing
+	mu      sync.Mutex
+	secrets map[int]Secret
+}
+
+// sealedFile is the on-disk form of a secrets file.
+type sealedFile struct {
+	KeyVersion int    `yaml:"key_version"`
+	WrappedKey string `yaml:"wrapped_key"` // base64
+	Ciphertext string `yaml:"ciphertext"`  // base64 of nonce || ciphertext
+}
+
+// fileSecret is a Secret as listed in the plaintext of a secrets file, and
+// in the plaintext YAML read by ParseSecretsYAML.
+type fileSecret struct {
+	AcctID       int                 `yaml:"acct_id"`
+	UserID       int                 `yaml:"user_id,omitempty"`
+	AcctName     string              `yaml:"acct_name,omitempty"`
+	Exchange     string              `yaml:"exchange"`
+	APIKey       string              `yaml:"api_key,omitempty"`
+	APISecret    string              `yaml:"api_secret,omitempty"`
+	Passphrase   string              `yaml:"passphrase,omitempty"`
+	KeyType      string              `yaml:"key_type,omitempty"` // "hmac" if empty
+	PrivateKey   string              `yaml:"private_key,omitempty"`
+	MasterAcctID int                 `yaml:"master_acct_id,omitempty"`
+	Active       *bool               `yaml:"active,omitempty"` // true if unset
+	Permissions  *fileKeyPermissions `yaml:"permissions,omitempty"`
+}
+
+// fileKeyPermissions is KeyPermissions in a secrets file.
+type fileKeyPermissions struct {
+	Read         bool      `yaml:"read"`
+	Trade        bool      `yaml:"trade"`
+	Margin       bool      `yaml:"margin"`
+	Futures      bool      `yaml:"futures"`
+	Withdraw     bool      `yaml:"withdraw"`
+	Transfer     bool      `yaml:"transfer"`
+	IPRestricted bool      `yaml:"ip_restricted"`
+	IPWhitelist  []string  `yaml:"ip_whitelist,omitempty"`
+	CheckedAt    time.Time `yaml:"checked_at"`
+}
+
+func NewFileBackend(path string, keys *KeyRing) *FileBackend {
+	return &FileBackend{path: path, keys: keys, secrets: make(map[int]Secret)}
+}
+
+func (b *FileBackend) Load() ([]Secret, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	secrets, _, err := b.read()
+	if err != nil {
+		return nil, err
+	}
+	b.secrets = secrets
+	return sortedSecrets(secrets), nil
+}
+
+// Reseal rewrites the file under the primary master key of the key ring and
+// returns the key version it was sealed with, 0 for a missing file, which
+// is left missing. Run it when rotating master keys, before the previous
+// key is removed from the ring.
+func (b *FileBackend) Reseal() (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	secrets, version, err := b.read()
+	if err != nil || version == 0 {
+		return 0, err
+	}
+	if err := b.write(secrets); err != nil {
+		return 0, err
+	}
+	b.secrets = secrets
+	return version, nil
+}
+
+func (b *FileBackend) Insert(secret Secret) error {
+	return b.modify(func(secrets map[int]Secret) error {
+		if _, ok := secrets[secret.AcctID]; ok {
+			return fmt.Errorf("%w for acctID: %d", ErrSecretExists, secret.AcctID)
+		}
+		secrets[secret.AcctID] = secret
+		return nil
+	})
+}
+
+func (b *FileBackend) Update(secret Secret) error {
+	return b.modify(func(secrets map[int]Secret) error {
+		current, ok := secrets[secret.AcctID]
+		if !ok {
+			return fmt.Errorf("%w for acctID: %d", ErrSecretNotFound, secret.AcctID)
+		}
+		secret.Active = current.Active
+		secrets[secret.AcctID] = secret
+		return nil
+	})
+}
+
+func (b *FileBackend) SetActive(acctID int, active bool) error {
+	return b.modify(func(secrets map[int]Secret) error {
+		secret, ok := secrets[acctID]
+		if !ok {
+			return fmt.Errorf("%w for acctID: %d", ErrSecretNotFound, acctID)
+		}
+		secret.Active = active
+		secrets[acctID] = secret
+		return nil
+	})
+}
+
+func (b *FileBackend) SetPermissions(acctID int, perms KeyPermissions) error {
+	return b.modify(func(secrets map[int]Secret) error {
+		secret, ok := secrets[acctID]
+		if !ok {
+			return fmt.Errorf("%w for acctID: %d", ErrSecretNotFound, acctID)
+		}
+		secret.Permissions = perms
+		secrets[acctID] = secret
+		return nil
+	})
+}
+
+func (b *FileBackend) Delete(acctID int) error {
+	return b.modify(func(secrets map[int]Secret) error {
+		if _, ok := secrets[acctID]; !ok {
+			return fmt.Errorf("%w for acctID: %d", ErrSecretNotFound, acctID)
+		}
+		delete(secrets, acctID)
+		return nil
+	})
+}
+
+// Replace overwrites the file with secrets, e.g. those parsed by
+// ParseSecretsYAML.
+func (b *FileBackend) Replace(secrets []Secret) error {
+	for _, secret := range secrets {
+		if err := validateKey(secret); err != nil {
+			return err
+		}
+	}
+	return b.modify(func(current map[int]Secret) error {
+		clear(current)
+		for _, secret := range secrets {
+			if _, ok := current[secret.AcctID]; ok {
+				return fmt.Errorf("%w for acctID: %d", ErrSecretExists, secret.AcctID)
+			}
+			current[secret.AcctID] = secret
+		}
+		return nil
+	})
+}
+
+// modify applies change to a copy of the secrets and writes it, keeping
+// the copy only if the write succeeds.
+func (b *FileBackend) modify(change func(map[int]Secret) error) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	secrets := make(map[int]Secret, len(b.secrets)+1)
+	for acctID, secret := range b.secrets {
+		secrets[acctID] = secret
+	}
+	if err := change(secrets); err != nil {
+		return err
+	}
+	if err := b.write(secrets); err != nil {
+		return err
+	}
+	b.secrets = secrets
+	return nil
+}
+
+// read returns the secrets of the file and the version of the master key
+// they are sealed with, 0 for a missing file.
+func (b *FileBackend) read() (map[int]Secret, int, error) {
+	data, err := os.ReadFile(b.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return make(map[int]Secret), 0, nil
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read secrets file: %w", err)
+	}
+	var sealed sealedFile
+	if err := yaml.Unmarshal(data, &sealed); err != nil {
+		return nil, 0, fmt.Errorf("invalid secrets file %s: %w", b.path, err)
+	}
+	plaintext, err := b.open(sealed)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to decrypt secrets file %s: %w", b.path, err)
+	}
+	list, err := ParseSecretsYAML(plaintext)
+	if err != nil {
+		return nil, 0, fmt.Errorf("secrets file %s: %w", b.path, err)
+	}
+	secrets := make(map[int]Secret, len(list))
+	for _, secret := range list {
+		secrets[secret.AcctID] = secret
+	}
+	return secrets, sealed.KeyVersion, nil
+}
+
+func (b *FileBackend) open(sealed sealedFile) ([]byte, error) {
+	keys, err := b.keys.Key(sealed.KeyVersion)
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := base64.StdEncoding.DecodeString(sealed.WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("wrapped key not base64: %w", err)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(sealed.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("ciphertext not base64: %w", err)
+	}
+	dataKey, err := keys.Unwrap(wrapped, secretsFileAAD)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return open(aead, ciphertext, secretsFileAAD)
+}
+
+// write seals secrets under a fresh data key and replaces the file with
+// them through a rename, so readers never see a partial file.
+func (b *FileBackend) write(secrets map[int]Secret) error {
+	list := sortedSecrets(secrets)
+	entries := make([]fileSecret, 0, len(list))
+	for _, secret := range list {
+		entries = append(entries, toFileSecret(secret))
+	}
+	plaintext, err := yaml.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	dataKey, err := randomBytes(KeySize)
+	if err != nil {
+		return err
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return err
+	}
+	ciphertext, err := seal(aead, plaintext, secretsFileAAD)
+	if err != nil {
+		return err
+	}
+	wrapped, err := b.keys.Primary().Wrap(dataKey, secretsFileAAD)
+	if err != nil {
+		return fmt.Errorf("failed to wrap data key: %w", err)
+	}
+	data, err := yaml.Marshal(sealedFile{
+		KeyVersion: b.keys.Primary().Version(),
+		WrappedKey: base64.StdEncoding.EncodeToString(wrapped),
+		Ciphertext: base64.StdEncoding.EncodeToString(ciphertext),
+	})
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(b.path), filepath.Base(b.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write secrets file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write secrets file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write secrets file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), b.path); err != nil {
+		return fmt.Errorf("failed to write secrets file: %w", err)
+	}
+	return nil
+}
+
+// ParseSecretsYAML parses a plaintext YAML list of secrets with the fields
+// acct_id, user_id, acct_name, exchange, api_key, api_secret, passphrase,
+// key_type, private_key, master_acct_id and active.
+func ParseSecretsYAML(data []byte) ([]Secret, error) {
+	var entries []fileSecret
+	if err := yaml.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("invalid secrets YAML: %w", err)
+	}
+	secrets := make([]Secret, 0, len(entries))
+	for _, entry := range entries {
+		secret, err := entry.secret()
+		if err != nil {
+			return nil, err
+		}
+		secrets = append(secrets, secret)
+	}
+	return secrets, nil
+}
+
+func (f fileSecret) secret() (Secret, error) {
+	secret := Secret{
+		AcctID:       f.AcctID,
+		UserID:       f.UserID,
+		AcctName:     f.AcctName,
+		Exchange:     f.Exchange,
+		APIKey:       SecretString(f.APIKey),
+		APISecret:    SecretString(f.APISecret),
+		Passphrase:   SecretString(f.Passphrase),
+		PrivateKey:   SecretString(f.PrivateKey),
+		MasterAcctID: f.MasterAcctID,
+		Active:       f.Active == nil || *f.Active,
+	}
+	if f.Permissions != nil {
+		secret.Permissions = KeyPermissions(*f.Permissions)
+	}
+	if f.KeyType != "" {
+		keyType, err := ParseKeyType(f.KeyType)
+		if err != nil {
+			return Secret{}, fmt.Errorf("acctID %d: %w", f.AcctID, err)
+		}
+		secret.KeyType = keyType
+	}
+	return secret, nil
+}
+
+func toFileSecret(secret Secret) fileSecret {
+	active := secret.Active
+	var perms *fileKeyPermissions
+	if secret.Permissions.Checked() {
+		filePerms := fileKeyPermissions(secret.Permissions)
+		perms = &filePerms
+	}
+	return fileSecret{
+		AcctID:       secret.AcctID,
+		UserID:       secret.UserID,
+		AcctName:     secret.AcctName,
+		Exchange:     secret.Exchange,
+		APIKey:       secret.APIKey.Reveal(),
+		APISecret:    secret.APISecret.Reveal(),
+		Passphrase:   secret.Passphrase.Reveal(),
+		KeyType:      secret.KeyType.String(),
+		PrivateKey:   secret.PrivateKey.Reveal(),
+		MasterAcctID: secret.MasterAcctID,
+		Active:       &active,
+		Permissions:  perms,
+	}
+}
+
+func sortedSecrets(secrets map[int]Secret) []Secret {
+	list := make([]Secret, 0, len(secrets))
+	for _, secret := range secrets {
+		list = append(list, secret)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].AcctID < list[j].AcctID })
+	return list
+}
@@ -0,0 +1,479 @@
+package sms
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BullionBear/seq/internal/config"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	BinanceBaseURL = "https://api.binance.com"
+	OKXBaseURL     = "https://www.okx.com"
+
+	// DefaultKeyCheckInterval is how often RunKeyChecks re-checks keys when
+	// the configuration does not say.
+	DefaultKeyCheckInterval = time.Hour
+
+	// keyCheckTimeout bounds the check AddSecret and UpdateSecret make.
+	keyCheckTimeout = 15 * time.Second
+)
+
+var ErrWithdrawalEnabled = errors.New("API key has withdrawal permission")
+
+// KeyPermissions is what a venue reports an API key may do. We never hold
+// keys able to withdraw.
+type KeyPermissions struct {
+	Read         bool
+	Trade        bool // Spot trading
+	Margin       bool
+	Futures      bool
+	Withdraw     bool
+	Transfer     bool      // Transfers between the venue's own accounts
+	IPRestricted bool      // The key only works from whitelisted IPs
+	IPWhitelist  []string  // Whitelisted IPs, when the venue reports them
+	CheckedAt    time.Time // Zero if the key was never checked
+}
+
+// Checked reports whether p came from a check.
+func (p KeyPermissions) Checked() bool {
+	return !p.CheckedAt.IsZero()
+}
+
+// KeyChecker asks a venue what the API key signing for signer may do.
+type KeyChecker interface {
+	Exchange() string
+	CheckKey(ctx context.Context, signer Signer) (KeyPermissions, error)
+}
+
+// NewKeyChecker returns the checker for exchange ("binance" or "okx"). An
+// empty baseURL selects the exchange's public endpoint.
+func NewKeyChecker(exchange, baseURL string, client *http.Client) (KeyChecker, error) {
+	switch exchange {
+	case "binance":
+		return NewBinanceKeyChecker(baseURL, client), nil
+	case "okx":
+		return NewOKXKeyChecker(baseURL, client), nil
+	default:
+		return nil, fmt.Errorf("unsupported exchange: %s", exchange)
+	}
+}
+
+// NewKeyCheckersFromConfig returns the checkers of cfg.KeyCheck.Exchanges.
+func NewKeyCheckersFromConfig(cfg config.ConfigSMS, client *http.Client) ([]KeyChecker, error) {
+	checkers := make([]KeyChecker, 0, len(cfg.KeyCheck.Exchanges))
+	for _, exchange := range cfg.KeyCheck.Exchanges {
+		checker, err := NewKeyChecker(exchange, cfg.KeyCheck.BaseURLs[exchange], client)
+		if err != nil {
+			return nil, err
+		}
+		checkers = append(checkers, checker)
+	}
+	return checkers, nil
+}
+
+// KeyCheckInterval returns how often cfg asks keys to be re-checked.
+func KeyCheckInterval(cfg config.ConfigSMS) time.Duration {
+	if cfg.KeyCheck.IntervalMinutes <= 0 {
+		return DefaultKeyCheckInterval
+	}
+	return time.Duration(cfg.KeyCheck.IntervalMinutes) * time.Minute
+}
+
+func defaultHTTPClient(client *http.Client) *http.Client {
+	if client == nil {
+		return &http.Client{Timeout: 10 * time.Second}
+	}
+	return client
+}
+
+// doJSON sends req and decodes its JSON response into v. Error responses
+// are reported with the start of their body, which is where venues explain
+// a rejected key.
+func doJSON(client *http.Client, req *http.Request, v any) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s: %w", req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("failed to fetch %s: %s: %s", req.URL.Path, resp.Status, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", req.URL.Path, err)
+	}
+	return nil
+}
+
+// BinanceKeyChecker reads the apiRestrictions endpoint. Binance reports
+// whether a key is IP-restricted but not the whitelist itself.
+type BinanceKeyChecker struct {
+	baseURL string
+	client  *http.Client
+	now     func() time.Time
+}
+
+func NewBinanceKeyChecker(baseURL string, client *http.Client) *BinanceKeyChecker {
+	if baseURL == "" {
+		baseURL = BinanceBaseURL
+	}
+	return &BinanceKeyChecker{baseURL: strings.TrimSuffix(baseURL, "/"), client: defaultHTTPClient(client), now: time.Now}
+}
+
+func (c *BinanceKeyChecker) Exchange() string {
+	return "binance"
+}
+
+type binanceAPIRestrictions struct {
+	IPRestrict                 bool `json:"ipRestrict"`
+	EnableReading              bool `json:"enableReading"`
+	EnableSpotAndMarginTrading bool `json:"enableSpotAndMarginTrading"`
+	EnableMargin               bool `json:"enableMargin"`
+	EnableFutures              bool `json:"enableFutures"`
+	EnableWithdrawals          bool `json:"enableWithdrawals"`
+	EnableInternalTransfer     bool `json:"enableInternalTransfer"`
+	PermitsUniversalTransfer   bool `json:"permitsUniversalTransfer"`
+}
+
+func (c *BinanceKeyChecker) CheckKey(ctx context.Context, signer Signer) (KeyPermissions, error) {
+	apiKey, err := signer.APIKey()
+	if err != nil {
+		return KeyPermissions{}, err
+	}
+	keyType, err := signer.KeyType()
+	if err != nil {
+		return KeyPermissions{}, err
+	}
+	query := "timestamp=" + strconv.FormatInt(c.now().UnixMilli(), 10)
+	signature, err := binanceSignature(signer, keyType, query)
+	if err != nil {
+		return KeyPermissions{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/sapi/v1/account/apiRestrictions?"+query+"&signature="+signature, nil)
+	if err != nil {
+		return KeyPermissions{}, err
+	}
+	req.Header.Set("X-MBX-APIKEY", apiKey)
+	var restrictions binanceAPIRestrictions
+	if err := doJSON(c.client, req, &restrictions); err != nil {
+		return KeyPermissions{}, err
+	}
+	return KeyPermissions{
+		Read:         restrictions.EnableReading,
+		Trade:        restrictions.EnableSpotAndMarginTrading,
+		Margin:       restrictions.EnableMargin,
+		Futures:      restrictions.EnableFutures,
+		Withdraw:     restrictions.EnableWithdrawals,
+		Transfer:     restrictions.EnableInternalTransfer || restrictions.PermitsUniversalTransfer,
+		IPRestricted: restrictions.IPRestrict,
+	}, nil
+}
+
+// binanceSignature signs query as Binance expects for keyType: HMAC keys
+// hex-encoded, Ed25519 and RSA keys base64-encoded.
+func binanceSignature(signer Signer, keyType KeyType, query string) (string, error) {
+	switch keyType {
+	case KeyTypeHMAC:
+		signature, err := signer.Sign(SignHMACSHA256, []byte(query))
+		return hex.EncodeToString(signature), err
+	case KeyTypeEd25519:
+		signature, err := signer.Sign(SignEd25519, []byte(query))
+		return url.QueryEscape(base64.StdEncoding.EncodeToString(signature)), err
+	case KeyTypeRSA:
+		signature, err := signer.Sign(SignRSASHA256, []byte(query))
+		return url.QueryEscape(base64.StdEncoding.EncodeToString(signature)), err
+	}
+	return "", fmt.Errorf("%w: %s key", ErrUnsupportedAlgorithm, keyType)
+}
+
+// OKXKeyChecker reads the account config endpoint, which lists the
+// permissions and whitelisted IPs of the key.
+type OKXKeyChecker struct {
+	baseURL string
+	client  *http.Client
+	now     func() time.Time
+}
+
+func NewOKXKeyChecker(baseURL string, client *http.Client) *OKXKeyChecker {
+	if baseURL == "" {
+		baseURL = OKXBaseURL
+	}
+	return &OKXKeyChecker{baseURL: strings.TrimSuffix(baseURL, "/"), client: defaultHTTPClient(client), now: time.Now}
+}
+
+func (c *OKXKeyChecker) Exchange() string {
+	return "okx"
+}
+
+type okxAccountConfig struct {
+	Code string `json:"code"`
+	Msg  string `json:"msg"`
+	Data []struct {
+		Perm string `json:"perm"` // e.g. "read_only,trade"
+		IP   string `json:"ip"`   // Comma-separated, empty if unrestricted
+	} `json:"data"`
+}
+
+func (c *OKXKeyChecker) CheckKey(ctx context.Context, signer Signer) (KeyPermissions, error) {
+	apiKey, err := signer.APIKey()
+	if err != nil {
+		return KeyPermissions{}, err
+	}
+	passphrase, err := signer.Passphrase()
+	if err != nil {
+		return KeyPermissions{}, err
+	}
+	const path = "/api/v5/account/config"
+	timestamp := c.now().UTC().Format("2006-01-02T15:04:05.000Z")
+	signature, err := signer.Sign(SignHMACSHA256, []byte(timestamp+http.MethodGet+path))
+	if err != nil {
+		return KeyPermissions{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return KeyPermissions{}, err
+	}
+	req.Header.Set("OK-ACCESS-KEY", apiKey)
+	req.Header.Set("OK-ACCESS-SIGN", base64.StdEncoding.EncodeToString(signature))
+	req.Header.Set("OK-ACCESS-TIMESTAMP", timestamp)
+	req.Header.Set("OK-ACCESS-PASSPHRASE", passphrase)
+	var accountConfig okxAccountConfig
+	if err := doJSON(c.client, req, &accountConfig); err != nil {
+		return KeyPermissions{}, err
+	}
+	if accountConfig.Code != "0" {
+		return KeyPermissions{}, fmt.Errorf("failed to fetch %s: code %s: %s", path, accountConfig.Code, accountConfig.Msg)
+	}
+	if len(accountConfig.Data) == 0 {
+		return KeyPermissions{}, fmt.Errorf("failed to fetch %s: no account config", path)
+	}
+
+	var perms KeyPermissions
+	for _, perm := range strings.Split(accountConfig.Data[0].Perm, ",") {
+		switch strings.TrimSpace(perm) {
+		case "read_only":
+			perms.Read = true
+		case "trade":
+			// OKX grants trading on every instrument type at once.
+			perms.Trade, perms.Margin, perms.Futures = true, true, true
+		case "withdraw":
+			perms.Withdraw = true
+		}
+	}
+	for _, ip := range strings.Split(accountConfig.Data[0].IP, ",") {
+		if ip = strings.TrimSpace(ip); ip != "" {
+			perms.IPWhitelist = append(perms.IPWhitelist, ip)
+		}
+	}
+	perms.IPRestricted = len(perms.IPWhitelist) > 0
+	return perms, nil
+}
+
+// secretSigner signs with a secret that is not stored yet, so AddSecret
+// and UpdateSecret can check a key before accepting it.
+type secretSigner struct {
+	secret Secret
+}
+
+func (s secretSigner) AcctID() int {
+	return s.secret.AcctID
+}
+
+func (s secretSigner) APIKey() (string, error) {
+	return s.secret.APIKey.Reveal(), nil
+}
+
+func (s secretSigner) Passphrase() (string, error) {
+	return s.secret.Passphrase.Reveal(), nil
+}
+
+func (s secretSigner) KeyType() (KeyType, error) {
+	return s.secret.KeyType, nil
+}
+
+func (s secretSigner) Sign(algorithm SignAlgorithm, payload []byte) ([]byte, error) {
+	return sign(s.secret, algorithm, payload)
+}
+
+// SetKeyCheckers replaces the checkers AddSecret, UpdateSecret and
+// RecheckKeys ask about API keys. Keys of exchanges without a checker are
+// stored unchecked.
+func (s *SecretManager) SetKeyCheckers(checkers ...KeyChecker) {
+	byExchange := make(map[string]KeyChecker, len(checkers))
+	for _, checker := range checkers {
+		byExchange[checker.Exchange()] = checker
+	}
+	s.mu.Lock()
+	s.keyCheckers = byExchange
+	s.mu.Unlock()
+}
+
+// checkKey returns the permissions of the API key of secret, or zero
+// KeyPermissions if its exchange has no checker. A key able to withdraw
+// fails with ErrWithdrawalEnabled along with its permissions.
+func (s *SecretManager) checkKey(ctx context.Context, secret Secret) (KeyPermissions, error) {
+	s.mu.RLock()
+	checker, ok := s.keyCheckers[secret.Exchange]
+	s.mu.RUnlock()
+	if !ok {
+		return KeyPermissions{}, nil
+	}
+	perms, err := checker.CheckKey(ctx, secretSigner{secret: secret})
+	if err != nil {
+		return KeyPermissions{}, fmt.Errorf("failed to check API key for acctID %d: %w", secret.AcctID, err)
+	}
+	perms.CheckedAt = time.Now()
+	if perms.Withdraw {
+		return perms, fmt.Errorf("%w for acctID: %d", ErrWithdrawalEnabled, secret.AcctID)
+	}
+	if !perms.IPRestricted {
+		log.Warn().Int("acct_id", secret.AcctID).Str("exchange", secret.Exchange).Msg("API key is not IP-restricted")
+	}
+	return perms, nil
+}
+
+// checkNewKey checks the key of a secret about to be added or updated.
+func (s *SecretManager) checkNewKey(secret Secret) (KeyPermissions, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), keyCheckTimeout)
+	defer cancel()
+	return s.checkKey(ctx, secret)
+}
+
+// KeyCheck is the outcome of re-checking the API key of one account.
+type KeyCheck struct {
+	AcctID      int
+	Permissions KeyPermissions
+	Disabled    bool  // The key could withdraw, so its secret was disabled
+	Err         error // The check failed or found the key able to withdraw
+}
+
+// RecheckKeys checks the API key of every active secret whose exchange has
+// a checker again and stores the permissions found. A key that gained
+// withdrawal permission is disabled. Checks that fail leave the stored
+// permissions as they were, and so do keys updated during the check.
+func (s *SecretManager) RecheckKeys(ctx context.Context) []KeyCheck {
+	var checks []KeyCheck
+	for _, secret := range s.list(func(secret Secret) bool { return secret.Active }) {
+		s.mu.RLock()
+		_, ok := s.keyCheckers[secret.Exchange]
+		s.mu.RUnlock()
+		if !ok {
+			continue
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		checks = append(checks, s.recheckKey(ctx, secret))
+	}
+	return checks
+}
+
+func (s *SecretManager) recheckKey(ctx context.Context, secret Secret) KeyCheck {
+	check := KeyCheck{AcctID: secret.AcctID}
+	perms, err := s.checkKey(ctx, secret)
+	check.Err = s.system.audit(AccessSign, secret.AcctID, err)
+	if err != nil && !errors.Is(err, ErrWithdrawalEnabled) {
+		return check
+	}
+	check.Permissions = perms
+	if err := s.setPermissions(secret, perms); err != nil {
+		check.Err = err
+		return check
+	}
+	if perms.Withdraw {
+		log.Error().Int("acct_id", secret.AcctID).Str("exchange", secret.Exchange).Msg("API key has withdrawal permission, disabling secret")
+		err := s.system.DisableSecret(secret.AcctID)
+		if errors.Is(err, ErrReadOnlyBackend) {
+			// The backend cannot record it, but the key must not be used.
+			err = s.disableCached(secret.AcctID)
+		}
+		if err != nil {
+			check.Err = errors.Join(check.Err, err)
+		} else {
+			check.Disabled = true
+		}
+	}
+	return check
+}
+
+// setPermissions stores the permissions found for the key of checked,
+// unless the key was replaced in the meantime.
+func (s *SecretManager) setPermissions(checked Secret, perms KeyPermissions) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	secret, err := s.lookup(checked.AcctID)
+	if err != nil {
+		return err
+	}
+	if secret.Exchange != checked.Exchange || secret.APIKey != checked.APIKey {
+		return nil
+	}
+	if err := s.backend.SetPermissions(secret.AcctID, perms); err != nil {
+		return fmt.Errorf("failed to store key permissions for acctID %d: %w", secret.AcctID, err)
+	}
+	secret.Permissions = perms
+	s.store(secret)
+	return nil
+}
+
+// disableCached disables the secret of acctID in the cache only, for a
+// backend that cannot store it. The secret is active again after a reload.
+func (s *SecretManager) disableCached(acctID int) error {
+	s.writeMu.Lock()
+	secret, err := s.lookup(acctID)
+	if err != nil || !secret.Active {
+		s.writeMu.Unlock()
+		return err
+	}
+	secret.Active = false
+	s.store(secret)
+	s.writeMu.Unlock()
+
+	s.publishChange(SecretChange{Kind: SecretDisabled, AcctID: acctID, Exchange: secret.Exchange})
+	return nil
+}
+
+// StartKeyChecks re-checks every key once, so keys loaded from the backend
+// without going through AddSecret are checked before they are used, and
+// then runs RunKeyChecks in a goroutine. errHandler receives failed checks.
+func (s *SecretManager) StartKeyChecks(ctx context.Context, interval time.Duration, errHandler func(error)) {
+	s.reportKeyChecks(ctx, errHandler)
+	go s.RunKeyChecks(ctx, interval, errHandler)
+}
+
+// RunKeyChecks calls RecheckKeys every interval until ctx is done.
+// errHandler receives failed checks.
+func (s *SecretManager) RunKeyChecks(ctx context.Context, interval time.Duration, errHandler func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.reportKeyChecks(ctx, errHandler)
+		}
+	}
+}
+
+func (s *SecretManager) reportKeyChecks(ctx context.Context, errHandler func(error)) {
+	for _, check := range s.RecheckKeys(ctx) {
+		if check.Err != nil && errHandler != nil {
+			errHandler(check.Err)
+		}
+	}
+}
@@ -0,0 +1,289 @@
+package sms
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BullionBear/seq/pkg/evbus"
+)
+
+var testCheckTime = time.Date(2026, 3, 2, 10, 30, 0, 0, time.UTC)
+
+func testHMAC(secret, payload string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+// binanceStandIn serves apiRestrictions for the key "key" signed with
+// "secret", answering with the restrictions currently set.
+type binanceStandIn struct {
+	mu           sync.Mutex
+	restrictions string
+	requests     int
+}
+
+func (b *binanceStandIn) set(restrictions string) {
+	b.mu.Lock()
+	b.restrictions = restrictions
+	b.mu.Unlock()
+}
+
+func (b *binanceStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.requests++
+	query := r.URL.Query()
+	signed := "timestamp=" + query.Get("timestamp")
+	if r.URL.Path != "/sapi/v1/account/apiRestrictions" || r.Header.Get("X-MBX-APIKEY") != "key" ||
+		query.Get("signature") != hex.EncodeToString(testHMAC("secret", signed)) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"code":-2015,"msg":"Invalid API-key, IP, or permissions for action."}`)
+		return
+	}
+	fmt.Fprint(w, b.restrictions)
+}
+
+func TestBinanceKeyChecker(t *testing.T) {
+	standIn := &binanceStandIn{}
+	standIn.set(`{"ipRestrict":true,"enableReading":true,"enableSpotAndMarginTrading":true,"enableWithdrawals":false,"enableInternalTransfer":false,"permitsUniversalTransfer":true,"enableFutures":false,"enableMargin":false}`)
+	server := httptest.NewServer(standIn)
+	defer server.Close()
+
+	checker := NewBinanceKeyChecker(server.URL, server.Client())
+	checker.now = func() time.Time { return testCheckTime }
+	perms, err := checker.CheckKey(context.Background(), secretSigner{secret: Secret{AcctID: 1, APIKey: "key", APISecret: "secret"}})
+	if err != nil {
+		t.Fatalf("CheckKey failed: %v", err)
+	}
+	want := KeyPermissions{Read: true, Trade: true, Transfer: true, IPRestricted: true}
+	if fmt.Sprint(perms) != fmt.Sprint(want) {
+		t.Errorf("Expected %+v, got %+v", want, perms)
+	}
+
+	_, err = checker.CheckKey(context.Background(), secretSigner{secret: Secret{AcctID: 1, APIKey: "key", APISecret: "wrong"}})
+	if err == nil || !strings.Contains(err.Error(), "Invalid API-key") {
+		t.Errorf("Expected the venue's rejection, got %v", err)
+	}
+}
+
+func TestOKXKeyChecker(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timestamp := r.Header.Get("OK-ACCESS-TIMESTAMP")
+		signature := base64.StdEncoding.EncodeToString(testHMAC("secret", timestamp+"GET/api/v5/account/config"))
+		if timestamp != "2026-03-02T10:30:00.000Z" || r.Header.Get("OK-ACCESS-KEY") != "key" ||
+			r.Header.Get("OK-ACCESS-PASSPHRASE") != "pass" || r.Header.Get("OK-ACCESS-SIGN") != signature {
+			fmt.Fprint(w, `{"code":"50113","msg":"Invalid Sign","data":[]}`)
+			return
+		}
+		fmt.Fprint(w, `{"code":"0","msg":"","data":[{"uid":"1","perm":"read_only,trade","ip":"10.0.0.1,10.0.0.2"}]}`)
+	}))
+	defer server.Close()
+
+	checker := NewOKXKeyChecker(server.URL, server.Client())
+	checker.now = func() time.Time { return testCheckTime }
+	perms, err := checker.CheckKey(context.Background(), secretSigner{secret: Secret{AcctID: 1, APIKey: "key", APISecret: "secret", Passphrase: "pass"}})
+	if err != nil {
+		t.Fatalf("CheckKey failed: %v", err)
+	}
+	if !perms.Read || !perms.Trade || !perms.Futures || perms.Withdraw || !perms.IPRestricted ||
+		strings.Join(perms.IPWhitelist, ",") != "10.0.0.1,10.0.0.2" {
+		t.Errorf("Unexpected permissions %+v", perms)
+	}
+
+	_, err = checker.CheckKey(context.Background(), secretSigner{secret: Secret{AcctID: 1, APIKey: "key", APISecret: "secret", Passphrase: "wrong"}})
+	if err == nil || !strings.Contains(err.Error(), "Invalid Sign") {
+		t.Errorf("Expected the venue's rejection, got %v", err)
+	}
+}
+
+func TestSecretManager_KeyChecks(t *testing.T) {
+	standIn := &binanceStandIn{}
+	server := httptest.NewServer(standIn)
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "secrets.enc.yml")
+	ring, _ := NewKeyRing(testMasterKey(t, 1))
+	s, err := NewSecretManagerWithBackend(NewFileBackend(path, ring))
+	if err != nil {
+		t.Fatalf("NewSecretManagerWithBackend failed: %v", err)
+	}
+	s.SetKeyCheckers(NewBinanceKeyChecker(server.URL, server.Client()))
+	var changes []SecretChange
+	if _, err := s.SubscribeSecretChanges(func(event *evbus.Event[SecretChange]) error {
+		changes = append(changes, event.Data)
+		return nil
+	}, nil); err != nil {
+		t.Fatalf("SubscribeSecretChanges failed: %v", err)
+	}
+
+	secret := Secret{AcctID: 1, Exchange: "binance", APIKey: "key", APISecret: "secret"}
+	standIn.set(`{"ipRestrict":true,"enableReading":true,"enableSpotAndMarginTrading":true,"enableWithdrawals":true}`)
+	if err := s.AddSecret(secret); !errors.Is(err, ErrWithdrawalEnabled) {
+		t.Fatalf("Expected ErrWithdrawalEnabled, got %v", err)
+	}
+	if _, err := s.GetSecret(1); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("Expected the refused key not to be stored, got %v", err)
+	}
+
+	standIn.set(`{"ipRestrict":true,"enableReading":true,"enableSpotAndMarginTrading":true}`)
+	if err := s.AddSecret(secret); err != nil {
+		t.Fatalf("AddSecret failed: %v", err)
+	}
+	stored, _ := s.GetSecret(1)
+	if !stored.Permissions.Checked() || !stored.Permissions.Trade || stored.Permissions.Withdraw {
+		t.Errorf("Expected the checked permissions to be stored, got %+v", stored.Permissions)
+	}
+	// Keys are only sent to the venue for secrets that can be written.
+	requests := standIn.requests
+	if err := s.AddSecret(secret); !errors.Is(err, ErrSecretExists) {
+		t.Errorf("Expected ErrSecretExists, got %v", err)
+	}
+	if err := s.AddSecret(Secret{AcctID: 3, Exchange: "binance", APIKey: "key", APISecret: "secret", MasterAcctID: 9}); !errors.Is(err, ErrInvalidMaster) {
+		t.Errorf("Expected ErrInvalidMaster, got %v", err)
+	}
+	if err := s.UpdateSecret(Secret{AcctID: 3, Exchange: "binance", APIKey: "key", APISecret: "secret"}); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("Expected ErrSecretNotFound, got %v", err)
+	}
+	if standIn.requests != requests {
+		t.Errorf("Expected no venue requests for refused writes, got %d", standIn.requests-requests)
+	}
+
+	// Keys of exchanges without a checker are stored unchecked.
+	if err := s.AddSecret(Secret{AcctID: 2, Exchange: "okx", APIKey: "other", APISecret: "other"}); err != nil {
+		t.Fatalf("AddSecret failed: %v", err)
+	}
+
+	// The key gains withdrawal permission at the venue.
+	standIn.set(`{"ipRestrict":true,"enableReading":true,"enableSpotAndMarginTrading":true,"enableWithdrawals":true}`)
+	checks := s.RecheckKeys(context.Background())
+	if len(checks) != 1 || checks[0].AcctID != 1 || !checks[0].Disabled || !errors.Is(checks[0].Err, ErrWithdrawalEnabled) {
+		t.Fatalf("Expected acctID 1 to be disabled, got %+v", checks)
+	}
+	if _, err := s.GetSecret(1); !errors.Is(err, ErrSecretDisabled) {
+		t.Errorf("Expected ErrSecretDisabled, got %v", err)
+	}
+	if len(changes) != 3 || changes[2].Kind != SecretDisabled {
+		t.Errorf("Expected the disabling to be published, got %+v", changes)
+	}
+
+	reopened, err := NewSecretManagerWithBackend(NewFileBackend(path, ring))
+	if err != nil {
+		t.Fatalf("NewSecretManagerWithBackend failed on reopen: %v", err)
+	}
+	reloaded, _ := reopened.lookup(1)
+	if !reloaded.Permissions.Withdraw || reloaded.Active {
+		t.Errorf("Expected the recheck to be stored, got %+v", reloaded)
+	}
+
+	// Disabled keys are not checked again, and failed checks keep what was stored.
+	if checks := s.RecheckKeys(context.Background()); len(checks) != 0 {
+		t.Errorf("Expected no checks of disabled keys, got %+v", checks)
+	}
+	server.Close()
+	if err := s.UpdateSecret(Secret{AcctID: 1, Exchange: "binance", APIKey: "key", APISecret: "secret"}); err == nil {
+		t.Error("Expected UpdateSecret to fail when the venue cannot be asked")
+	}
+}
+
+func TestSecretManager_KeyChecksReadOnlyBackend(t *testing.T) {
+	standIn := &binanceStandIn{}
+	standIn.set(`{"ipRestrict":true,"enableReading":true,"enableSpotAndMarginTrading":true,"enableWithdrawals":true}`)
+	server := httptest.NewServer(standIn)
+	defer server.Close()
+
+	backend := NewEnvBackend(DefaultEnvPrefix)
+	backend.environ = func() []string {
+		return []string{"SEQ_SECRET_1_EXCHANGE=binance", "SEQ_SECRET_1_API_KEY=key", "SEQ_SECRET_1_API_SECRET=secret"}
+	}
+	s, err := NewSecretManagerWithBackend(backend)
+	if err != nil {
+		t.Fatalf("NewSecretManagerWithBackend failed: %v", err)
+	}
+	s.SetKeyCheckers(NewBinanceKeyChecker(server.URL, server.Client()))
+	signer, err := s.Signer(1, PurposeTrading)
+	if err != nil {
+		t.Fatalf("Signer failed: %v", err)
+	}
+
+	checks := s.RecheckKeys(context.Background())
+	if len(checks) != 1 || !checks[0].Disabled || !errors.Is(checks[0].Err, ErrWithdrawalEnabled) {
+		t.Fatalf("Expected acctID 1 to be disabled, got %+v", checks)
+	}
+	if _, err := signer.Sign(SignHMACSHA256, []byte("payload")); !errors.Is(err, ErrSecretDisabled) {
+		t.Errorf("Expected the signer to stop signing, got %v", err)
+	}
+
+	// A key stored as able to withdraw is refused even while active.
+	secret, _ := s.lookup(1)
+	secret.Active = true
+	s.store(secret)
+	if _, err := s.GetSecret(1); !errors.Is(err, ErrWithdrawalEnabled) {
+		t.Errorf("Expected ErrWithdrawalEnabled, got %v", err)
+	}
+}
+
+func TestSecretManager_StartKeyChecks(t *testing.T) {
+	standIn := &binanceStandIn{}
+	standIn.set(`{"ipRestrict":true,"enableReading":true,"enableSpotAndMarginTrading":true}`)
+	server := httptest.NewServer(standIn)
+	defer server.Close()
+
+	// Secrets sealed into a file never go through AddSecret.
+	path := filepath.Join(t.TempDir(), "secrets.enc.yml")
+	ring, _ := NewKeyRing(testMasterKey(t, 1))
+	if err := NewFileBackend(path, ring).Replace([]Secret{{AcctID: 1, Exchange: "binance", APIKey: "key", APISecret: "secret", Active: true}}); err != nil {
+		t.Fatalf("Replace failed: %v", err)
+	}
+	s, err := NewSecretManagerWithBackend(NewFileBackend(path, ring))
+	if err != nil {
+		t.Fatalf("NewSecretManagerWithBackend failed: %v", err)
+	}
+	s.SetKeyCheckers(NewBinanceKeyChecker(server.URL, server.Client()))
+	disabled := make(chan struct{})
+	if _, err := s.SubscribeSecretChanges(func(event *evbus.Event[SecretChange]) error {
+		if event.Data.Kind == SecretDisabled {
+			close(disabled)
+		}
+		return nil
+	}, nil); err != nil {
+		t.Fatalf("SubscribeSecretChanges failed: %v", err)
+	}
+	errs := make(chan error, 16)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s.StartKeyChecks(ctx, 10*time.Millisecond, func(err error) { errs <- err })
+	if secret, _ := s.lookup(1); !secret.Permissions.Checked() || !secret.Active {
+		t.Fatalf("Expected the loaded key to be checked on start, got %+v", secret.Permissions)
+	}
+
+	standIn.set(`{"ipRestrict":true,"enableReading":true,"enableSpotAndMarginTrading":true,"enableWithdrawals":true}`)
+	select {
+	case <-disabled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the periodic check to disable acctID 1")
+	}
+	if err := <-errs; !errors.Is(err, ErrWithdrawalEnabled) {
+		t.Errorf("Expected ErrWithdrawalEnabled, got %v", err)
+	}
+	if _, err := s.GetSecret(1); !errors.Is(err, ErrSecretDisabled) {
+		t.Errorf("Expected ErrSecretDisabled, got %v", err)
+	}
+	loaded, err := NewFileBackend(path, ring).Load()
+	if err != nil || len(loaded) != 1 || loaded[0].Active || !loaded[0].Permissions.Withdraw {
+		t.Errorf("Expected the file to record the disabled key, got %+v (%v)", loaded, err)
+	}
+}
@@ -0,0 +1,202 @@
+package sms
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/BullionBear/seq/internal/config"
+	"gorm.io/gorm"
+)
+
+const (
+	QueryWrappedKeys = `
+		SELECT acct_id, wrapped_key, key_version FROM secrets
+		WHERE ciphertext IS NOT NULL AND key_version <> ? ORDER BY acct_id FOR UPDATE
+	`
+	RewrapSecret = `
+		UPDATE secrets SET wrapped_key = ?, key_version = ? WHERE acct_id = ?
+	`
+	InsertKeyRotation = `
+		INSERT INTO secret_key_rotations (key_version, previous_versions, rewrapped, rotated_by, started_at, completed_at)
+		VALUES (?, ?, ?, ?, ?, ?) RETURNING rotation_id
+	`
+)
+
+// KeyRing holds every master key version still in use. New data keys are
+// wrapped with the primary key; data keys wrapped with any older version in
+// the ring still unwrap, so secrets stay readable while a rotation is rolled
+// out.
+type KeyRing struct {
+	primary KeyProvider
+	keys    map[int]KeyProvider
+}
+
+// NewKeyRing returns a ring whose primary key is primary, able to unwrap
+// with primary and every key in previous.
+func NewKeyRing(primary KeyProvider, previous ...KeyProvider) (*KeyRing, error) {
+	ring := &KeyRing{primary: primary, keys: map[int]KeyProvider{primary.Version(): primary}}
+	for _, key := range previous {
+		if _, ok := ring.keys[key.Version()]; ok {
+			return nil, fmt.Errorf("duplicate master key version %d", key.Version())
+		}
+		ring.keys[key.Version()] = key
+	}
+	return ring, nil
+}
+
+// NewKeyRingFromConfig builds the ring of cfg.MasterKey and
+// cfg.PreviousKeys.
+func NewKeyRingFromConfig(cfg config.ConfigSMS) (*KeyRing, error) {
+	primary, err := NewKeyProvider(cfg.MasterKey)
+	if err != nil {
+		return nil, err
+	}
+	previous := make([]KeyProvider, 0, len(cfg.PreviousKeys))
+	for _, keyConfig := range cfg.PreviousKeys {
+		key, err := NewKeyProvider(keyConfig)
+		if err != nil {
+			return nil, fmt.Errorf("previous master key: %w", err)
+		}
+		previous = append(previous, key)
+	}
+	return NewKeyRing(primary, previous...)
+}
+
+// Primary returns the key new data keys are wrapped with.
+func (r *KeyRing) Primary() KeyProvider {
+	return r.primary
+}
+
+// Key returns the key of version.
+func (r *KeyRing) Key(version int) (KeyProvider, error) {
+	key, ok := r.keys[version]
+	if !ok {
+		return nil, fmt.Errorf("%w: master key version %d", ErrKeyNotFound, version)
+	}
+	return key, nil
+}
+
+// Versions returns the versions in the ring in ascending order.
+func (r *KeyRing) Versions() []int {
+	versions := make([]int, 0, len(r.keys))
+	for version := range r.keys {
+		versions = append(versions, version)
+	}
+	sort.Ints(versions)
+	return versions
+}
+
+// KeyRotation is the audit record of one master key rotation.
+type KeyRotation struct {
+	RotationID       int64
+	KeyVersion       int
+	PreviousVersions map[int]int // Number of data keys re-wrapped per version
+	Rewrapped        int
+	RotatedBy        string
+	StartedAt        time.Time
+	CompletedAt      time.Time
+}
+
+// RotateMasterKey re-wraps every data key that is not wrapped with the
+// primary key of ring, and records the rotation in secret_key_rotations, in
+// a single transaction. Ciphertexts are unchanged: only the wrapped data
+// keys are replaced. Every key version found in the table must be in the
+// ring, or nothing is written.
+func RotateMasterKey(ctx context.Context, db *gorm.DB, ring *KeyRing, rotatedBy string) (KeyRotation, error) {
+	rotation := KeyRotation{
+		KeyVersion:       ring.Primary().Version(),
+		PreviousVersions: make(map[int]int),
+		RotatedBy:        rotatedBy,
+		StartedAt:        time.Now(),
+	}
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		type wrappedKey struct {
+			acctID  int
+			wrapped []byte
+			version int
+		}
+		rows, err := tx.Raw(QueryWrappedKeys, rotation.KeyVersion).Rows()
+		if err != nil {
+			return err
+		}
+		var keys []wrappedKey
+		for rows.Next() {
+			var key wrappedKey
+			if err := rows.Scan(&key.acctID, &key.wrapped, &key.version); err != nil {
+				rows.Close()
+				return err
+			}
+			keys = append(keys, key)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, key := range keys {
+			rewrapped, err := rewrapKey(ring, key.acctID, key.wrapped, key.version)
+			if err != nil {
+				return err
+			}
+			if err := tx.Exec(RewrapSecret, rewrapped, rotation.KeyVersion, key.acctID).Error; err != nil {
+				return fmt.Errorf("failed to re-wrap data key for acctID %d: %w", key.acctID, err)
+			}
+			rotation.PreviousVersions[key.version]++
+		}
+		rotation.Rewrapped = len(keys)
+		rotation.CompletedAt = time.Now()
+
+		var rotationID sql.NullInt64
+		err = tx.Raw(InsertKeyRotation, rotation.KeyVersion, formatVersionCounts(rotation.PreviousVersions), rotation.Rewrapped,
+			rotation.RotatedBy, rotation.StartedAt, rotation.CompletedAt).Scan(&rotationID).Error
+		if err != nil {
+			return fmt.Errorf("failed to record key rotation: %w", err)
+		}
+		rotation.RotationID = rotationID.Int64
+		return nil
+	})
+	if err != nil {
+		return KeyRotation{}, err
+	}
+	return rotation, nil
+}
+
+// rewrapKey unwraps the data key of acctID with key version and wraps it
+// with the primary key.
+func rewrapKey(ring *KeyRing, acctID int, wrapped []byte, version int) ([]byte, error) {
+	key, err := ring.Key(version)
+	if err != nil {
+		return nil, fmt.Errorf("cannot re-wrap data key for acctID %d: %w", acctID, err)
+	}
+	aad := secretAAD(acctID)
+	dataKey, err := key.Unwrap(wrapped, aad)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key for acctID %d: %w", acctID, err)
+	}
+	rewrapped, err := ring.Primary().Wrap(dataKey, aad)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap data key for acctID %d: %w", acctID, err)
+	}
+	return rewrapped, nil
+}
+
+// formatVersionCounts formats counts as "version:count" pairs in version
+// order, e.g. "1:40,2:3".
+func formatVersionCounts(counts map[int]int) string {
+	versions := make([]int, 0, len(counts))
+	for version := range counts {
+		versions = append(versions, version)
+	}
+	sort.Ints(versions)
+	formatted := ""
+	for n, version := range versions {
+		if n > 0 {
+			formatted += ","
+		}
+		formatted += fmt.Sprintf("%d:%d", version, counts[version])
+	}
+	return formatted
+}
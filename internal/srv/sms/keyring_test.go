@@ -0,0 +1,113 @@
+package sms
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/BullionBear/seq/internal/db/dbtest"
+)
+
+func TestKeyRing(t *testing.T) {
+	v1, v2 := testMasterKey(t, 1), testMasterKey(t, 2)
+	ring, err := NewKeyRing(v2, v1)
+	if err != nil {
+		t.Fatalf("NewKeyRing failed: %v", err)
+	}
+	if ring.Primary().Version() != 2 {
+		t.Errorf("Expected primary version 2, got %d", ring.Primary().Version())
+	}
+	if got := ring.Versions(); !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Errorf("Expected versions [1 2], got %v", got)
+	}
+	if _, err := ring.Key(3); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Expected ErrKeyNotFound, got %v", err)
+	}
+	if _, err := NewKeyRing(v1, testMasterKey(t, 1)); err == nil {
+		t.Error("Expected error for duplicate key versions")
+	}
+
+	// A secret sealed under version 1 decrypts through the ring, and after
+	// re-wrapping decrypts with version 2 alone.
+	secret := testSecret()
+	sealed, err := encryptSecret(v1, secret)
+	if err != nil {
+		t.Fatalf("encryptSecret failed: %v", err)
+	}
+	decrypted := Secret{AcctID: secret.AcctID}
+	if err := decryptSecret(ring, &decrypted, sealed); err != nil || decrypted.APISecret != "secret" {
+		t.Fatalf("Expected version 1 secret to decrypt, got %+v (%v)", decrypted, err)
+	}
+	sealed.WrappedKey, err = rewrapKey(ring, secret.AcctID, sealed.WrappedKey, sealed.KeyVersion)
+	if err != nil {
+		t.Fatalf("rewrapKey failed: %v", err)
+	}
+	sealed.KeyVersion = 2
+	v2Only, _ := NewKeyRing(v2)
+	decrypted = Secret{AcctID: secret.AcctID}
+	if err := decryptSecret(v2Only, &decrypted, sealed); err != nil || decrypted.APISecret != "secret" {
+		t.Errorf("Expected re-wrapped secret to decrypt with version 2, got %+v (%v)", decrypted, err)
+	}
+	if _, err := rewrapKey(ring, secret.AcctID+1, sealed.WrappedKey, 2); err == nil {
+		t.Error("Expected error re-wrapping the key of another account")
+	}
+}
+
+func TestFormatVersionCounts(t *testing.T) {
+	if got := formatVersionCounts(map[int]int{2: 3, 1: 40}); got != "1:40,2:3" {
+		t.Errorf("Expected 1:40,2:3, got %s", got)
+	}
+	if got := formatVersionCounts(nil); got != "" {
+		t.Errorf("Expected empty string, got %s", got)
+	}
+}
+
+func TestRotateMasterKey_Postgres(t *testing.T) {
+	pg := dbtest.Postgres(t)
+	v1, v2 := testMasterKey(t, 1), testMasterKey(t, 2)
+	insertTestUser(t, pg.DB)
+	for acctID := 1; acctID <= 3; acctID++ {
+		secret := testSecret()
+		secret.AcctID = acctID
+		sealed, err := encryptSecret(v1, secret)
+		if err != nil {
+			t.Fatalf("encryptSecret failed: %v", err)
+		}
+		err = pg.DB.Exec(`INSERT INTO secrets (acct_id, user_id, acct_name, exchange, ciphertext, nonce, wrapped_key, key_version)
+			VALUES (?, 1, 'main', 'okx', ?, ?, ?, ?)`, acctID, sealed.Ciphertext, sealed.Nonce, sealed.WrappedKey, sealed.KeyVersion).Error
+		if err != nil {
+			t.Fatalf("Failed to insert secret: %v", err)
+		}
+	}
+
+	// A ring without version 1 cannot rotate and writes nothing.
+	v2Only, _ := NewKeyRing(v2)
+	if _, err := RotateMasterKey(context.Background(), pg.DB, v2Only, "ops"); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("Expected ErrKeyNotFound, got %v", err)
+	}
+
+	ring, _ := NewKeyRing(v2, v1)
+	rotation, err := RotateMasterKey(context.Background(), pg.DB, ring, "ops")
+	if err != nil {
+		t.Fatalf("RotateMasterKey failed: %v", err)
+	}
+	if rotation.RotationID == 0 || rotation.Rewrapped != 3 || rotation.PreviousVersions[1] != 3 {
+		t.Errorf("Unexpected rotation: %+v", rotation)
+	}
+	var audited string
+	if err := pg.DB.Raw(`SELECT previous_versions FROM secret_key_rotations WHERE rotation_id = ?`, rotation.RotationID).Scan(&audited).Error; err != nil || audited != "1:3" {
+		t.Errorf("Expected audit record 1:3, got %q (%v)", audited, err)
+	}
+
+	manager, err := NewSecretManager(pg.DB, v2Only)
+	if err != nil {
+		t.Fatalf("Expected secrets to load with version 2 alone: %v", err)
+	}
+	if secret, err := manager.GetSecret(2); err != nil || secret.APISecret != "secret" {
+		t.Errorf("Expected decrypted secret, got %+v (%v)", secret, err)
+	}
+	if again, err := RotateMasterKey(context.Background(), pg.DB, ring, "ops"); err != nil || again.Rewrapped != 0 {
+		t.Errorf("Expected nothing left to rotate, got %+v (%v)", again, err)
+	}
+}
@@ -0,0 +1,280 @@
+package sms
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/BullionBear/seq/internal/config"
+)
+
+// KeySize is the size of master and data keys: AES-256.
+const KeySize = 32
+
+var ErrKeyNotFound = errors.New("key not found")
+
+// KeyProvider wraps the per-secret data keys with a master key it never
+// reveals. aad binds a wrapped key to the record it belongs to, so a wrapped
+// key copied to another record fails to unwrap.
+type KeyProvider interface {
+	Version() int // Stored with each wrapped key
+	Wrap(dataKey, aad []byte) ([]byte, error)
+	Unwrap(wrapped, aad []byte) ([]byte, error)
+}
+
+// MasterKey is a KeyProvider holding an AES-256 key in memory.
+type MasterKey struct {
+	version int
+	aead    cipher.AEAD
+}
+
+func NewMasterKey(key []byte, version int) (*MasterKey, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid master key: %w", err)
+	}
+	return &MasterKey{version: version, aead: aead}, nil
+}
+
+// NewMasterKeyFromFile reads a base64-encoded master key from path.
+func NewMasterKeyFromFile(path string, version int) (*MasterKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read master key: %w", err)
+	}
+	key, err := decodeKey(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("master key in %s: %w", path, err)
+	}
+	return NewMasterKey(key, version)
+}
+
+// NewMasterKeyFromEnv reads a base64-encoded master key from the
+// environment variable name.
+func NewMasterKeyFromEnv(name string, version int) (*MasterKey, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return nil, fmt.Errorf("master key variable %s not set", name)
+	}
+	key, err := decodeKey(value)
+	if err != nil {
+		return nil, fmt.Errorf("master key in %s: %w", name, err)
+	}
+	return NewMasterKey(key, version)
+}
+
+func (k *MasterKey) Version() int {
+	return k.version
+}
+
+func (k *MasterKey) Wrap(dataKey, aad []byte) ([]byte, error) {
+	return seal(k.aead, dataKey, aad)
+}
+
+func (k *MasterKey) Unwrap(wrapped, aad []byte) ([]byte, error) {
+	return open(k.aead, wrapped, aad)
+}
+
+// KMS is the subset of a key management service used to wrap data keys.
+// LocalKMS implements it for development and tests.
+type KMS interface {
+	Encrypt(keyID string, plaintext, aad []byte) ([]byte, error)
+	Decrypt(keyID string, ciphertext, aad []byte) ([]byte, error)
+}
+
+// KMSKeyProvider wraps data keys with the KMS key keyID.
+type KMSKeyProvider struct {
+	kms     KMS
+	keyID   string
+	version int
+}
+
+func NewKMSKeyProvider(kms KMS, keyID string, version int) *KMSKeyProvider {
+	return &KMSKeyProvider{kms: kms, keyID: keyID, version: version}
+}
+
+func (p *KMSKeyProvider) Version() int {
+	return p.version
+}
+
+func (p *KMSKeyProvider) Wrap(dataKey, aad []byte) ([]byte, error) {
+	return p.kms.Encrypt(p.keyID, dataKey, aad)
+}
+
+func (p *KMSKeyProvider) Unwrap(wrapped, aad []byte) ([]byte, error) {
+	return p.kms.Decrypt(p.keyID, wrapped, aad)
+}
+
+// LocalKMS is an in-process stand-in for a key management service. Keys are
+// created in memory or loaded from a JSON file mapping key IDs to
+// base64-encoded keys.
+type LocalKMS struct {
+	mu   sync.RWMutex
+	keys map[string]cipher.AEAD
+}
+
+func NewLocalKMS() *LocalKMS {
+	return &LocalKMS{keys: make(map[string]cipher.AEAD)}
+}
+
+// LoadLocalKMS reads the keys of a LocalKMS from path.
+func LoadLocalKMS(path string) (*LocalKMS, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read KMS keys: %w", err)
+	}
+	var encoded map[string]string
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return nil, fmt.Errorf("failed to decode KMS keys in %s: %w", path, err)
+	}
+	kms := NewLocalKMS()
+	for keyID, value := range encoded {
+		key, err := decodeKey(value)
+		if err != nil {
+			return nil, fmt.Errorf("KMS key %s: %w", keyID, err)
+		}
+		if err := kms.ImportKey(keyID, key); err != nil {
+			return nil, err
+		}
+	}
+	return kms, nil
+}
+
+// CreateKey generates a random key named keyID.
+func (k *LocalKMS) CreateKey(keyID string) error {
+	key, err := randomBytes(KeySize)
+	if err != nil {
+		return err
+	}
+	return k.ImportKey(keyID, key)
+}
+
+func (k *LocalKMS) ImportKey(keyID string, key []byte) error {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return fmt.Errorf("invalid KMS key %s: %w", keyID, err)
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if _, ok := k.keys[keyID]; ok {
+		return fmt.Errorf("KMS key %s already exists", keyID)
+	}
+	k.keys[keyID] = aead
+	return nil
+}
+
+func (k *LocalKMS) Encrypt(keyID string, plaintext, aad []byte) ([]byte, error) {
+	aead, err := k.key(keyID)
+	if err != nil {
+		return nil, err
+	}
+	return seal(aead, plaintext, aad)
+}
+
+func (k *LocalKMS) Decrypt(keyID string, ciphertext, aad []byte) ([]byte, error) {
+	aead, err := k.key(keyID)
+	if err != nil {
+		return nil, err
+	}
+	return open(aead, ciphertext, aad)
+}
+
+func (k *LocalKMS) key(keyID string) (cipher.AEAD, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	aead, ok := k.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: KMS key %s", ErrKeyNotFound, keyID)
+	}
+	return aead, nil
+}
+
+// NewKeyProvider returns the KeyProvider selected by cfg.Source: "file",
+// "env" or "kms" (a LocalKMS loaded from cfg.Path).
+func NewKeyProvider(cfg config.ConfigMasterKey) (KeyProvider, error) {
+	version := cfg.Version
+	if version == 0 {
+		version = 1
+	}
+	switch cfg.Source {
+	case "file":
+		return NewMasterKeyFromFile(cfg.Path, version)
+	case "env":
+		return NewMasterKeyFromEnv(cfg.Env, version)
+	case "kms":
+		kms, err := LoadLocalKMS(cfg.Path)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := kms.key(cfg.KeyID); err != nil {
+			return nil, err
+		}
+		return NewKMSKeyProvider(kms, cfg.KeyID, version), nil
+	}
+	return nil, fmt.Errorf("unknown master key source %q", cfg.Source)
+}
+
+// GenerateKey returns a random base64-encoded key for a master key file or
+// environment variable.
+func GenerateKey() (string, error) {
+	key, err := randomBytes(KeySize)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+func decodeKey(value string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+	if err != nil {
+		return nil, fmt.Errorf("not base64: %w", err)
+	}
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("expected %d bytes, got %d", KeySize, len(key))
+	}
+	return key, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("expected %d-byte key, got %d bytes", KeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plaintext with a random nonce and returns nonce || ciphertext.
+func seal(aead cipher.AEAD, plaintext, aad []byte) ([]byte, error) {
+	nonce, err := randomBytes(aead.NonceSize())
+	if err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+// open reverses seal.
+func open(aead cipher.AEAD, sealed, aad []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, aad)
+}
+
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("failed to generate random bytes: %w", err)
+	}
+	return b, nil
+}
@@ -0,0 +1,130 @@
+package sms
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+// MinRSAKeyBits is the smallest RSA key AddSecret accepts.
+const MinRSAKeyBits = 2048
+
+var ErrUnknownKeyType = errors.New("unknown key type")
+
+// KeyType is the kind of key material an account signs with.
+type KeyType int
+
+const (
+	KeyTypeHMAC    KeyType = iota // Shared APISecret
+	KeyTypeEd25519                // Ed25519 key pair, PrivateKey holds the private key
+	KeyTypeRSA                    // RSA key pair, PrivateKey holds the private key
+)
+
+func (k KeyType) String() string {
+	switch k {
+	case KeyTypeHMAC:
+		return "hmac"
+	case KeyTypeEd25519:
+		return "ed25519"
+	case KeyTypeRSA:
+		return "rsa"
+	}
+	return fmt.Sprintf("KeyType(%d)", int(k))
+}
+
+// ParseKeyType parses the key_type column.
+func ParseKeyType(s string) (KeyType, error) {
+	switch s {
+	case "hmac":
+		return KeyTypeHMAC, nil
+	case "ed25519":
+		return KeyTypeEd25519, nil
+	case "rsa":
+		return KeyTypeRSA, nil
+	}
+	return 0, fmt.Errorf("%w: %q", ErrUnknownKeyType, s)
+}
+
+// validateKey checks that the key material of secret matches its KeyType:
+// HMAC secrets carry no private key, and the private key of a key pair must
+// parse as the declared type.
+func validateKey(secret Secret) error {
+	switch secret.KeyType {
+	case KeyTypeHMAC:
+		if secret.PrivateKey != "" {
+			return fmt.Errorf("%w: HMAC secret for acctID %d has a private key", ErrInvalidPrivateKey, secret.AcctID)
+		}
+		return nil
+	case KeyTypeEd25519, KeyTypeRSA:
+		if _, err := signingKey(secret); err != nil {
+			return fmt.Errorf("acctID %d: %w", secret.AcctID, err)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w for acctID %d: %s", ErrUnknownKeyType, secret.AcctID, secret.KeyType)
+}
+
+// signingKey parses the private key of secret and checks it is of its
+// KeyType.
+func signingKey(secret Secret) (crypto.Signer, error) {
+	key, err := parsePrivateKey(secret.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	switch key := key.(type) {
+	case ed25519.PrivateKey:
+		if secret.KeyType == KeyTypeEd25519 {
+			return key, nil
+		}
+	case *rsa.PrivateKey:
+		if secret.KeyType == KeyTypeRSA {
+			if key.N.BitLen() < MinRSAKeyBits {
+				return nil, fmt.Errorf("%w: %d-bit RSA key, need at least %d", ErrInvalidPrivateKey, key.N.BitLen(), MinRSAKeyBits)
+			}
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: key type %s does not match %T", ErrInvalidPrivateKey, secret.KeyType, key)
+}
+
+// parsePrivateKey decodes a PEM-encoded PKCS#8 private key, or a PKCS#1 RSA
+// private key.
+func parsePrivateKey(data SecretString) (crypto.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(data.Reveal()))
+	if block == nil {
+		return nil, fmt.Errorf("%w: no PEM block", ErrInvalidPrivateKey)
+	}
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPrivateKey, err)
+		}
+		return key, nil
+	case "RSA PRIVATE KEY":
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPrivateKey, err)
+		}
+		return key, nil
+	}
+	return nil, fmt.Errorf("%w: unexpected PEM block %q", ErrInvalidPrivateKey, block.Type)
+}
+
+// PublicKeyPEM returns the PKIX PEM-encoded public key of a key pair
+// secret, as registered with the exchange.
+func (s Secret) PublicKeyPEM() (string, error) {
+	key, err := signingKey(s)
+	if err != nil {
+		return "", err
+	}
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), nil
+}
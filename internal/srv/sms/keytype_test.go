@@ -0,0 +1,112 @@
+package sms
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+)
+
+func TestValidateKey(t *testing.T) {
+	_, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	weakRSA, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	edPEM := testPEM(t, edPrivate)
+
+	valid := []Secret{
+		{AcctID: 1, APISecret: "secret"},
+		{AcctID: 1, KeyType: KeyTypeEd25519, PrivateKey: edPEM},
+	}
+	for _, secret := range valid {
+		if err := validateKey(secret); err != nil {
+			t.Errorf("Expected %s secret to be valid, got %v", secret.KeyType, err)
+		}
+	}
+
+	invalid := map[string]struct {
+		secret Secret
+		err    error
+	}{
+		"hmac with private key": {Secret{AcctID: 1, PrivateKey: edPEM}, ErrInvalidPrivateKey},
+		"type mismatch":         {Secret{AcctID: 1, KeyType: KeyTypeRSA, PrivateKey: edPEM}, ErrInvalidPrivateKey},
+		"not PEM":               {Secret{AcctID: 1, KeyType: KeyTypeEd25519, PrivateKey: "secret"}, ErrInvalidPrivateKey},
+		"bad PEM block":         {Secret{AcctID: 1, KeyType: KeyTypeEd25519, PrivateKey: SecretString(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("junk")}))}, ErrInvalidPrivateKey},
+		"weak RSA":              {Secret{AcctID: 1, KeyType: KeyTypeRSA, PrivateKey: testPEM(t, weakRSA)}, ErrInvalidPrivateKey},
+		"unknown type":          {Secret{AcctID: 1, KeyType: KeyType(9)}, ErrUnknownKeyType},
+	}
+	for name, tt := range invalid {
+		if err := validateKey(tt.secret); !errors.Is(err, tt.err) {
+			t.Errorf("Expected %v for %s, got %v", tt.err, name, err)
+		}
+	}
+
+	s, changes := newTestManager(t)
+	if err := s.AddSecret(invalid["type mismatch"].secret); !errors.Is(err, ErrInvalidPrivateKey) {
+		t.Errorf("Expected AddSecret to reject a mismatched key, got %v", err)
+	}
+	if len(*changes) != 0 {
+		t.Errorf("Expected no changes, got %+v", *changes)
+	}
+}
+
+func TestKeyType_ParseRoundTrip(t *testing.T) {
+	for _, keyType := range []KeyType{KeyTypeHMAC, KeyTypeEd25519, KeyTypeRSA} {
+		if parsed, err := ParseKeyType(keyType.String()); err != nil || parsed != keyType {
+			t.Errorf("Expected %s to round-trip, got %s (%v)", keyType, parsed, err)
+		}
+	}
+	if _, err := ParseKeyType("dsa"); !errors.Is(err, ErrUnknownKeyType) {
+		t.Errorf("Expected ErrUnknownKeyType, got %v", err)
+	}
+}
+
+func TestSecret_PublicKeyPEM(t *testing.T) {
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	secret := Secret{AcctID: 1, KeyType: KeyTypeEd25519, PrivateKey: testPEM(t, edPrivate)}
+	publicPEM, err := secret.PublicKeyPEM()
+	if err != nil {
+		t.Fatalf("PublicKeyPEM failed: %v", err)
+	}
+	block, _ := pem.Decode([]byte(publicPEM))
+	if block == nil || block.Type != "PUBLIC KEY" {
+		t.Fatalf("Expected a PUBLIC KEY block, got %q", publicPEM)
+	}
+	public, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil || !edPublic.Equal(public) {
+		t.Errorf("Expected the Ed25519 public key, got %v (%v)", public, err)
+	}
+	if _, err := (Secret{APISecret: "secret"}).PublicKeyPEM(); !errors.Is(err, ErrInvalidPrivateKey) {
+		t.Errorf("Expected HMAC secrets to have no public key, got %v", err)
+	}
+}
+
+func TestEncryptSecret_PrivateKey(t *testing.T) {
+	_, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	ring, _ := NewKeyRing(testMasterKey(t, 1))
+	secret := Secret{AcctID: 7, APIKey: "key", KeyType: KeyTypeEd25519, PrivateKey: testPEM(t, edPrivate)}
+	sealed, err := encryptSecret(ring.Primary(), secret)
+	if err != nil {
+		t.Fatalf("encryptSecret failed: %v", err)
+	}
+	decrypted := Secret{AcctID: 7, KeyType: KeyTypeEd25519}
+	if err := decryptSecret(ring, &decrypted, sealed); err != nil {
+		t.Fatalf("decryptSecret failed: %v", err)
+	}
+	if decrypted.PrivateKey != secret.PrivateKey || validateKey(decrypted) != nil {
+		t.Error("Expected the private key to survive encryption")
+	}
+}
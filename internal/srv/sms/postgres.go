@@ -0,0 +1,248 @@
+package sms
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+const (
+	QueryAllSecrets = `
+		SELECT acct_id, user_id, acct_name, exchange, api_key, api_secret, passphrase, master_acct_id, active, key_type, ciphertext, nonce, wrapped_key, key_version FROM secrets
+	`
+	InsertSecret = `
+		INSERT INTO secrets (acct_id, user_id, acct_name, exchange, key_type, ciphertext, nonce, wrapped_key, key_version, master_acct_id, active) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	UpdateSecret = `
+		UPDATE secrets SET user_id = ?, acct_name = ?, exchange = ?, api_key = NULL, api_secret = NULL, passphrase = NULL, key_type = ?,
+			ciphertext = ?, nonce = ?, wrapped_key = ?, key_version = ?, master_acct_id = ?
+		WHERE acct_id = ?
+	`
+	SetSecretActive = `
+		UPDATE secrets SET active = ? WHERE acct_id = ?
+	`
+	DeleteSecret = `
+		DELETE FROM secrets WHERE acct_id = ?
+	`
+	QueryPlaintextSecrets = `
+		SELECT acct_id, api_key, api_secret, passphrase FROM secrets WHERE ciphertext IS NULL FOR UPDATE
+	`
+	QueryAllKeyPermissions = `
+		SELECT acct_id, can_read, can_trade, can_margin, can_futures, can_withdraw, can_transfer, ip_restricted, ip_whitelist, checked_at FROM secret_key_permissions
+	`
+	UpsertKeyPermissions = `
+		INSERT INTO secret_key_permissions (acct_id, can_read, can_trade, can_margin, can_futures, can_withdraw, can_transfer, ip_restricted, ip_whitelist, checked_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (acct_id) DO UPDATE SET can_read = EXCLUDED.can_read, can_trade = EXCLUDED.can_trade, can_margin = EXCLUDED.can_margin,
+			can_futures = EXCLUDED.can_futures, can_withdraw = EXCLUDED.can_withdraw, can_transfer = EXCLUDED.can_transfer,
+			ip_restricted = EXCLUDED.ip_restricted, ip_whitelist = EXCLUDED.ip_whitelist, checked_at = EXCLUDED.checked_at
+	`
+	DeleteKeyPermissions = `
+		DELETE FROM secret_key_permissions WHERE acct_id = ?
+	`
+	EncryptSecret = `
+		UPDATE secrets SET api_key = NULL, api_secret = NULL, passphrase = NULL, ciphertext = ?, nonce = ?, wrapped_key = ?, key_version = ?
+		WHERE acct_id = ?
+	`
+)
+
+// PostgresBackend stores secrets in the secrets table. Credentials are
+// encrypted with a data key per secret, wrapped by the primary master key
+// of keys, and decrypted when loaded.
+type PostgresBackend struct {
+	db   *gorm.DB
+	keys *KeyRing
+}
+
+func NewPostgresBackend(db *gorm.DB, keys *KeyRing) *PostgresBackend {
+	return &PostgresBackend{db: db, keys: keys}
+}
+
+// Load reads and decrypts every secret. Rows written before encryption are
+// read from their plaintext columns until EncryptPlaintextSecrets converts
+// them.
+func (b *PostgresBackend) Load() ([]Secret, error) {
+	rows, err := b.db.Raw(QueryAllSecrets).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var secrets []Secret
+	for rows.Next() {
+		var secret Secret
+		var apiKey, apiSecret, passphrase sql.NullString
+		var sealed sealedSecret
+		var masterAcctID, keyVersion sql.NullInt64
+		var keyType string
+		err := rows.Scan(&secret.AcctID, &secret.UserID, &secret.AcctName, &secret.Exchange, &apiKey, &apiSecret, &passphrase, &masterAcctID, &secret.Active,
+			&keyType, &sealed.Ciphertext, &sealed.Nonce, &sealed.WrappedKey, &keyVersion)
+		if err != nil {
+			return nil, err
+		}
+		secret.MasterAcctID = int(masterAcctID.Int64)
+		if secret.KeyType, err = ParseKeyType(keyType); err != nil {
+			return nil, fmt.Errorf("acctID %d: %w", secret.AcctID, err)
+		}
+		if sealed.Ciphertext == nil {
+			log.Warn().Int("acct_id", secret.AcctID).Msg("Secret stored in plaintext")
+			secret.APIKey, secret.APISecret, secret.Passphrase = SecretString(apiKey.String), SecretString(apiSecret.String), SecretString(passphrase.String)
+		} else {
+			sealed.KeyVersion = int(keyVersion.Int64)
+			if err := decryptSecret(b.keys, &secret, sealed); err != nil {
+				return nil, err
+			}
+		}
+		secrets = append(secrets, secret)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	perms, err := b.loadPermissions()
+	if err != nil {
+		return nil, err
+	}
+	for n := range secrets {
+		secrets[n].Permissions = perms[secrets[n].AcctID]
+	}
+	return secrets, nil
+}
+
+func (b *PostgresBackend) loadPermissions() (map[int]KeyPermissions, error) {
+	rows, err := b.db.Raw(QueryAllKeyPermissions).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	perms := make(map[int]KeyPermissions)
+	for rows.Next() {
+		var acctID int
+		var p KeyPermissions
+		var ipWhitelist string
+		err := rows.Scan(&acctID, &p.Read, &p.Trade, &p.Margin, &p.Futures, &p.Withdraw, &p.Transfer, &p.IPRestricted, &ipWhitelist, &p.CheckedAt)
+		if err != nil {
+			return nil, err
+		}
+		if ipWhitelist != "" {
+			p.IPWhitelist = strings.Split(ipWhitelist, ",")
+		}
+		perms[acctID] = p
+	}
+	return perms, rows.Err()
+}
+
+func (b *PostgresBackend) Insert(secret Secret) error {
+	sealed, err := encryptSecret(b.keys.Primary(), secret)
+	if err != nil {
+		return err
+	}
+	return b.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(InsertSecret, secret.AcctID, secret.UserID, secret.AcctName, secret.Exchange, secret.KeyType.String(),
+			sealed.Ciphertext, sealed.Nonce, sealed.WrappedKey, sealed.KeyVersion, nullableAcctID(secret.MasterAcctID), secret.Active).Error
+		if err != nil {
+			return err
+		}
+		return writePermissions(tx, secret.AcctID, secret.Permissions)
+	})
+}
+
+// Update re-encrypts the credentials of secret under a new data key.
+func (b *PostgresBackend) Update(secret Secret) error {
+	sealed, err := encryptSecret(b.keys.Primary(), secret)
+	if err != nil {
+		return err
+	}
+	return b.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Exec(UpdateSecret, secret.UserID, secret.AcctName, secret.Exchange, secret.KeyType.String(),
+			sealed.Ciphertext, sealed.Nonce, sealed.WrappedKey, sealed.KeyVersion, nullableAcctID(secret.MasterAcctID), secret.AcctID)
+		if err := requireRow(result, secret.AcctID); err != nil {
+			return err
+		}
+		return writePermissions(tx, secret.AcctID, secret.Permissions)
+	})
+}
+
+func (b *PostgresBackend) SetPermissions(acctID int, perms KeyPermissions) error {
+	return b.db.Transaction(func(tx *gorm.DB) error {
+		return writePermissions(tx, acctID, perms)
+	})
+}
+
+// writePermissions stores perms for acctID, or removes the stored
+// permissions if perms were never checked, e.g. after the key changed.
+func writePermissions(tx *gorm.DB, acctID int, perms KeyPermissions) error {
+	if !perms.Checked() {
+		return tx.Exec(DeleteKeyPermissions, acctID).Error
+	}
+	return tx.Exec(UpsertKeyPermissions, acctID, perms.Read, perms.Trade, perms.Margin, perms.Futures, perms.Withdraw, perms.Transfer,
+		perms.IPRestricted, strings.Join(perms.IPWhitelist, ","), perms.CheckedAt).Error
+}
+
+func (b *PostgresBackend) SetActive(acctID int, active bool) error {
+	return b.db.Transaction(func(tx *gorm.DB) error {
+		return requireRow(tx.Exec(SetSecretActive, active, acctID), acctID)
+	})
+}
+
+func (b *PostgresBackend) Delete(acctID int) error {
+	return b.db.Transaction(func(tx *gorm.DB) error {
+		return requireRow(tx.Exec(DeleteSecret, acctID), acctID)
+	})
+}
+
+// requireRow turns a statement that matched no row into ErrSecretNotFound.
+func requireRow(result *gorm.DB, acctID int) error {
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w for acctID: %d", ErrSecretNotFound, acctID)
+	}
+	return nil
+}
+
+// EncryptPlaintextSecrets encrypts every secret still stored in plaintext
+// and clears its plaintext columns, in a single transaction. It returns the
+// number of secrets encrypted.
+func (b *PostgresBackend) EncryptPlaintextSecrets() (int, error) {
+	encrypted := 0
+	err := b.db.Transaction(func(tx *gorm.DB) error {
+		rows, err := tx.Raw(QueryPlaintextSecrets).Rows()
+		if err != nil {
+			return err
+		}
+		var secrets []Secret
+		for rows.Next() {
+			var secret Secret
+			var apiKey, apiSecret, passphrase sql.NullString
+			if err := rows.Scan(&secret.AcctID, &apiKey, &apiSecret, &passphrase); err != nil {
+				rows.Close()
+				return err
+			}
+			secret.APIKey, secret.APISecret, secret.Passphrase = SecretString(apiKey.String), SecretString(apiSecret.String), SecretString(passphrase.String)
+			secrets = append(secrets, secret)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, secret := range secrets {
+			sealed, err := encryptSecret(b.keys.Primary(), secret)
+			if err != nil {
+				return err
+			}
+			if err := tx.Exec(EncryptSecret, sealed.Ciphertext, sealed.Nonce, sealed.WrappedKey, sealed.KeyVersion, secret.AcctID).Error; err != nil {
+				return fmt.Errorf("failed to encrypt secret for acctID %d: %w", secret.AcctID, err)
+			}
+		}
+		encrypted = len(secrets)
+		return nil
+	})
+	return encrypted, err
+}
@@ -0,0 +1,82 @@
+package sms
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/rs/zerolog"
+)
+
+// Redacted is what a non-empty SecretString prints as.
+const Redacted = "****"
+
+// SecretString holds key material. It prints as Redacted with every fmt
+// verb and marshals as Redacted to JSON, YAML and zerolog, so logging a
+// Secret or wrapping it in an error cannot leak it. Use Reveal to get the
+// value itself.
+type SecretString string
+
+// Reveal returns the value of s.
+func (s SecretString) Reveal() string {
+	return string(s)
+}
+
+// String returns Redacted, or "" if s is empty so missing credentials still
+// show up.
+func (s SecretString) String() string {
+	if s == "" {
+		return ""
+	}
+	return Redacted
+}
+
+// GoString makes %#v print the redacted value too.
+func (s SecretString) GoString() string {
+	return fmt.Sprintf("sms.SecretString(%q)", s.String())
+}
+
+// Format redacts every verb, including the ones fmt would otherwise apply
+// to the underlying string, such as %x and %d.
+func (s SecretString) Format(f fmt.State, verb rune) {
+	switch verb {
+	case 'q':
+		fmt.Fprintf(f, "%q", s.String())
+	case 'v':
+		if f.Flag('#') {
+			_, _ = io.WriteString(f, s.GoString())
+			return
+		}
+		fallthrough
+	default:
+		_, _ = io.WriteString(f, s.String())
+	}
+}
+
+// MarshalText covers encoders going through encoding.TextMarshaler.
+func (s SecretString) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s SecretString) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + s.String() + `"`), nil
+}
+
+func (s SecretString) MarshalYAML() (any, error) {
+	return s.String(), nil
+}
+
+// MarshalZerologObject lets a Secret be logged with zerolog's Object without
+// its key material.
+func (s Secret) MarshalZerologObject(e *zerolog.Event) {
+	e.Int("acct_id", s.AcctID).
+		Int("user_id", s.UserID).
+		Str("acct_name", s.AcctName).
+		Str("exchange", s.Exchange).
+		Stringer("api_key", s.APIKey).
+		Stringer("api_secret", s.APISecret).
+		Stringer("passphrase", s.Passphrase).
+		Stringer("key_type", s.KeyType).
+		Stringer("private_key", s.PrivateKey).
+		Int("master_acct_id", s.MasterAcctID).
+		Bool("active", s.Active)
+}
@@ -0,0 +1,51 @@
+package sms
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"gopkg.in/yaml.v3"
+)
+
+func TestSecretString_Redacts(t *testing.T) {
+	secret := Secret{AcctID: 7, Exchange: "okx", APIKey: "k-3f9a", APISecret: "s-8c2e", Passphrase: "p-51d0"}
+	var logged bytes.Buffer
+	log := zerolog.New(&logged)
+	log.Info().Object("secret", secret).Interface("any", secret).Stringer("key", secret.APIKey).Msg("")
+	jsonData, _ := json.Marshal(secret)
+	yamlData, _ := yaml.Marshal(secret)
+
+	outputs := map[string]string{
+		"%v":      fmt.Sprintf("%v", secret),
+		"%+v":     fmt.Sprintf("%+v", secret),
+		"%#v":     fmt.Sprintf("%#v", secret),
+		"%s":      fmt.Sprintf("%s", secret.APISecret),
+		"%q":      fmt.Sprintf("%q", secret.APISecret),
+		"%x":      fmt.Sprintf("%x", secret.APISecret),
+		"%d":      fmt.Sprintf("%d", secret.APISecret),
+		"error":   fmt.Errorf("bad secret %v", secret).Error(),
+		"json":    string(jsonData),
+		"yaml":    string(yamlData),
+		"zerolog": logged.String(),
+	}
+	for name, output := range outputs {
+		for _, value := range []string{"k-3f9a", "s-8c2e", "p-51d0", "732d38633265"} {
+			if strings.Contains(output, value) {
+				t.Errorf("%s leaked %q: %s", name, value, output)
+			}
+		}
+		if !strings.Contains(output, Redacted) {
+			t.Errorf("Expected %s to contain %s, got %s", name, Redacted, output)
+		}
+	}
+	if secret.APISecret.Reveal() != "s-8c2e" {
+		t.Errorf("Expected Reveal to return the value, got %q", secret.APISecret.Reveal())
+	}
+	if SecretString("").String() != "" {
+		t.Error("Expected an empty SecretString to print empty")
+	}
+}
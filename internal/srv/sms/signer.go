@@ -0,0 +1,178 @@
+package sms
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"hash"
+)
+
+var (
+	ErrUnsupportedAlgorithm = errors.New("unsupported signature algorithm")
+	ErrInvalidPrivateKey    = errors.New("invalid private key")
+)
+
+// SignAlgorithm is how a Signer signs a request payload.
+type SignAlgorithm int
+
+const (
+	SignHMACSHA256 SignAlgorithm = iota + 1 // HMAC-SHA256 keyed with APISecret, e.g. Binance and OKX
+	SignHMACSHA512                          // HMAC-SHA512 keyed with APISecret
+	SignEd25519                             // Ed25519 with a KeyTypeEd25519 PrivateKey
+	SignRSASHA256                           // RSASSA-PKCS1-v1_5 over SHA-256 with a KeyTypeRSA PrivateKey
+)
+
+func (a SignAlgorithm) String() string {
+	switch a {
+	case SignHMACSHA256:
+		return "HMAC-SHA256"
+	case SignHMACSHA512:
+		return "HMAC-SHA512"
+	case SignEd25519:
+		return "Ed25519"
+	case SignRSASHA256:
+		return "RSA-PKCS1v15-SHA256"
+	}
+	return fmt.Sprintf("SignAlgorithm(%d)", int(a))
+}
+
+// Signer signs request payloads for one account without revealing its key
+// material. Venue clients should sign through a Signer rather than call
+// GetSecret, so the raw secret never leaves this package; the interface also
+// leaves room for signing in a separate process.
+//
+// The API key and passphrase are not used for signing but sent in request
+// headers, so they are handed out.
+type Signer interface {
+	AcctID() int
+	APIKey() (string, error)
+	Passphrase() (string, error)
+	// KeyType tells which SignAlgorithm the account can use.
+	KeyType() (KeyType, error)
+	// Sign returns the raw signature of payload; callers encode it as the
+	// venue expects, e.g. hex or base64.
+	Sign(algorithm SignAlgorithm, payload []byte) ([]byte, error)
+}
+
+// Signer returns the Signer of acctID for purpose. The credentials are
+// resolved as by ResolveCredentials on every call, so updates, rotations
+// and DisableSecret take effect immediately.
+func (s *SecretManager) Signer(acctID int, purpose CredentialPurpose) (Signer, error) {
+	return s.system.Signer(acctID, purpose)
+}
+
+// accountSigner is the in-process Signer. Every signature, and every call
+// handing out credentials, is checked against the policy and recorded as an
+// AccessEvent.
+type accountSigner struct {
+	access  *SecretAccess
+	acctID  int
+	purpose CredentialPurpose
+	perm    Permission
+}
+
+func (a *accountSigner) AcctID() int {
+	return a.acctID
+}
+
+func (a *accountSigner) APIKey() (string, error) {
+	secret, err := a.credentials()
+	if err != nil {
+		return "", err
+	}
+	return secret.APIKey.Reveal(), nil
+}
+
+func (a *accountSigner) Passphrase() (string, error) {
+	secret, err := a.credentials()
+	if err != nil {
+		return "", err
+	}
+	return secret.Passphrase.Reveal(), nil
+}
+
+func (a *accountSigner) KeyType() (KeyType, error) {
+	secret, err := a.credentials()
+	if err != nil {
+		return 0, err
+	}
+	return secret.KeyType, nil
+}
+
+// credentials resolves the credentials of the signer for handing out
+// their non-signing parts, checked and recorded like Sign.
+func (a *accountSigner) credentials() (Secret, error) {
+	if err := a.access.authorize(AccessCredentials, a.acctID, a.perm); err != nil {
+		return Secret{}, err
+	}
+	secret, err := a.access.manager.resolveCredentials(a.acctID, a.purpose)
+	return secret, a.access.audit(AccessCredentials, a.acctID, err)
+}
+
+func (a *accountSigner) Sign(algorithm SignAlgorithm, payload []byte) ([]byte, error) {
+	if err := a.access.authorize(AccessSign, a.acctID, a.perm); err != nil {
+		return nil, err
+	}
+	secret, err := a.access.manager.resolveCredentials(a.acctID, a.purpose)
+	if err != nil {
+		return nil, a.access.audit(AccessSign, a.acctID, err)
+	}
+	signature, err := sign(secret, algorithm, payload)
+	if err != nil {
+		err = fmt.Errorf("failed to sign for acctID %d: %w", a.acctID, err)
+	}
+	return signature, a.access.audit(AccessSign, a.acctID, err)
+}
+
+// sign signs payload with the key material of secret. HMAC algorithms need
+// a KeyTypeHMAC secret, the others a key pair of the matching type.
+func sign(secret Secret, algorithm SignAlgorithm, payload []byte) ([]byte, error) {
+	switch algorithm {
+	case SignHMACSHA256, SignHMACSHA512:
+		if secret.KeyType != KeyTypeHMAC {
+			return nil, fmt.Errorf("%w: %s with a %s key", ErrUnsupportedAlgorithm, algorithm, secret.KeyType)
+		}
+		if algorithm == SignHMACSHA512 {
+			return hmacSign(sha512.New, secret.APISecret, payload), nil
+		}
+		return hmacSign(sha256.New, secret.APISecret, payload), nil
+	case SignEd25519:
+		if secret.KeyType != KeyTypeEd25519 {
+			return nil, fmt.Errorf("%w: %s with a %s key", ErrUnsupportedAlgorithm, algorithm, secret.KeyType)
+		}
+		key, err := signingKey(secret)
+		if err != nil {
+			return nil, err
+		}
+		return ed25519Sign(key, payload)
+	case SignRSASHA256:
+		if secret.KeyType != KeyTypeRSA {
+			return nil, fmt.Errorf("%w: %s with a %s key", ErrUnsupportedAlgorithm, algorithm, secret.KeyType)
+		}
+		key, err := signingKey(secret)
+		if err != nil {
+			return nil, err
+		}
+		return rsaSign(key, payload)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, algorithm)
+}
+
+func hmacSign(newHash func() hash.Hash, key SecretString, payload []byte) []byte {
+	mac := hmac.New(newHash, []byte(key.Reveal()))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+func ed25519Sign(key crypto.Signer, payload []byte) ([]byte, error) {
+	return key.Sign(rand.Reader, payload, crypto.Hash(0))
+}
+
+func rsaSign(key crypto.Signer, payload []byte) ([]byte, error) {
+	digest := sha256.Sum256(payload)
+	return key.Sign(rand.Reader, digest[:], crypto.SHA256)
+}
@@ -0,0 +1,119 @@
+package sms
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"testing"
+)
+
+func testPEM(t *testing.T, key crypto.PrivateKey) SecretString {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey failed: %v", err)
+	}
+	return SecretString(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+}
+
+func TestSigner_HMAC(t *testing.T) {
+	// Example request from the Binance API documentation
+	s, _ := newTestManager(t, Secret{
+		AcctID: 1, Exchange: "binance", APIKey: "vmPUZE6mv9SD5VNHk4HlWFsOr6aKE2zvsw0MuIgwCIPy6utIco14y7Ju91duEh8A",
+		APISecret: "NhqPtmdSJYdKjVHjA7PZj4Mge3R5YNiP1e3UZjInClVN65XAbvqqM6A7H5fATj0j", Active: true,
+	})
+	signer, err := s.Signer(1, PurposeTrading)
+	if err != nil {
+		t.Fatalf("Signer failed: %v", err)
+	}
+	payload := []byte("symbol=LTCBTC&side=BUY&type=LIMIT&timeInForce=GTC&quantity=1&price=0.1&recvWindow=5000&timestamp=1499827319559")
+	signature, err := signer.Sign(SignHMACSHA256, payload)
+	if err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	if got := hex.EncodeToString(signature); got != "c8db56825ae71d6d79447849e617115f4a920fa2acdcab2b053c4b2838bd6b71" {
+		t.Errorf("Unexpected HMAC-SHA256 signature %s", got)
+	}
+	if signature, err := signer.Sign(SignHMACSHA512, payload); err != nil || len(signature) != 64 {
+		t.Errorf("Expected a 64-byte HMAC-SHA512 signature, got %d bytes (%v)", len(signature), err)
+	}
+	if apiKey, err := signer.APIKey(); err != nil || apiKey != "vmPUZE6mv9SD5VNHk4HlWFsOr6aKE2zvsw0MuIgwCIPy6utIco14y7Ju91duEh8A" {
+		t.Errorf("Unexpected API key %q (%v)", apiKey, err)
+	}
+	if _, err := signer.Sign(SignAlgorithm(9), payload); !errors.Is(err, ErrUnsupportedAlgorithm) {
+		t.Errorf("Expected ErrUnsupportedAlgorithm, got %v", err)
+	}
+	if _, err := signer.Sign(SignEd25519, payload); !errors.Is(err, ErrUnsupportedAlgorithm) {
+		t.Errorf("Expected ErrUnsupportedAlgorithm for an HMAC secret, got %v", err)
+	}
+}
+
+func TestSigner_Asymmetric(t *testing.T) {
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	rsaPrivate, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	rsaPKCS1 := SecretString(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaPrivate)}))
+	s, _ := newTestManager(t,
+		Secret{AcctID: 1, Exchange: "binance", KeyType: KeyTypeEd25519, PrivateKey: testPEM(t, edPrivate), Active: true},
+		Secret{AcctID: 2, Exchange: "binance", KeyType: KeyTypeRSA, PrivateKey: testPEM(t, rsaPrivate), Active: true},
+		Secret{AcctID: 3, Exchange: "binance", KeyType: KeyTypeRSA, PrivateKey: rsaPKCS1, Active: true},
+	)
+	payload := []byte("timestamp=1499827319559")
+	digest := sha256.Sum256(payload)
+
+	signer, _ := s.Signer(1, PurposeTrading)
+	signature, err := signer.Sign(SignEd25519, payload)
+	if err != nil || !ed25519.Verify(edPublic, payload, signature) {
+		t.Errorf("Expected a valid Ed25519 signature (%v)", err)
+	}
+	if _, err := signer.Sign(SignRSASHA256, payload); !errors.Is(err, ErrUnsupportedAlgorithm) {
+		t.Errorf("Expected ErrUnsupportedAlgorithm for an Ed25519 key, got %v", err)
+	}
+	for _, acctID := range []int{2, 3} {
+		signer, _ := s.Signer(acctID, PurposeTrading)
+		signature, err := signer.Sign(SignRSASHA256, payload)
+		if err != nil {
+			t.Fatalf("Sign failed for acctID %d: %v", acctID, err)
+		}
+		if err := rsa.VerifyPKCS1v15(&rsaPrivate.PublicKey, crypto.SHA256, digest[:], signature); err != nil {
+			t.Errorf("Expected a valid RSA signature for acctID %d: %v", acctID, err)
+		}
+	}
+}
+
+func TestSigner_ResolvesCredentials(t *testing.T) {
+	s, _ := newTestManager(t,
+		Secret{AcctID: 1, Exchange: "okx", APIKey: "master", APISecret: "m", Passphrase: "mp", Active: true},
+		Secret{AcctID: 2, Exchange: "okx", APIKey: "sub", APISecret: "s", MasterAcctID: 1, Active: true},
+	)
+	if _, err := s.Signer(9, PurposeTrading); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("Expected ErrSecretNotFound, got %v", err)
+	}
+	transfer, err := s.Signer(2, PurposeTransfer)
+	if err != nil {
+		t.Fatalf("Signer failed: %v", err)
+	}
+	if passphrase, err := transfer.Passphrase(); err != nil || passphrase != "mp" {
+		t.Errorf("Expected the master passphrase for transfers, got %q (%v)", passphrase, err)
+	}
+	if transfer.AcctID() != 2 {
+		t.Errorf("Expected acctID 2, got %d", transfer.AcctID())
+	}
+
+	trading, _ := s.Signer(2, PurposeTrading)
+	s.store(Secret{AcctID: 2, Exchange: "okx", APIKey: "sub", APISecret: "s", MasterAcctID: 1})
+	if _, err := trading.Sign(SignHMACSHA256, []byte("payload")); !errors.Is(err, ErrSecretDisabled) {
+		t.Errorf("Expected a disabled account to stop signing, got %v", err)
+	}
+}
@@ -1,28 +1,57 @@
 package sms
 
 import (
+	"errors"
 	"fmt"
+	"sort"
+	"sync"
 
+	"github.com/BullionBear/seq/internal/config"
+	"github.com/BullionBear/seq/pkg/evbus"
 	"github.com/rs/zerolog/log"
 	"gorm.io/gorm"
 )
 
-const (
-	QueryAllSecrets = `
-		SELECT * FROM secrets
-	`
-	InsertSecret = `
-		INSERT INTO secrets (acct_id, user_id, acct_name, exchange, api_key, api_secret, passphrase, master_is) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
-	`
+var (
+	ErrSecretNotFound = errors.New("secret not found")
+	ErrSecretExists   = errors.New("secret already exists")
+	ErrSecretDisabled = errors.New("secret disabled")
 )
 
+// SecretManager keeps the exchange credentials of every account, loaded
+// from a Backend and cached in memory.
+//
+// Every write is committed to the backend before the cache is updated, so a
+// failed write leaves the cache unchanged. Each committed change is then
+// published as a SecretChange.
+//
+// Methods called on the manager itself act as SystemService. Other
+// components go through Access, which checks the Policy; both record every
+// call as an AccessEvent.
 type SecretManager struct {
-	db      *gorm.DB
-	secrets map[int]Secret
+	backend       Backend
+	writeMu       sync.Mutex   // serializes writers across the backend round trip
+	mu            sync.RWMutex // guards secrets, policy and keyCheckers
+	secrets       map[int]Secret
+	policy        Policy
+	keyCheckers   map[string]KeyChecker // By exchange
+	system        *SecretAccess         // Backs the methods called directly on the manager
+	changes       *evbus.Bus[SecretChange]
+	changeFactory *evbus.EventFactory[SecretChange]
+	accesses      *evbus.Bus[AccessEvent]
+	accessFactory *evbus.EventFactory[AccessEvent]
 }
 
-func NewSecretManager(db *gorm.DB) (*SecretManager, error) {
-	secretManager := &SecretManager{db: db, secrets: make(map[int]Secret, 1024)}
+// NewSecretManager returns a SecretManager storing secrets in PostgreSQL,
+// encrypted with keys.
+func NewSecretManager(db *gorm.DB, keys *KeyRing) (*SecretManager, error) {
+	return NewSecretManagerWithBackend(NewPostgresBackend(db, keys))
+}
+
+// NewSecretManagerWithBackend returns a SecretManager storing secrets in
+// backend.
+func NewSecretManagerWithBackend(backend Backend) (*SecretManager, error) {
+	secretManager := newSecretManager(backend)
 	if err := secretManager.loadSecrets(); err != nil {
 		log.Error().Err(err).Msg("Failed to load secrets")
 		return nil, err
@@ -30,39 +59,314 @@ func NewSecretManager(db *gorm.DB) (*SecretManager, error) {
 	return secretManager, nil
 }
 
+// NewSecretManagerFromConfig returns a SecretManager with the backend,
+// access policy and key checkers of cfg. db is only used by the postgres
+// backend.
+func NewSecretManagerFromConfig(cfg config.ConfigSMS, db *gorm.DB) (*SecretManager, error) {
+	backend, err := NewBackendFromConfig(cfg, db)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := NewPolicyFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	checkers, err := NewKeyCheckersFromConfig(cfg, nil)
+	if err != nil {
+		return nil, err
+	}
+	secretManager, err := NewSecretManagerWithBackend(backend)
+	if err != nil {
+		return nil, err
+	}
+	secretManager.SetPolicy(policy)
+	secretManager.SetKeyCheckers(checkers...)
+	return secretManager, nil
+}
+
+func newSecretManager(backend Backend) *SecretManager {
+	secretManager := &SecretManager{
+		backend: backend,
+		secrets: make(map[int]Secret, 1024),
+		changes: evbus.NewBus[SecretChange](),
+		changeFactory: evbus.NewEventFactory(func(change *SecretChange) {
+			*change = SecretChange{}
+		}),
+		accesses: evbus.NewBus[AccessEvent](),
+		accessFactory: evbus.NewEventFactory(func(access *AccessEvent) {
+			*access = AccessEvent{}
+		}),
+	}
+	secretManager.system = secretManager.Access(SystemService)
+	return secretManager
+}
+
 func (s *SecretManager) loadSecrets() error {
-	rows, err := s.db.Raw(QueryAllSecrets).Rows()
+	loaded, err := s.backend.Load()
 	if err != nil {
 		return err
 	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var secret Secret
-		err := rows.Scan(&secret.AcctID, &secret.AcctName, &secret.Exchange, &secret.APIKey, &secret.APISecret)
-		if err != nil {
-			return err
-		}
-		s.secrets[secret.AcctID] = secret
+	secrets := make(map[int]Secret, len(loaded))
+	for _, secret := range loaded {
+		secrets[secret.AcctID] = secret
 	}
+
+	s.mu.Lock()
+	s.secrets = secrets
+	s.mu.Unlock()
 	return nil
 }
 
+// AddSecret stores a new, active secret. The private key of an Ed25519 or
+// RSA secret must parse as its KeyType.
 func (s *SecretManager) AddSecret(secret Secret) error {
-	if _, ok := s.secrets[secret.AcctID]; ok {
-		return fmt.Errorf("secret already exists for acctID: %d", secret.AcctID)
+	return s.system.AddSecret(secret)
+}
+
+func (s *SecretManager) addSecret(secret Secret) error {
+	secret.Active = true
+	if err := validateKey(secret); err != nil {
+		return err
 	}
-	s.secrets[secret.AcctID] = secret
-	if err := s.db.Exec(InsertSecret, secret.AcctID, secret.UserID, secret.AcctName, secret.Exchange, secret.APIKey, secret.APISecret, secret.Passphrase, secret.MasterIs).Error; err != nil {
+	// The key is only sent to the venue for a secret that can be added.
+	if err := s.validateAdd(secret); err != nil {
 		return err
 	}
+	perms, err := s.checkNewKey(secret)
+	if err != nil {
+		return err
+	}
+	secret.Permissions = perms
+
+	s.writeMu.Lock()
+	// Secrets may have changed while the key was checked.
+	if err := s.validateAdd(secret); err != nil {
+		s.writeMu.Unlock()
+		return err
+	}
+	if err := s.backend.Insert(secret); err != nil {
+		s.writeMu.Unlock()
+		return fmt.Errorf("failed to add secret for acctID %d: %w", secret.AcctID, err)
+	}
+	s.store(secret)
+	s.writeMu.Unlock()
+
+	s.publishChange(SecretChange{Kind: SecretAdded, AcctID: secret.AcctID, Exchange: secret.Exchange})
+	return nil
+}
+
+// UpdateSecret replaces the credentials and account details of an existing
+// secret, re-encrypting them under a new data key. Whether the secret is
+// active is left unchanged; use DisableSecret and EnableSecret for that.
+func (s *SecretManager) UpdateSecret(secret Secret) error {
+	return s.system.UpdateSecret(secret)
+}
+
+func (s *SecretManager) updateSecret(secret Secret) error {
+	if err := validateKey(secret); err != nil {
+		return err
+	}
+	// The key is only sent to the venue for a secret that can be updated.
+	if err := s.validateUpdate(secret); err != nil {
+		return err
+	}
+	perms, err := s.checkNewKey(secret)
+	if err != nil {
+		return err
+	}
+	secret.Permissions = perms
+
+	s.writeMu.Lock()
+	// Secrets may have changed while the key was checked.
+	if err := s.validateUpdate(secret); err != nil {
+		s.writeMu.Unlock()
+		return err
+	}
+	current, _ := s.lookup(secret.AcctID)
+	secret.Active = current.Active
+	if err := s.backend.Update(secret); err != nil {
+		s.writeMu.Unlock()
+		return fmt.Errorf("failed to update secret for acctID %d: %w", secret.AcctID, err)
+	}
+	s.store(secret)
+	s.writeMu.Unlock()
+
+	s.publishChange(SecretChange{Kind: SecretUpdated, AcctID: secret.AcctID, Exchange: secret.Exchange})
 	return nil
 }
 
+// validateAdd checks that secret is new and may be written with its
+// MasterAcctID.
+func (s *SecretManager) validateAdd(secret Secret) error {
+	if _, err := s.lookup(secret.AcctID); err == nil {
+		return fmt.Errorf("%w for acctID: %d", ErrSecretExists, secret.AcctID)
+	}
+	return s.validateMaster(secret)
+}
+
+// validateUpdate checks that secret exists and may be written with its
+// MasterAcctID.
+func (s *SecretManager) validateUpdate(secret Secret) error {
+	if _, err := s.lookup(secret.AcctID); err != nil {
+		return err
+	}
+	return s.validateMaster(secret)
+}
+
+// DisableSecret keeps the secret of acctID but stops GetSecret from
+// returning it, e.g. while a leaked key is being replaced.
+func (s *SecretManager) DisableSecret(acctID int) error {
+	return s.system.DisableSecret(acctID)
+}
+
+// EnableSecret reverses DisableSecret.
+func (s *SecretManager) EnableSecret(acctID int) error {
+	return s.system.EnableSecret(acctID)
+}
+
+func (s *SecretManager) setActive(acctID int, active bool) error {
+	s.writeMu.Lock()
+	secret, err := s.lookup(acctID)
+	if err != nil {
+		s.writeMu.Unlock()
+		return err
+	}
+	if secret.Active == active {
+		s.writeMu.Unlock()
+		return nil
+	}
+	if err := s.backend.SetActive(acctID, active); err != nil {
+		s.writeMu.Unlock()
+		return fmt.Errorf("failed to update secret for acctID %d: %w", acctID, err)
+	}
+	secret.Active = active
+	s.store(secret)
+	s.writeMu.Unlock()
+
+	kind := SecretDisabled
+	if active {
+		kind = SecretEnabled
+	}
+	s.publishChange(SecretChange{Kind: kind, AcctID: acctID, Exchange: secret.Exchange})
+	return nil
+}
+
+// DeleteSecret removes the secret of acctID.
+func (s *SecretManager) DeleteSecret(acctID int) error {
+	return s.system.DeleteSecret(acctID)
+}
+
+func (s *SecretManager) deleteSecret(acctID int) error {
+	s.writeMu.Lock()
+	secret, err := s.lookup(acctID)
+	if err != nil {
+		s.writeMu.Unlock()
+		return err
+	}
+	if subAccounts := s.ListSubAccounts(acctID); len(subAccounts) > 0 {
+		s.writeMu.Unlock()
+		return fmt.Errorf("%w: acctID %d has %d sub-accounts", ErrHasSubAccounts, acctID, len(subAccounts))
+	}
+	if err := s.backend.Delete(acctID); err != nil {
+		s.writeMu.Unlock()
+		return fmt.Errorf("failed to delete secret for acctID %d: %w", acctID, err)
+	}
+	s.mu.Lock()
+	delete(s.secrets, acctID)
+	s.mu.Unlock()
+	s.writeMu.Unlock()
+
+	s.publishChange(SecretChange{Kind: SecretDeleted, AcctID: acctID, Exchange: secret.Exchange})
+	return nil
+}
+
+// GetSecret returns the secret of acctID, or ErrSecretDisabled if it is
+// disabled. Venue clients sign with a Signer instead, so they never hold
+// the raw key material.
 func (s *SecretManager) GetSecret(acctID int) (Secret, error) {
+	return s.system.GetSecret(acctID)
+}
+
+func (s *SecretManager) getSecret(acctID int) (Secret, error) {
+	secret, err := s.lookup(acctID)
+	if err != nil {
+		return Secret{}, err
+	}
+	if !secret.Active {
+		return Secret{}, fmt.Errorf("%w for acctID: %d", ErrSecretDisabled, acctID)
+	}
+	// A key found able to withdraw is never used, even if it could not be
+	// disabled.
+	if secret.Permissions.Withdraw {
+		return Secret{}, fmt.Errorf("%w for acctID: %d", ErrWithdrawalEnabled, acctID)
+	}
+	return secret, nil
+}
+
+// ListByUser returns the accounts of userID, including disabled ones,
+// ordered by AcctID, without key material as by SecretAccess.Account.
+func (s *SecretManager) ListByUser(userID int) []Secret {
+	return s.listAccounts(func(secret Secret) bool { return secret.UserID == userID })
+}
+
+// ListByExchange returns the accounts for exchange, including disabled
+// ones, ordered by AcctID, without key material as by SecretAccess.Account.
+func (s *SecretManager) ListByExchange(exchange string) []Secret {
+	return s.listAccounts(func(secret Secret) bool { return secret.Exchange == exchange })
+}
+
+// listAccounts is list with the key material of every secret cleared.
+func (s *SecretManager) listAccounts(match func(Secret) bool) []Secret {
+	secrets := s.list(match)
+	for i := range secrets {
+		secrets[i] = withoutKeyMaterial(secrets[i])
+	}
+	return secrets
+}
+
+// withoutKeyMaterial returns secret with APISecret, Passphrase and
+// PrivateKey cleared. The API key identifies the account and is kept.
+func withoutKeyMaterial(secret Secret) Secret {
+	secret.APISecret, secret.Passphrase, secret.PrivateKey = "", "", ""
+	return secret
+}
+
+func (s *SecretManager) list(match func(Secret) bool) []Secret {
+	s.mu.RLock()
+	var secrets []Secret
+	for _, secret := range s.secrets {
+		if match(secret) {
+			secrets = append(secrets, secret)
+		}
+	}
+	s.mu.RUnlock()
+	sort.Slice(secrets, func(i, j int) bool { return secrets[i].AcctID < secrets[j].AcctID })
+	return secrets
+}
+
+func (s *SecretManager) lookup(acctID int) (Secret, error) {
+	s.mu.RLock()
 	secret, ok := s.secrets[acctID]
+	s.mu.RUnlock()
 	if !ok {
-		return Secret{}, fmt.Errorf("secret not found for acctID: %d", acctID)
+		return Secret{}, fmt.Errorf("%w for acctID: %d", ErrSecretNotFound, acctID)
 	}
 	return secret, nil
 }
+
+func (s *SecretManager) store(secret Secret) {
+	s.mu.Lock()
+	s.secrets[secret.AcctID] = secret
+	s.mu.Unlock()
+}
+
+// EncryptPlaintextSecrets encrypts every secret its PostgresBackend still
+// stores in plaintext, and returns the number of secrets encrypted. Other
+// backends never store plaintext.
+func (s *SecretManager) EncryptPlaintextSecrets() (int, error) {
+	backend, ok := s.backend.(*PostgresBackend)
+	if !ok {
+		return 0, fmt.Errorf("%T does not store plaintext secrets", s.backend)
+	}
+	return backend.EncryptPlaintextSecrets()
+}
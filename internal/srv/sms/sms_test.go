@@ -0,0 +1,211 @@
+package sms
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/BullionBear/seq/internal/db/dbtest"
+	"github.com/BullionBear/seq/pkg/evbus"
+	gormpostgres "gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestManager returns a SecretManager holding secrets whose database is
+// unreachable, so every write fails.
+func newTestManager(t *testing.T, secrets ...Secret) (*SecretManager, *[]SecretChange) {
+	t.Helper()
+	db, err := gorm.Open(gormpostgres.Open("postgres://seq@127.0.0.1:1/seq?connect_timeout=1"), &gorm.Config{
+		DisableAutomaticPing: true,
+		Logger:               logger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	ring, _ := NewKeyRing(testMasterKey(t, 1))
+	s := newSecretManager(NewPostgresBackend(db, ring))
+	for _, secret := range secrets {
+		s.store(secret)
+	}
+
+	var changes []SecretChange
+	_, err = s.SubscribeSecretChanges(func(event *evbus.Event[SecretChange]) error {
+		changes = append(changes, event.Data)
+		return nil
+	}, nil)
+	if err != nil {
+		t.Fatalf("SubscribeSecretChanges failed: %v", err)
+	}
+	return s, &changes
+}
+
+func TestSecretManager_FailedWritesLeaveCache(t *testing.T) {
+	existing := testSecret()
+	existing.Active = true
+	s, changes := newTestManager(t, existing)
+
+	added := testSecret()
+	added.AcctID = 8
+	if err := s.AddSecret(added); err == nil {
+		t.Fatal("Expected AddSecret to fail without a database")
+	}
+	if _, err := s.GetSecret(8); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("Expected failed add to leave no secret, got %v", err)
+	}
+
+	updated := existing
+	updated.APISecret = "rotated"
+	if err := s.UpdateSecret(updated); err == nil {
+		t.Fatal("Expected UpdateSecret to fail without a database")
+	}
+	if err := s.DisableSecret(existing.AcctID); err == nil {
+		t.Fatal("Expected DisableSecret to fail without a database")
+	}
+	if err := s.DeleteSecret(existing.AcctID); err == nil {
+		t.Fatal("Expected DeleteSecret to fail without a database")
+	}
+	if secret, err := s.GetSecret(existing.AcctID); err != nil || secret.APISecret != "secret" {
+		t.Errorf("Expected failed writes to leave the secret unchanged, got %+v (%v)", secret, err)
+	}
+	if len(*changes) != 0 {
+		t.Errorf("Expected no changes published, got %+v", *changes)
+	}
+
+	if err := s.AddSecret(existing); !errors.Is(err, ErrSecretExists) {
+		t.Errorf("Expected ErrSecretExists, got %v", err)
+	}
+	if err := s.UpdateSecret(added); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("Expected ErrSecretNotFound, got %v", err)
+	}
+	if err := s.EnableSecret(existing.AcctID); err != nil {
+		t.Errorf("Expected enabling an active secret to be a no-op, got %v", err)
+	}
+}
+
+func TestSecretManager_Lookups(t *testing.T) {
+	s, _ := newTestManager(t,
+		Secret{AcctID: 3, UserID: 1, Exchange: "okx", Active: true},
+		Secret{AcctID: 1, UserID: 1, Exchange: "binance", APIKey: "key", APISecret: "secret", Active: true},
+		Secret{AcctID: 2, UserID: 2, Exchange: "binance"},
+	)
+	if _, err := s.GetSecret(2); !errors.Is(err, ErrSecretDisabled) {
+		t.Errorf("Expected ErrSecretDisabled, got %v", err)
+	}
+	if _, err := s.GetSecret(4); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("Expected ErrSecretNotFound, got %v", err)
+	}
+	if got := s.ListByUser(1); len(got) != 2 || got[0].AcctID != 1 || got[1].AcctID != 3 {
+		t.Errorf("Expected accounts 1 and 3 of user 1, got %+v", got)
+	}
+	if got := s.ListByExchange("binance"); len(got) != 2 || got[0].AcctID != 1 || got[1].AcctID != 2 {
+		t.Errorf("Expected binance accounts 1 and 2 including disabled, got %+v", got)
+	}
+	if got := s.ListByExchange("binance"); got[0].APIKey != "key" || got[0].APISecret != "" {
+		t.Errorf("Expected listed accounts without key material, got %+v", got[0])
+	}
+}
+
+func TestSecretManager_ConcurrentAccess(t *testing.T) {
+	s, _ := newTestManager(t)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for acctID := 0; acctID < 200; acctID++ {
+				s.store(Secret{AcctID: acctID, Exchange: "okx", Active: true})
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for acctID := 0; acctID < 200; acctID++ {
+				_, _ = s.GetSecret(acctID)
+				_ = s.ListByExchange("okx")
+			}
+		}()
+	}
+	wg.Wait()
+	if got := len(s.ListByExchange("okx")); got != 200 {
+		t.Errorf("Expected 200 secrets, got %d", got)
+	}
+}
+
+func insertTestUser(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	if err := db.Exec(`INSERT INTO users (id, username, email, password) VALUES (1, 'trader', 'trader@example.com', 'x')`).Error; err != nil {
+		t.Fatalf("Failed to insert user: %v", err)
+	}
+}
+
+func TestSecretManager_Postgres(t *testing.T) {
+	pg := dbtest.Postgres(t)
+	insertTestUser(t, pg.DB)
+	ring, _ := NewKeyRing(testMasterKey(t, 1))
+	s, err := NewSecretManager(pg.DB, ring)
+	if err != nil {
+		t.Fatalf("NewSecretManager failed: %v", err)
+	}
+	var changes []SecretChange
+	if _, err := s.SubscribeSecretChanges(func(event *evbus.Event[SecretChange]) error {
+		changes = append(changes, event.Data)
+		return nil
+	}, nil); err != nil {
+		t.Fatalf("SubscribeSecretChanges failed: %v", err)
+	}
+
+	master := Secret{AcctID: 1, UserID: 1, AcctName: "main", Exchange: "okx", APIKey: "k1", APISecret: "s1", Passphrase: "p1"}
+	sub := Secret{AcctID: 2, UserID: 1, AcctName: "sub", Exchange: "okx", APIKey: "k2", APISecret: "s2", MasterAcctID: 1}
+	for _, secret := range []Secret{master, sub} {
+		if err := s.AddSecret(secret); err != nil {
+			t.Fatalf("AddSecret failed: %v", err)
+		}
+	}
+	if err := s.AddSecret(Secret{AcctID: 3, UserID: 99, AcctName: "ghost", Exchange: "okx"}); err == nil {
+		t.Error("Expected AddSecret to fail for an unknown user")
+	}
+	if _, err := s.GetSecret(3); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("Expected failed insert to leave the cache unchanged, got %v", err)
+	}
+	sub.APISecret = "s2-rotated"
+	if err := s.UpdateSecret(sub); err != nil {
+		t.Fatalf("UpdateSecret failed: %v", err)
+	}
+	if err := s.DisableSecret(2); err != nil {
+		t.Fatalf("DisableSecret failed: %v", err)
+	}
+
+	// A fresh manager decrypts what was written.
+	reloaded, err := NewSecretManager(pg.DB, ring)
+	if err != nil {
+		t.Fatalf("NewSecretManager failed: %v", err)
+	}
+	if secret, err := reloaded.ResolveCredentials(2, PurposeTransfer); err != nil || secret.APISecret != "s1" {
+		t.Errorf("Expected master credentials for transfers, got %+v (%v)", secret, err)
+	}
+	if _, err := reloaded.ResolveCredentials(2, PurposeTrading); !errors.Is(err, ErrSecretDisabled) {
+		t.Errorf("Expected disabled sub-account, got %v", err)
+	}
+	if subs := reloaded.ListSubAccounts(1); len(subs) != 1 || subs[0].AcctID != 2 || subs[0].UserID != 1 || subs[0].Active {
+		t.Errorf("Unexpected sub-accounts: %+v", subs)
+	}
+	if sub, err := reloaded.lookup(2); err != nil || sub.APISecret != "s2-rotated" {
+		t.Errorf("Expected the rotated secret of the sub-account, got %+v (%v)", sub, err)
+	}
+
+	if err := s.DeleteSecret(1); !errors.Is(err, ErrHasSubAccounts) {
+		t.Errorf("Expected ErrHasSubAccounts, got %v", err)
+	}
+	if err := s.DeleteSecret(2); err != nil {
+		t.Fatalf("DeleteSecret failed: %v", err)
+	}
+	want := []ChangeKind{SecretAdded, SecretAdded, SecretUpdated, SecretDisabled, SecretDeleted}
+	if len(changes) != len(want) {
+		t.Fatalf("Expected %d changes, got %+v", len(want), changes)
+	}
+	for n, kind := range want {
+		if changes[n].Kind != kind {
+			t.Errorf("Expected change %d to be %s, got %s", n, kind, changes[n].Kind)
+		}
+	}
+}
@@ -1,12 +1,22 @@
 package sms
 
 type Secret struct {
-	AcctID     int
-	UserID     int
-	AcctName   string
-	Exchange   string
-	APIKey     string
-	APISecret  string
-	Passphrase string
-	MasterIs   int
+	AcctID       int
+	UserID       int // users.id of the owner
+	AcctName     string
+	Exchange     string
+	APIKey       SecretString
+	APISecret    SecretString
+	Passphrase   SecretString
+	KeyType      KeyType        // What the account signs with: APISecret or PrivateKey
+	PrivateKey   SecretString   // PEM-encoded private key of Ed25519 and RSA key pairs
+	MasterAcctID int            // Master account of a sub-account, 0 for master accounts
+	Active       bool           // Disabled secrets are kept but not handed out
+	Permissions  KeyPermissions // What the venue reported the API key may do
+}
+
+// IsMaster reports whether secret is a master account rather than a
+// sub-account.
+func (s Secret) IsMaster() bool {
+	return s.MasterAcctID == 0
 }
@@ -0,0 +1 @@
+ALTER TABLE instruments DROP COLUMN venue;
\ No newline at end of file
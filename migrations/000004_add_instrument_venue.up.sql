@@ -0,0 +1 @@
+ALTER TABLE instruments ADD COLUMN venue VARCHAR(255) NOT NULL DEFAULT '';
\ No newline at end of file
@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS instruments_notify_change ON instruments;
+DROP FUNCTION IF EXISTS notify_instrument_change();
\ No newline at end of file
@@ -0,0 +1,14 @@
+CREATE OR REPLACE FUNCTION notify_instrument_change() RETURNS trigger AS $$
+BEGIN
+	IF TG_OP = 'DELETE' THEN
+		PERFORM pg_notify('instrument_changes', json_build_object('op', TG_OP, 'row', row_to_json(OLD))::text);
+		RETURN OLD;
+	END IF;
+	PERFORM pg_notify('instrument_changes', json_build_object('op', TG_OP, 'row', row_to_json(NEW))::text);
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER instruments_notify_change
+	AFTER INSERT OR UPDATE OR DELETE ON instruments
+	FOR EACH ROW EXECUTE FUNCTION notify_instrument_change();
\ No newline at end of file
@@ -0,0 +1,5 @@
+ALTER TABLE instruments
+	DROP COLUMN min_notional,
+	DROP COLUMN min_qty,
+	ALTER COLUMN qty_tick_size TYPE DECIMAL(10, 4),
+	ALTER COLUMN price_tick_size TYPE DECIMAL(10, 4);
\ No newline at end of file
@@ -0,0 +1,5 @@
+ALTER TABLE instruments
+	ALTER COLUMN price_tick_size TYPE DECIMAL(38, 18),
+	ALTER COLUMN qty_tick_size TYPE DECIMAL(38, 18),
+	ADD COLUMN min_qty DECIMAL(38, 18) NOT NULL DEFAULT 0,
+	ADD COLUMN min_notional DECIMAL(38, 18) NOT NULL DEFAULT 0;
\ No newline at end of file
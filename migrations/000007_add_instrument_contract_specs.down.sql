@@ -0,0 +1,10 @@
+ALTER TABLE instruments
+	DROP COLUMN funding_interval_sec,
+	DROP COLUMN underlying_id,
+	DROP COLUMN option_type,
+	DROP COLUMN strike,
+	DROP COLUMN expiry,
+	DROP COLUMN inverse,
+	DROP COLUMN settle_ccy_id,
+	DROP COLUMN settle_ccy,
+	DROP COLUMN contract_size;
\ No newline at end of file
@@ -0,0 +1,10 @@
+ALTER TABLE instruments
+	ADD COLUMN contract_size DECIMAL(38, 18) NOT NULL DEFAULT 1,
+	ADD COLUMN settle_ccy VARCHAR(255) NOT NULL DEFAULT '',
+	ADD COLUMN settle_ccy_id INT NOT NULL DEFAULT 0,
+	ADD COLUMN inverse BOOLEAN NOT NULL DEFAULT FALSE,
+	ADD COLUMN expiry TIMESTAMPTZ,
+	ADD COLUMN strike DECIMAL(38, 18) NOT NULL DEFAULT 0,
+	ADD COLUMN option_type VARCHAR(4) NOT NULL DEFAULT '',
+	ADD COLUMN underlying_id INT NOT NULL DEFAULT 0,
+	ADD COLUMN funding_interval_sec INT NOT NULL DEFAULT 0;
\ No newline at end of file
@@ -0,0 +1,2 @@
+DROP TABLE currency_aliases;
+DROP TABLE currencies;
\ No newline at end of file
@@ -0,0 +1,14 @@
+CREATE TABLE currencies (
+	ccy_id INT PRIMARY KEY,
+	code VARCHAR(32) NOT NULL UNIQUE,
+	name VARCHAR(255) NOT NULL DEFAULT '',
+	precision INT NOT NULL DEFAULT 8,
+	asset_class VARCHAR(32) NOT NULL DEFAULT 'crypto'
+);
+
+CREATE TABLE currency_aliases (
+	exchange VARCHAR(255) NOT NULL,
+	alias VARCHAR(32) NOT NULL,
+	ccy_id INT NOT NULL REFERENCES currencies (ccy_id) ON DELETE CASCADE,
+	PRIMARY KEY (exchange, alias)
+);
\ No newline at end of file
@@ -0,0 +1,6 @@
+ALTER TABLE instruments DROP COLUMN calendar_id;
+
+DROP TABLE maintenance_windows;
+DROP TABLE trading_calendar_exceptions;
+DROP TABLE trading_sessions;
+DROP TABLE trading_calendars;
\ No newline at end of file
@@ -0,0 +1,33 @@
+CREATE TABLE trading_calendars (
+	calendar_id INT PRIMARY KEY,
+	name VARCHAR(255) NOT NULL UNIQUE,
+	timezone VARCHAR(64) NOT NULL DEFAULT 'UTC'
+);
+
+-- Weekly sessions in the calendar's time zone. A close_time at or before
+-- open_time closes on the following day.
+CREATE TABLE trading_sessions (
+	calendar_id INT NOT NULL REFERENCES trading_calendars (calendar_id) ON DELETE CASCADE,
+	weekday SMALLINT NOT NULL CHECK (weekday BETWEEN 0 AND 6),
+	open_time TIME NOT NULL,
+	close_time TIME NOT NULL,
+	PRIMARY KEY (calendar_id, weekday, open_time)
+);
+
+-- Holidays (close_time NULL) and early closes of the session closing on
+-- trade_date.
+CREATE TABLE trading_calendar_exceptions (
+	calendar_id INT NOT NULL REFERENCES trading_calendars (calendar_id) ON DELETE CASCADE,
+	trade_date DATE NOT NULL,
+	close_time TIME,
+	PRIMARY KEY (calendar_id, trade_date)
+);
+
+CREATE TABLE maintenance_windows (
+	calendar_id INT NOT NULL REFERENCES trading_calendars (calendar_id) ON DELETE CASCADE,
+	start_at TIMESTAMPTZ NOT NULL,
+	end_at TIMESTAMPTZ NOT NULL CHECK (end_at > start_at),
+	PRIMARY KEY (calendar_id, start_at)
+);
+
+ALTER TABLE instruments ADD COLUMN calendar_id INT NOT NULL DEFAULT 0;
\ No newline at end of file
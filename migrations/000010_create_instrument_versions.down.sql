@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS instruments_record_version ON instruments;
+DROP FUNCTION IF EXISTS record_instrument_version();
+
+DROP TABLE instrument_versions;
\ No newline at end of file
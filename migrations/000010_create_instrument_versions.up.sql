@@ -0,0 +1,44 @@
+-- Every version of every instrument row, maintained by a trigger. A version
+-- is valid from valid_from until valid_to, or is current while valid_to is
+-- NULL. The row is stored as JSON so that columns added to instruments later
+-- are versioned without changing this table.
+CREATE TABLE instrument_versions (
+	symbol_id INT NOT NULL,
+	valid_from TIMESTAMPTZ NOT NULL,
+	valid_to TIMESTAMPTZ,
+	definition JSONB NOT NULL,
+	PRIMARY KEY (symbol_id, valid_from),
+	CHECK (valid_to IS NULL OR valid_to >= valid_from)
+);
+
+CREATE UNIQUE INDEX instrument_versions_current ON instrument_versions (symbol_id) WHERE valid_to IS NULL;
+CREATE INDEX instrument_versions_valid ON instrument_versions (valid_from, valid_to);
+
+-- Versions change at transaction start time, so every change committed
+-- together takes effect at the same instant. A version replaced within the
+-- transaction that created it never took effect and is dropped.
+CREATE OR REPLACE FUNCTION record_instrument_version() RETURNS trigger AS $$
+BEGIN
+	IF TG_OP = 'UPDATE' AND to_jsonb(OLD) = to_jsonb(NEW) THEN
+		RETURN NEW;
+	END IF;
+	IF TG_OP IN ('UPDATE', 'DELETE') THEN
+		DELETE FROM instrument_versions WHERE symbol_id = OLD.symbol_id AND valid_to IS NULL AND valid_from = now();
+		UPDATE instrument_versions SET valid_to = GREATEST(now(), valid_from)
+			WHERE symbol_id = OLD.symbol_id AND valid_to IS NULL;
+	END IF;
+	IF TG_OP = 'DELETE' THEN
+		RETURN OLD;
+	END IF;
+	INSERT INTO instrument_versions (symbol_id, valid_from, definition) VALUES (NEW.symbol_id, now(), to_jsonb(NEW));
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER instruments_record_version
+	AFTER INSERT OR UPDATE OR DELETE ON instruments
+	FOR EACH ROW EXECUTE FUNCTION record_instrument_version();
+
+-- Rows existing before versioning are taken to be valid since the epoch.
+INSERT INTO instrument_versions (symbol_id, valid_from, definition)
+SELECT symbol_id, '1970-01-01 00:00:00+00', to_jsonb(instruments) FROM instruments;
\ No newline at end of file
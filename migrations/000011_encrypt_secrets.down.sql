@@ -0,0 +1,10 @@
+-- Fails while encrypted secrets exist: they cannot be decrypted here.
+ALTER TABLE secrets
+	DROP CONSTRAINT secrets_encrypted,
+	ALTER COLUMN api_key SET NOT NULL,
+	ALTER COLUMN api_secret SET NOT NULL,
+	ALTER COLUMN passphrase SET NOT NULL,
+	DROP COLUMN ciphertext,
+	DROP COLUMN nonce,
+	DROP COLUMN wrapped_key,
+	DROP COLUMN key_version;
\ No newline at end of file
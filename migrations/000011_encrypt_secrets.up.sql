@@ -0,0 +1,16 @@
+-- Secrets are stored encrypted: ciphertext holds the credentials sealed
+-- with AES-256-GCM under a per-secret data key, which is stored wrapped by
+-- master key version key_version. The plaintext columns are only kept for
+-- rows written before encryption.
+ALTER TABLE secrets
+	ALTER COLUMN api_key DROP NOT NULL,
+	ALTER COLUMN api_secret DROP NOT NULL,
+	ALTER COLUMN passphrase DROP NOT NULL,
+	ADD COLUMN ciphertext BYTEA,
+	ADD COLUMN nonce BYTEA,
+	ADD COLUMN wrapped_key BYTEA,
+	ADD COLUMN key_version INT,
+	ADD CONSTRAINT secrets_encrypted CHECK (
+		(ciphertext IS NULL AND api_key IS NOT NULL AND api_secret IS NOT NULL AND passphrase IS NOT NULL)
+		OR (ciphertext IS NOT NULL AND nonce IS NOT NULL AND wrapped_key IS NOT NULL AND key_version IS NOT NULL)
+	);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE secret_key_rotations;
\ No newline at end of file
@@ -0,0 +1,12 @@
+-- One row per master key rotation: the key version data keys were
+-- re-wrapped with, and how many were re-wrapped from each previous version
+-- as "version:count" pairs, e.g. "1:40,2:3".
+CREATE TABLE secret_key_rotations (
+	rotation_id BIGSERIAL PRIMARY KEY,
+	key_version INT NOT NULL,
+	previous_versions VARCHAR(255) NOT NULL,
+	rewrapped INT NOT NULL,
+	rotated_by VARCHAR(255) NOT NULL,
+	started_at TIMESTAMPTZ NOT NULL,
+	completed_at TIMESTAMPTZ NOT NULL
+);
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE secrets DROP COLUMN active;
\ No newline at end of file
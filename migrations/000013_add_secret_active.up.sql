@@ -0,0 +1 @@
+ALTER TABLE secrets ADD COLUMN active BOOLEAN NOT NULL DEFAULT true;
\ No newline at end of file
@@ -0,0 +1,13 @@
+DROP INDEX secrets_master_acct_id;
+ALTER TABLE secrets
+	DROP CONSTRAINT secrets_not_own_master,
+	DROP CONSTRAINT secrets_master_acct_id_fkey;
+UPDATE secrets SET master_acct_id = 0 WHERE master_acct_id IS NULL;
+ALTER TABLE secrets ALTER COLUMN master_acct_id SET NOT NULL;
+ALTER TABLE secrets RENAME COLUMN master_acct_id TO master_is;
+
+ALTER TABLE secrets ADD COLUMN username VARCHAR(255);
+UPDATE secrets SET username = users.username FROM users WHERE users.id = secrets.user_id;
+ALTER TABLE secrets ALTER COLUMN username SET NOT NULL;
+DROP INDEX secrets_user_id;
+ALTER TABLE secrets DROP COLUMN user_id;
\ No newline at end of file
@@ -0,0 +1,17 @@
+-- secrets.username never matched the code, which stores the users.id of
+-- the owner. Fails if a secret names a user that does not exist.
+ALTER TABLE secrets ADD COLUMN user_id INT REFERENCES users (id);
+UPDATE secrets SET user_id = users.id FROM users WHERE users.username = secrets.username;
+ALTER TABLE secrets ALTER COLUMN user_id SET NOT NULL;
+ALTER TABLE secrets DROP COLUMN username;
+CREATE INDEX secrets_user_id ON secrets (user_id);
+
+-- master_is becomes the acct_id of the master account a sub-account
+-- belongs to, NULL for master accounts.
+ALTER TABLE secrets RENAME COLUMN master_is TO master_acct_id;
+ALTER TABLE secrets ALTER COLUMN master_acct_id DROP NOT NULL;
+UPDATE secrets SET master_acct_id = NULL WHERE master_acct_id = 0 OR master_acct_id = acct_id;
+ALTER TABLE secrets
+	ADD CONSTRAINT secrets_master_acct_id_fkey FOREIGN KEY (master_acct_id) REFERENCES secrets (acct_id),
+	ADD CONSTRAINT secrets_not_own_master CHECK (master_acct_id <> acct_id);
+CREATE INDEX secrets_master_acct_id ON secrets (master_acct_id);
\ No newline at end of file
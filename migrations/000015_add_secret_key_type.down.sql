@@ -0,0 +1 @@
+ALTER TABLE secrets DROP COLUMN key_type;
\ No newline at end of file
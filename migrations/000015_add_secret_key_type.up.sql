@@ -0,0 +1,4 @@
+-- How a secret signs requests: an HMAC api_secret, or an Ed25519 or RSA
+-- private key stored PEM-encoded inside the encrypted credentials.
+ALTER TABLE secrets ADD COLUMN key_type VARCHAR(16) NOT NULL DEFAULT 'hmac'
+	CONSTRAINT secrets_key_type CHECK (key_type IN ('hmac', 'ed25519', 'rsa'));
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE secret_access_log;
\ No newline at end of file
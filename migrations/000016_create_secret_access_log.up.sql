@@ -0,0 +1,14 @@
+-- One row per call on the credentials of an account, written by
+-- sms.AuditLog: the calling service, the action ("reveal", "sign", "add",
+-- ...), whether the policy denied it and the error it ended with, if any.
+-- acct_id is not a foreign key so the history outlives deleted secrets.
+CREATE TABLE secret_access_log (
+	access_id BIGSERIAL PRIMARY KEY,
+	acct_id INT NOT NULL,
+	service VARCHAR(255) NOT NULL,
+	action VARCHAR(32) NOT NULL,
+	denied BOOLEAN NOT NULL,
+	error TEXT NOT NULL,
+	accessed_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX secret_access_log_acct_id ON secret_access_log (acct_id, accessed_at);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE secret_key_permissions;
\ No newline at end of file
@@ -0,0 +1,15 @@
+-- What the venue reported the API key of a secret may do, as last checked
+-- by sms.KeyChecker. ip_whitelist is comma-separated, empty when the venue
+-- does not report it.
+CREATE TABLE secret_key_permissions (
+	acct_id INT PRIMARY KEY REFERENCES secrets (acct_id) ON DELETE CASCADE,
+	can_read BOOLEAN NOT NULL,
+	can_trade BOOLEAN NOT NULL,
+	can_margin BOOLEAN NOT NULL,
+	can_futures BOOLEAN NOT NULL,
+	can_withdraw BOOLEAN NOT NULL,
+	can_transfer BOOLEAN NOT NULL,
+	ip_restricted BOOLEAN NOT NULL,
+	ip_whitelist TEXT NOT NULL,
+	checked_at TIMESTAMPTZ NOT NULL
+);
\ No newline at end of file
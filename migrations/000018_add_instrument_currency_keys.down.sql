@@ -0,0 +1,11 @@
+ALTER TABLE instruments
+	DROP CONSTRAINT instruments_settle_ccy_id_fkey,
+	DROP CONSTRAINT instruments_quote_ccy_id_fkey,
+	DROP CONSTRAINT instruments_base_ccy_id_fkey;
+
+ALTER TABLE instruments DISABLE TRIGGER instruments_record_version;
+UPDATE instruments SET settle_ccy_id = 0 WHERE settle_ccy_id IS NULL;
+ALTER TABLE instruments ALTER COLUMN settle_ccy_id SET DEFAULT 0, ALTER COLUMN settle_ccy_id SET NOT NULL;
+ALTER TABLE instruments ENABLE TRIGGER instruments_record_version;
+
+ALTER TABLE currencies DROP CONSTRAINT currencies_code_upper;
\ No newline at end of file
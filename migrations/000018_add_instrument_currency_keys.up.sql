@@ -0,0 +1,26 @@
+-- Instruments reference currencies by ID. Currencies instruments already
+-- use but that are missing from currencies are added under the code the
+-- instruments give them, and a settle_ccy_id of 0 (no settlement currency)
+-- becomes NULL. Codes are canonical upper case.
+UPDATE currencies SET code = upper(code) WHERE code <> upper(code);
+ALTER TABLE currencies ADD CONSTRAINT currencies_code_upper CHECK (code = upper(code));
+
+INSERT INTO currencies (ccy_id, code)
+SELECT DISTINCT ON (ccy_id) ccy_id, upper(code) FROM (
+	SELECT base_ccy_id, base_ccy FROM instruments
+	UNION ALL SELECT quote_ccy_id, quote_ccy FROM instruments
+	UNION ALL SELECT settle_ccy_id, settle_ccy FROM instruments WHERE settle_ccy_id <> 0
+) AS used (ccy_id, code)
+ORDER BY ccy_id, code
+ON CONFLICT DO NOTHING;
+
+-- The column change does not create new instrument versions.
+ALTER TABLE instruments DISABLE TRIGGER instruments_record_version;
+ALTER TABLE instruments ALTER COLUMN settle_ccy_id DROP NOT NULL, ALTER COLUMN settle_ccy_id DROP DEFAULT;
+UPDATE instruments SET settle_ccy_id = NULL WHERE settle_ccy_id = 0;
+ALTER TABLE instruments ENABLE TRIGGER instruments_record_version;
+
+ALTER TABLE instruments
+	ADD CONSTRAINT instruments_base_ccy_id_fkey FOREIGN KEY (base_ccy_id) REFERENCES currencies (ccy_id),
+	ADD CONSTRAINT instruments_quote_ccy_id_fkey FOREIGN KEY (quote_ccy_id) REFERENCES currencies (ccy_id),
+	ADD CONSTRAINT instruments_settle_ccy_id_fkey FOREIGN KEY (settle_ccy_id) REFERENCES currencies (ccy_id);
\ No newline at end of file
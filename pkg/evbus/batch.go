@@ -0,0 +1,82 @@
+package evbus
+
+// BatchHandler processes events delivered together by PublishBatch. events
+// is a run of consecutive events on one topic and, like the events
+// themselves, is only valid for the duration of the call. endOfBatch is true
+// on the last run this subscription receives from a batch, so consumers
+// such as book builders can defer expensive recomputation until then.
+type BatchHandler[T any] func(events []*Event[T], endOfBatch bool) error
+
+// SubscribeBatch registers a batch-aware handler for every topic matching
+// pattern. Events sent with Publish arrive as a batch of one.
+func (b *Bus[T]) SubscribeBatch(pattern string, handler BatchHandler[T], errHandler func(error)) (unsubscribe func(), err error) {
+	if handler == nil {
+		return nil, ErrNilHandler
+	}
+	return b.subscribe(pattern, nil, handler, errHandler)
+}
+
+// PublishBatch publishes events, each on the topic already set in its Topic
+// field, in order. Sequence numbers are assigned as if every event had been
+// published individually. Plain subscribers receive events one by one;
+// batch subscribers receive each run of consecutive same-topic events as a
+// single slice. Every event of a run is sequenced before the run is
+// delivered. If any topic is invalid nothing is published. PublishBatch
+// does not allocate.
+func (b *Bus[T]) PublishBatch(events []*Event[T]) error {
+	for i := 0; i < len(events); i = runEnd(events, i) {
+		if _, err := b.topic(events[i].Topic); err != nil {
+			return err
+		}
+	}
+	topics := *b.topics.Load()
+	log := b.replay.Load()
+
+	for i := 0; i < len(events); {
+		j := runEnd(events, i)
+		st := topics[events[i].Topic]
+		for _, event := range events[i:j] {
+			st.sequence(event)
+			if log != nil {
+				log.Record(event)
+			}
+		}
+		for _, sub := range *st.subs.Load() {
+			if sub.batch != nil {
+				last := !receivesAfter(topics, events, j, sub)
+				if err := sub.batch(events[i:j], last); err != nil && sub.errHandler != nil {
+					sub.errHandler(err)
+				}
+				continue
+			}
+			for _, event := range events[i:j] {
+				if err := sub.handler(event); err != nil && sub.errHandler != nil {
+					sub.errHandler(err)
+				}
+			}
+		}
+		i = j
+	}
+	return nil
+}
+
+// runEnd returns the end of the run of events sharing the topic of events[i].
+func runEnd[T any](events []*Event[T], i int) int {
+	j := i + 1
+	for j < len(events) && events[j].Topic == events[i].Topic {
+		j++
+	}
+	return j
+}
+
+// receivesAfter reports whether sub is subscribed to any topic of events[from:].
+func receivesAfter[T any](topics map[string]*topicState[T], events []*Event[T], from int, sub *subscription[T]) bool {
+	for i := from; i < len(events); i = runEnd(events, i) {
+		for _, s := range *topics[events[i].Topic].subs.Load() {
+			if s == sub {
+				return true
+			}
+		}
+	}
+	return false
+}
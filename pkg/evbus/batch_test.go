@@ -0,0 +1,141 @@
+package evbus
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func makeBatch(topics ...string) []*Event[testData] {
+	events := make([]*Event[testData], len(topics))
+	for i, topic := range topics {
+		events[i] = &Event[testData]{Data: testData{Value: i}, Topic: topic}
+	}
+	return events
+}
+
+func TestBus_PublishBatch(t *testing.T) {
+	bus := NewBus[testData]()
+
+	var runs []string
+	_, err := bus.SubscribeBatch("md.*.trades", func(events []*Event[testData], endOfBatch bool) error {
+		runs = append(runs, fmt.Sprintf("%s:%d:%t", events[0].Topic, len(events), endOfBatch))
+		return nil
+	}, nil)
+	if err != nil {
+		t.Fatalf("SubscribeBatch failed: %v", err)
+	}
+	var plain []int
+	_, _ = bus.Subscribe("md.BTC.trades", func(e *Event[testData]) error {
+		plain = append(plain, e.Data.Value)
+		return nil
+	}, nil)
+
+	events := makeBatch("md.BTC.trades", "md.BTC.trades", "md.ETH.trades", "md.BTC.book", "md.BTC.trades")
+	if err := bus.PublishBatch(events); err != nil {
+		t.Fatalf("PublishBatch failed: %v", err)
+	}
+
+	want := "[md.BTC.trades:2:false md.ETH.trades:1:false md.BTC.trades:1:true]"
+	if fmt.Sprint(runs) != want {
+		t.Errorf("Expected runs %s, got %v", want, runs)
+	}
+	if fmt.Sprint(plain) != "[0 1 4]" {
+		t.Errorf("Expected plain deliveries [0 1 4], got %v", plain)
+	}
+	if events[4].Seq != 3 || events[2].Seq != 1 || events[3].Seq != 1 {
+		t.Errorf("Unexpected sequence numbers %d %d %d", events[2].Seq, events[3].Seq, events[4].Seq)
+	}
+}
+
+func TestBus_PublishBatchSingleEvent(t *testing.T) {
+	bus := NewBus[testData]()
+	calls := 0
+	_, _ = bus.SubscribeBatch("a", func(events []*Event[testData], endOfBatch bool) error {
+		calls++
+		if len(events) != 1 || !endOfBatch {
+			t.Errorf("Expected batch of one with endOfBatch, got %d %t", len(events), endOfBatch)
+		}
+		return nil
+	}, nil)
+	publishValues(t, bus, "a", 1)
+	if calls != 1 {
+		t.Errorf("Expected 1 call, got %d", calls)
+	}
+}
+
+func TestBus_PublishBatchInvalidTopic(t *testing.T) {
+	bus := NewBus[testData]()
+	delivered := 0
+	_, _ = bus.Subscribe(">", func(*Event[testData]) error {
+		delivered++
+		return nil
+	}, nil)
+	err := bus.PublishBatch(makeBatch("a", "b.*"))
+	if !errors.Is(err, ErrInvalidTopic) {
+		t.Errorf("Expected ErrInvalidTopic, got %v", err)
+	}
+	if delivered != 0 || bus.Seq("a") != 0 {
+		t.Errorf("Expected nothing published, got %d deliveries seq %d", delivered, bus.Seq("a"))
+	}
+}
+
+func TestBus_PublishBatchZeroAllocation(t *testing.T) {
+	bus := NewBus[testData]()
+	_, _ = bus.SubscribeBatch("md.>", func([]*Event[testData], bool) error { return nil }, nil)
+	_, _ = bus.Subscribe("md.*.trades", func(*Event[testData]) error { return nil }, nil)
+	events := makeBatch("md.BTC.trades", "md.BTC.trades", "md.ETH.trades")
+	_ = bus.PublishBatch(events)
+	allocs := testing.AllocsPerRun(1000, func() {
+		_ = bus.PublishBatch(events)
+	})
+	if allocs > 0 {
+		t.Errorf("Expected zero allocations, got %.2f", allocs)
+	}
+}
+
+// BenchmarkBus_PublishBatch benchmarks a 32-event frame across 4 symbols
+func BenchmarkBus_PublishBatch(b *testing.B) {
+	bus := NewBus[testData]()
+	_, _ = bus.SubscribeBatch("md.binance.*.trades", func([]*Event[testData], bool) error { return nil }, nil)
+	topics := make([]string, 32)
+	for i := range topics {
+		topics[i] = fmt.Sprintf("md.binance.SYM%d.trades", i/8)
+	}
+	events := makeBatch(topics...)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = bus.PublishBatch(events)
+	}
+}
+
+// BenchmarkBus_PublishToBatchSubscriber benchmarks Publish delivering a batch of one
+func BenchmarkBus_PublishToBatchSubscriber(b *testing.B) {
+	bus := NewBus[testData]()
+	_, _ = bus.SubscribeBatch("a", func([]*Event[testData], bool) error { return nil }, nil)
+	event := &Event[testData]{}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = bus.Publish("a", event)
+	}
+}
+
+// BenchmarkBus_PublishEach benchmarks the same frame published event by event
+func BenchmarkBus_PublishEach(b *testing.B) {
+	bus := NewBus[testData]()
+	_, _ = bus.Subscribe("md.binance.*.trades", func(*Event[testData]) error { return nil }, nil)
+	topics := make([]string, 32)
+	for i := range topics {
+		topics[i] = fmt.Sprintf("md.binance.SYM%d.trades", i/8)
+	}
+	events := makeBatch(topics...)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, event := range events {
+			_ = bus.Publish(event.Topic, event)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package evbus
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// ErrInvalidTopic is returned for malformed topics and patterns, and when
+	// publishing on a pattern containing wildcards.
+	ErrInvalidTopic = errors.New("evbus: invalid topic")
+	// ErrNilHandler is returned when subscribing without a handler.
+	ErrNilHandler = errors.New("evbus: nil handler")
+)
+
+// Handler processes a delivered event. The event is owned by the publisher
+// and is only valid for the duration of the call; copy Data to retain it.
+type Handler[T any] func(*Event[T]) error
+
+type subscription[T any] struct {
+	id         uint64
+	pattern    string
+	handler    Handler[T]
+	batch      BatchHandler[T] // Set instead of handler by SubscribeBatch
+	errHandler func(error)
+}
+
+// topicState is created once per concrete topic and never removed, so the
+// sequence counter survives subscribers coming and going. subs caches every
+// subscription whose pattern matches the topic.
+type topicState[T any] struct {
+	seq  atomic.Uint64 // Highest sequence number assigned or received
+	subs atomic.Pointer[[]*subscription[T]]
+}
+
+// sequence assigns the next sequence number of the topic to a locally
+// created event. An event received from another process keeps its number,
+// which advances the topic to it, so Seq reflects bridged topics too.
+func (st *topicState[T]) sequence(event *Event[T]) {
+	if event.Origin == "" {
+		event.Seq = st.seq.Add(1)
+		return
+	}
+	for {
+		seq := st.seq.Load()
+		if event.Seq <= seq || st.seq.CompareAndSwap(seq, event.Seq) {
+			return
+		}
+	}
+}
+
+// Bus is an in-process, topic-keyed publish/subscribe hub for one event type.
+// Subscriptions may use wildcard patterns (see MatchTopic). Publish is
+// lock-free: the matching subscribers of each topic are resolved through a
+// trie when the topic or a subscription is added, and published as a
+// copy-on-write list that is swapped atomically, so subscribing while
+// publishing is in flight never blocks publishers.
+//
+// Every locally created event published on a topic is stamped with the next
+// per-topic sequence number. Ordering by sequence holds when each topic has a
+// single publishing goroutine.
+type Bus[T any] struct {
+	mu     sync.Mutex // serializes writers of trie, topics and subscriber lists
+	trie   topicTrie[T]
+	nextID uint64
+	topics atomic.Pointer[map[string]*topicState[T]]
+	replay atomic.Pointer[ReplayLog[T]]
+	single sync.Pool // *[1]*Event[T], lends Publish a batch of one without allocating
+}
+
+// NewBus creates an empty bus.
+func NewBus[T any]() *Bus[T] {
+	b := &Bus[T]{}
+	b.single.New = func() any { return new([1]*Event[T]) }
+	empty := make(map[string]*topicState[T])
+	b.topics.Store(&empty)
+	return b
+}
+
+// SetReplayLog makes the bus record every published event into log, which
+// enables Replay and Recover. Pass nil to stop recording.
+func (b *Bus[T]) SetReplayLog(log *ReplayLog[T]) {
+	b.replay.Store(log)
+}
+
+// topic returns the state for the concrete topic name, creating it if needed.
+func (b *Bus[T]) topic(name string) (*topicState[T], error) {
+	if st, ok := (*b.topics.Load())[name]; ok {
+		return st, nil
+	}
+	if !ValidTopic(name) {
+		return nil, ErrInvalidTopic
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	old := *b.topics.Load()
+	if st, ok := old[name]; ok {
+		return st, nil
+	}
+	st := &topicState[T]{}
+	subs := b.trie.match(name)
+	st.subs.Store(&subs)
+	next := make(map[string]*topicState[T], len(old)+1)
+	for k, v := range old {
+		next[k] = v
+	}
+	next[name] = st
+	b.topics.Store(&next)
+	return st, nil
+}
+
+// refresh re-resolves the subscribers of every known topic matching pattern.
+// Must be called with b.mu held.
+func (b *Bus[T]) refresh(pattern string) {
+	for name, st := range *b.topics.Load() {
+		if MatchTopic(pattern, name) {
+			subs := b.trie.match(name)
+			st.subs.Store(&subs)
+		}
+	}
+}
+
+// Subscribe registers handler for events published on every topic matching
+// pattern, which may be a concrete topic or contain wildcards. Errors
+// returned by handler are passed to errHandler when it is non-nil.
+func (b *Bus[T]) Subscribe(pattern string, handler Handler[T], errHandler func(error)) (unsubscribe func(), err error) {
+	if handler == nil {
+		return nil, ErrNilHandler
+	}
+	return b.subscribe(pattern, handler, nil, errHandler)
+}
+
+func (b *Bus[T]) subscribe(pattern string, handler Handler[T], batch BatchHandler[T], errHandler func(error)) (unsubscribe func(), err error) {
+	if !ValidPattern(pattern) {
+		return nil, ErrInvalidTopic
+	}
+
+	b.mu.Lock()
+	b.nextID++
+	sub := &subscription[T]{id: b.nextID, pattern: pattern, handler: handler, batch: batch, errHandler: errHandler}
+	b.trie.insert(sub)
+	b.refresh(pattern)
+	b.mu.Unlock()
+
+	var once sync.Once
+	return func() { once.Do(func() { b.remove(sub) }) }, nil
+}
+
+func (b *Bus[T]) remove(sub *subscription[T]) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trie.remove(sub)
+	b.refresh(sub.pattern)
+}
+
+// Publish delivers event synchronously to every subscriber of topic and sets
+// event.Topic. Locally created events (empty Origin) are assigned the next
+// sequence number of topic; events received from another process keep the
+// sequence number assigned by their origin, and advance the topic to it.
+// The caller keeps ownership of event once Publish returns.
+func (b *Bus[T]) Publish(topic string, event *Event[T]) error {
+	_, err := b.publish(topic, event)
+	return err
+}
+
+// publish is Publish, also returning the errors of the handlers joined, so
+// a bridge can tell whether the event was handled.
+func (b *Bus[T]) publish(topic string, event *Event[T]) (handlerErr error, err error) {
+	st, err := b.topic(topic)
+	if err != nil {
+		return nil, err
+	}
+	event.Topic = topic
+	st.sequence(event)
+	if log := b.replay.Load(); log != nil {
+		log.Record(event)
+	}
+	for _, sub := range *st.subs.Load() {
+		var err error
+		if sub.batch != nil {
+			one := b.single.Get().(*[1]*Event[T])
+			one[0] = event
+			err = sub.batch(one[:], true)
+			one[0] = nil
+			b.single.Put(one)
+		} else {
+			err = sub.handler(event)
+		}
+		if err != nil {
+			handlerErr = errors.Join(handlerErr, err)
+			if sub.errHandler != nil {
+				sub.errHandler(err)
+			}
+		}
+	}
+	return handlerErr, nil
+}
+
+// HasSubscribers reports whether any subscription matches the concrete topic.
+func (b *Bus[T]) HasSubscribers(topic string) bool {
+	if st, ok := (*b.topics.Load())[topic]; ok {
+		return len(*st.subs.Load()) > 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.trie.match(topic)) > 0
+}
+
+// Seq returns the last sequence number assigned on topic, or received from
+// another process if higher (0 if none).
+func (b *Bus[T]) Seq(topic string) uint64 {
+	if st, ok := (*b.topics.Load())[topic]; ok {
+		return st.seq.Load()
+	}
+	return 0
+}
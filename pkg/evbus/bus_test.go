@@ -0,0 +1,94 @@
+package evbus
+
+import (
+	"errors"
+	"testing"
+)
+
+type testData struct {
+	Value int
+}
+
+func TestBus_PublishSubscribe(t *testing.T) {
+	bus := NewBus[testData]()
+	factory := NewEventFactory[testData](nil)
+
+	var got []int
+	unsubscribe, err := bus.Subscribe("a", func(e *Event[testData]) error {
+		if e.Topic != "a" {
+			t.Errorf("Expected topic 'a', got '%s'", e.Topic)
+		}
+		got = append(got, e.Data.Value)
+		return nil
+	}, nil)
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+
+	for i := 1; i <= 3; i++ {
+		event := factory.GetEvent()
+		event.Data.Value = i
+		if err := bus.Publish("a", event); err != nil {
+			t.Fatalf("Publish failed: %v", err)
+		}
+		if err := bus.Publish("b", event); err != nil {
+			t.Fatalf("Publish failed: %v", err)
+		}
+		factory.PutEvent(event)
+	}
+	if len(got) != 3 || got[0] != 1 || got[2] != 3 {
+		t.Errorf("Expected [1 2 3], got %v", got)
+	}
+
+	unsubscribe()
+	unsubscribe()
+	if bus.HasSubscribers("a") {
+		t.Error("Expected no subscribers after unsubscribe")
+	}
+	event := factory.GetEvent()
+	_ = bus.Publish("a", event)
+	if len(got) != 3 {
+		t.Errorf("Expected no delivery after unsubscribe, got %v", got)
+	}
+}
+
+func TestBus_ErrorHandler(t *testing.T) {
+	bus := NewBus[testData]()
+	boom := errors.New("boom")
+
+	var reported error
+	_, err := bus.Subscribe("a", func(*Event[testData]) error { return boom }, func(err error) { reported = err })
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	_ = bus.Publish("a", &Event[testData]{})
+	if !errors.Is(reported, boom) {
+		t.Errorf("Expected handler error to be reported, got %v", reported)
+	}
+}
+
+func TestBus_InvalidArguments(t *testing.T) {
+	bus := NewBus[testData]()
+	if _, err := bus.Subscribe("", func(*Event[testData]) error { return nil }, nil); !errors.Is(err, ErrInvalidTopic) {
+		t.Errorf("Expected ErrInvalidTopic, got %v", err)
+	}
+	if _, err := bus.Subscribe("a", nil, nil); !errors.Is(err, ErrNilHandler) {
+		t.Errorf("Expected ErrNilHandler, got %v", err)
+	}
+	if err := bus.Publish("", &Event[testData]{}); !errors.Is(err, ErrInvalidTopic) {
+		t.Errorf("Expected ErrInvalidTopic, got %v", err)
+	}
+}
+
+// BenchmarkBus_Publish benchmarks synchronous delivery to a single subscriber
+func BenchmarkBus_Publish(b *testing.B) {
+	bus := NewBus[testData]()
+	factory := NewEventFactory[testData](nil)
+	_, _ = bus.Subscribe("a", func(*Event[testData]) error { return nil }, nil)
+	event := factory.GetEvent()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = bus.Publish("a", event)
+	}
+}
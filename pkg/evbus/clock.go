@@ -0,0 +1,112 @@
+package evbus
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Clock supplies timestamps for events. Implementations must be safe for
+// concurrent use.
+type Clock interface {
+	Now() time.Time
+}
+
+// WallClock reads the system wall clock (UTC).
+type WallClock struct{}
+
+func (WallClock) Now() time.Time { return time.Now().UTC() }
+
+// DefaultClock is used by components created without an explicit clock.
+var DefaultClock Clock = WallClock{}
+
+// MonotonicClock reports wall time anchored once at creation and advanced by
+// the monotonic clock, so readings never go backwards when the system clock
+// is stepped (e.g. by NTP).
+type MonotonicClock struct {
+	start time.Time // carries the monotonic reading
+	base  time.Time // wall time at start, UTC
+}
+
+// NewMonotonicClock creates a monotonic clock anchored at the current time.
+func NewMonotonicClock() *MonotonicClock {
+	start := time.Now()
+	return &MonotonicClock{start: start, base: start.UTC()}
+}
+
+func (c *MonotonicClock) Now() time.Time {
+	return c.base.Add(time.Since(c.start))
+}
+
+// CoarseClock caches the time of an underlying clock and refreshes it every
+// resolution from a background goroutine. Now is a single atomic load, which
+// suits hot paths that can tolerate timestamps up to one resolution stale.
+type CoarseClock struct {
+	source Clock
+	nanos  atomic.Int64
+	stop   chan struct{}
+	once   sync.Once
+}
+
+// NewCoarseClock starts a coarse clock over source (DefaultClock if nil).
+// Call Stop to release its goroutine.
+func NewCoarseClock(source Clock, resolution time.Duration) *CoarseClock {
+	if source == nil {
+		source = DefaultClock
+	}
+	if resolution <= 0 {
+		resolution = time.Millisecond
+	}
+	c := &CoarseClock{source: source, stop: make(chan struct{})}
+	c.nanos.Store(source.Now().UnixNano())
+	go c.run(resolution)
+	return c
+}
+
+func (c *CoarseClock) run(resolution time.Duration) {
+	ticker := time.NewTicker(resolution)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.nanos.Store(c.source.Now().UnixNano())
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+func (c *CoarseClock) Now() time.Time {
+	return time.Unix(0, c.nanos.Load()).UTC()
+}
+
+// Stop halts background refreshes; Now keeps returning the last value.
+func (c *CoarseClock) Stop() {
+	c.once.Do(func() { close(c.stop) })
+}
+
+// ManualClock only moves when told to, for backtests, replays and tests.
+type ManualClock struct {
+	nanos atomic.Int64
+}
+
+// NewManualClock creates a manual clock set to t.
+func NewManualClock(t time.Time) *ManualClock {
+	c := &ManualClock{}
+	c.Set(t)
+	return c
+}
+
+func (c *ManualClock) Now() time.Time {
+	return time.Unix(0, c.nanos.Load()).UTC()
+}
+
+// Set moves the clock to t, which may be earlier than the current time.
+func (c *ManualClock) Set(t time.Time) {
+	c.nanos.Store(t.UnixNano())
+}
+
+// Advance moves the clock forward by d and returns the new time.
+func (c *ManualClock) Advance(d time.Duration) time.Time {
+	return time.Unix(0, c.nanos.Add(int64(d))).UTC()
+}
@@ -0,0 +1,93 @@
+package evbus
+
+import (
+	"testing"
+	"time"
+)
+
+func TestManualClock(t *testing.T) {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	clock := NewManualClock(start)
+	if !clock.Now().Equal(start) {
+		t.Errorf("Expected %v, got %v", start, clock.Now())
+	}
+	if got := clock.Advance(time.Second); !got.Equal(start.Add(time.Second)) {
+		t.Errorf("Expected %v after Advance, got %v", start.Add(time.Second), got)
+	}
+	clock.Set(start)
+	if !clock.Now().Equal(start) {
+		t.Errorf("Expected %v after Set, got %v", start, clock.Now())
+	}
+}
+
+func TestEventFactory_UsesClock(t *testing.T) {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	clock := NewManualClock(start)
+	factory := NewEventFactoryWithClock[testData](clock, nil)
+
+	event := factory.GetEvent()
+	if !event.CreatedAt.Equal(start) || !event.UpdatedAt.Equal(start) {
+		t.Errorf("Expected timestamps %v, got %v / %v", start, event.CreatedAt, event.UpdatedAt)
+	}
+	factory.PutEvent(event)
+
+	clock.Advance(time.Minute)
+	event = factory.GetEvent()
+	if !event.CreatedAt.Equal(start.Add(time.Minute)) {
+		t.Errorf("Expected %v, got %v", start.Add(time.Minute), event.CreatedAt)
+	}
+}
+
+func TestMonotonicClock_NeverGoesBackwards(t *testing.T) {
+	clock := NewMonotonicClock()
+	prev := clock.Now()
+	for i := 0; i < 1000; i++ {
+		now := clock.Now()
+		if now.Before(prev) {
+			t.Fatalf("Clock went backwards: %v after %v", now, prev)
+		}
+		prev = now
+	}
+	if d := time.Since(prev); d < -time.Second || d > time.Second {
+		t.Errorf("Expected monotonic clock close to wall time, off by %v", d)
+	}
+}
+
+func TestCoarseClock_Refreshes(t *testing.T) {
+	source := NewManualClock(time.Unix(100, 0))
+	clock := NewCoarseClock(source, time.Millisecond)
+	defer clock.Stop()
+
+	source.Advance(time.Second)
+	deadline := time.Now().Add(time.Second)
+	for !clock.Now().Equal(time.Unix(101, 0)) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Coarse clock did not refresh, still at %v", clock.Now())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	clock.Stop()
+	clock.Stop()
+}
+
+// BenchmarkEventFactory_WallClock benchmarks GetEvent/PutEvent stamped by the wall clock
+func BenchmarkEventFactory_WallClock(b *testing.B) {
+	factory := NewEventFactory[testData](nil)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		factory.PutEvent(factory.GetEvent())
+	}
+}
+
+// BenchmarkEventFactory_CoarseClock benchmarks GetEvent/PutEvent stamped by a cached clock
+func BenchmarkEventFactory_CoarseClock(b *testing.B) {
+	clock := NewCoarseClock(nil, time.Millisecond)
+	defer clock.Stop()
+	factory := NewEventFactoryWithClock[testData](clock, nil)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		factory.PutEvent(factory.GetEvent())
+	}
+}
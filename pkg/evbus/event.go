@@ -11,6 +11,9 @@ import (
 type Event[T any] struct {
 	Data      T // Embedded value, pooled together with Event
 	EventID   int64
+	Seq       uint64 // Per-topic sequence number, set by Bus.Publish
+	Topic     string // Set by Bus.Publish
+	Origin    string // Non-empty when the event was received from another process
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
@@ -21,25 +24,42 @@ type EventFactory[T any] struct {
 	nextEventID atomic.Int64
 	eventPool   sync.Pool
 	resetFn     func(*T) // Reset function for Data cleanup
+	clock       Clock    // Source of CreatedAt/UpdatedAt
 }
 
-// NewEventFactory creates a new lock-free event factory.
+// NewEventFactory creates a new lock-free event factory stamping events
+// with DefaultClock.
 // resetFn is called on Data when returning event to pool.
 func NewEventFactory[T any](resetFn func(*T)) *EventFactory[T] {
+	return NewEventFactoryWithClock(DefaultClock, resetFn)
+}
+
+// NewEventFactoryWithClock creates an event factory stamping events with
+// clock, e.g. a ManualClock driven by a backtest or replay.
+func NewEventFactoryWithClock[T any](clock Clock, resetFn func(*T)) *EventFactory[T] {
+	if clock == nil {
+		clock = DefaultClock
+	}
 	return &EventFactory[T]{
 		eventPool: sync.Pool{
 			New: func() any { return new(Event[T]) },
 		},
 		resetFn: resetFn,
+		clock:   clock,
 	}
 }
 
+// Clock returns the clock used to stamp events.
+func (f *EventFactory[T]) Clock() Clock {
+	return f.clock
+}
+
 // GetEvent retrieves a pooled event (lock-free).
 // Data is zero-valued; set fields directly on event.Data.
 func (f *EventFactory[T]) GetEvent() *Event[T] {
 	event := f.eventPool.Get().(*Event[T])
 	event.EventID = f.nextEventID.Add(1)
-	now := time.Now().UTC()
+	now := f.clock.Now()
 	event.CreatedAt = now
 	event.UpdatedAt = now
 	return event
@@ -52,6 +72,9 @@ func (f *EventFactory[T]) PutEvent(event *Event[T]) {
 		f.resetFn(&event.Data)
 	}
 	event.EventID = 0
+	event.Seq = 0
+	event.Topic = ""
+	event.Origin = ""
 	event.CreatedAt = time.Time{}
 	event.UpdatedAt = time.Time{}
 	f.eventPool.Put(event)
@@ -0,0 +1,351 @@
+package evbus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Stream entry field names written by RedisBridge.
+const (
+	fieldEventID   = "event_id"
+	fieldSeq       = "seq"
+	fieldOrigin    = "origin"
+	fieldCreatedAt = "created_at"
+	fieldUpdatedAt = "updated_at"
+	fieldData      = "data"
+)
+
+const (
+	defaultStreamPrefix  = "evbus:"
+	defaultBlockTimeout  = time.Second
+	defaultReadCount     = 128
+	defaultForwardBuffer = 1024
+	defaultWriteTimeout  = 5 * time.Second
+)
+
+// ErrForwardQueueFull is reported for events Forward drops because Redis
+// is not keeping up.
+var ErrForwardQueueFull = errors.New("evbus: forward queue full")
+
+// Codec serializes event payloads for transports that leave the process.
+type Codec[T any] interface {
+	Marshal(v *T) ([]byte, error)
+	Unmarshal(data []byte, v *T) error
+}
+
+// JSONCodec encodes payloads with encoding/json.
+type JSONCodec[T any] struct{}
+
+func (JSONCodec[T]) Marshal(v *T) ([]byte, error) { return json.Marshal(v) }
+
+func (JSONCodec[T]) Unmarshal(data []byte, v *T) error { return json.Unmarshal(data, v) }
+
+// RedisBridgeOptions configures a RedisBridge.
+type RedisBridgeOptions struct {
+	StreamPrefix string        // Prefix for stream keys (default "evbus:")
+	Origin       string        // Identifies this process; its own entries are never re-published locally
+	MaxLen       int64         // Approximate stream length cap for XADD (0 = unbounded)
+	StartID      string        // Where a newly created consumer group starts (default "$")
+	BlockTimeout time.Duration // XREADGROUP block time (default 1s)
+	ReadCount    int64         // Max entries per XREADGROUP call (default 128)
+
+	ForwardBuffer int           // Events each Forward call queues for Redis before dropping new ones (default 1024)
+	WriteTimeout  time.Duration // Timeout of each XADD (default 5s); the client must have ContextTimeoutEnabled to cut off a hung socket
+}
+
+// RedisBridge forwards bus topics to Redis Streams and consumes them back
+// through consumer groups, so events can cross process boundaries.
+type RedisBridge[T any] struct {
+	client  redis.UniversalClient
+	bus     *Bus[T]
+	codec   Codec[T]
+	factory *EventFactory[T]
+	opts    RedisBridgeOptions
+}
+
+// NewRedisBridge creates a bridge between bus and Redis.
+// A nil codec defaults to JSONCodec.
+func NewRedisBridge[T any](client redis.UniversalClient, bus *Bus[T], codec Codec[T], opts RedisBridgeOptions) *RedisBridge[T] {
+	if codec == nil {
+		codec = JSONCodec[T]{}
+	}
+	if opts.StreamPrefix == "" {
+		opts.StreamPrefix = defaultStreamPrefix
+	}
+	if opts.StartID == "" {
+		opts.StartID = "$"
+	}
+	if opts.BlockTimeout <= 0 {
+		opts.BlockTimeout = defaultBlockTimeout
+	}
+	if opts.ReadCount <= 0 {
+		opts.ReadCount = defaultReadCount
+	}
+	if opts.ForwardBuffer <= 0 {
+		opts.ForwardBuffer = defaultForwardBuffer
+	}
+	if opts.WriteTimeout <= 0 {
+		opts.WriteTimeout = defaultWriteTimeout
+	}
+	return &RedisBridge[T]{
+		client: client,
+		bus:    bus,
+		codec:  codec,
+		factory: NewEventFactory(func(d *T) {
+			var zero T
+			*d = zero
+		}),
+		opts: opts,
+	}
+}
+
+// StreamKey returns the Redis stream key used for topic.
+func (r *RedisBridge[T]) StreamKey(topic string) string {
+	return r.opts.StreamPrefix + topic
+}
+
+// Forward appends every event published locally on topics to its stream.
+// topics may contain wildcard patterns; each concrete topic gets its own
+// stream. Events that were themselves received from Redis are not forwarded
+// again.
+//
+// Publishers never wait for Redis: events are encoded and queued, and a
+// single goroutine appends them in publish order. When the queue is full,
+// new events are dropped and ErrForwardQueueFull is passed to errHandler,
+// as are failed appends. stop unsubscribes and waits for the queued events
+// to be written.
+func (r *RedisBridge[T]) Forward(topics []string, errHandler func(error)) (stop func(), err error) {
+	queue := make(chan *redis.XAddArgs, r.opts.ForwardBuffer)
+	quit := make(chan struct{})
+	done := make(chan struct{})
+	go r.write(queue, quit, done, errHandler)
+
+	var once sync.Once
+	unsubscribes := make([]func(), 0, len(topics))
+	stop = func() {
+		once.Do(func() {
+			for _, unsubscribe := range unsubscribes {
+				unsubscribe()
+			}
+			close(quit)
+			<-done
+		})
+	}
+	forward := func(event *Event[T]) error {
+		return r.enqueue(queue, event)
+	}
+	for _, topic := range topics {
+		unsubscribe, err := r.bus.Subscribe(topic, forward, errHandler)
+		if err != nil {
+			stop()
+			return nil, err
+		}
+		unsubscribes = append(unsubscribes, unsubscribe)
+	}
+	return stop, nil
+}
+
+// enqueue encodes event for XADD and queues it without blocking. The queue
+// is never closed, so a publish racing stop cannot panic.
+func (r *RedisBridge[T]) enqueue(queue chan<- *redis.XAddArgs, event *Event[T]) error {
+	if event.Origin != "" {
+		return nil
+	}
+	data, err := r.codec.Marshal(&event.Data)
+	if err != nil {
+		return fmt.Errorf("evbus: encode %s: %w", event.Topic, err)
+	}
+	args := &redis.XAddArgs{
+		Stream: r.StreamKey(event.Topic),
+		Values: []any{
+			fieldEventID, event.EventID,
+			fieldSeq, event.Seq,
+			fieldOrigin, r.opts.Origin,
+			fieldCreatedAt, event.CreatedAt.UnixNano(),
+			fieldUpdatedAt, event.UpdatedAt.UnixNano(),
+			fieldData, data,
+		},
+	}
+	if r.opts.MaxLen > 0 {
+		args.MaxLen = r.opts.MaxLen
+		args.Approx = true
+	}
+	select {
+	case queue <- args:
+		return nil
+	default:
+		return fmt.Errorf("%w: dropped %s seq %d", ErrForwardQueueFull, event.Topic, event.Seq)
+	}
+}
+
+// write appends queued entries until quit is closed, then appends what is
+// left in the queue and closes done.
+func (r *RedisBridge[T]) write(queue <-chan *redis.XAddArgs, quit <-chan struct{}, done chan<- struct{}, errHandler func(error)) {
+	defer close(done)
+	for {
+		select {
+		case args := <-queue:
+			r.xadd(args, errHandler)
+		case <-quit:
+			for {
+				select {
+				case args := <-queue:
+					r.xadd(args, errHandler)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (r *RedisBridge[T]) xadd(args *redis.XAddArgs, errHandler func(error)) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.opts.WriteTimeout)
+	defer cancel()
+	if err := r.client.XAdd(ctx, args).Err(); err != nil && errHandler != nil {
+		errHandler(fmt.Errorf("evbus: xadd %s: %w", args.Stream, err))
+	}
+}
+
+// Consume reads topics' streams as consumer within group and publishes each
+// entry on the local bus, acknowledging it once local handlers have run
+// without error. Entries keep the sequence number assigned by the producing
+// bus, so a GapDetector on the consuming side sees the producer's ordering.
+// Entries left pending by a previous run of the same consumer, including
+// those a handler failed on, are delivered first. Handler and decoding
+// errors are passed to errHandler; entries that fail to decode are acked,
+// since retrying cannot help. Consume blocks until ctx is canceled.
+//
+// Each topic is read from its own stream, so topics must be concrete:
+// wildcard patterns fail with ErrInvalidTopic.
+func (r *RedisBridge[T]) Consume(ctx context.Context, group, consumer string, topics []string, errHandler func(error)) error {
+	if len(topics) == 0 {
+		return ErrInvalidTopic
+	}
+	for _, topic := range topics {
+		if !ValidTopic(topic) {
+			return fmt.Errorf("%w: %q: Consume needs concrete topics", ErrInvalidTopic, topic)
+		}
+	}
+	streams := make([]string, 0, 2*len(topics))
+	for _, topic := range topics {
+		key := r.StreamKey(topic)
+		err := r.client.XGroupCreateMkStream(ctx, key, group, r.opts.StartID).Err()
+		if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+			return fmt.Errorf("evbus: create group %s on %s: %w", group, key, err)
+		}
+		streams = append(streams, key)
+	}
+	ids := make([]string, len(topics))
+
+	// Drain our own pending entries before reading new ones. The pending
+	// pass reads on from the last entry seen on each stream, so entries
+	// that fail again stay pending without being read twice.
+	pending := true
+	for i := range ids {
+		ids[i] = "0"
+	}
+	for ctx.Err() == nil {
+		block := r.opts.BlockTimeout
+		if pending {
+			block = -1
+		} else {
+			for i := range ids {
+				ids[i] = ">"
+			}
+		}
+		res, err := r.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    group,
+			Consumer: consumer,
+			Streams:  append(streams, ids...),
+			Count:    r.opts.ReadCount,
+			Block:    block,
+		}).Result()
+		if err != nil {
+			if errors.Is(err, redis.Nil) {
+				pending = false
+				continue
+			}
+			if ctx.Err() != nil {
+				break
+			}
+			return fmt.Errorf("evbus: xreadgroup %s: %w", group, err)
+		}
+
+		delivered := 0
+		for _, stream := range res {
+			topic := strings.TrimPrefix(stream.Stream, r.opts.StreamPrefix)
+			for _, msg := range stream.Messages {
+				delivered++
+				if pending {
+					ids[slices.Index(streams, stream.Stream)] = msg.ID
+				}
+				ack, err := r.deliver(topic, msg)
+				if err != nil && errHandler != nil {
+					errHandler(err)
+				}
+				if !ack {
+					continue
+				}
+				if err := r.client.XAck(ctx, stream.Stream, group, msg.ID).Err(); err != nil && ctx.Err() == nil {
+					return fmt.Errorf("evbus: xack %s %s: %w", stream.Stream, msg.ID, err)
+				}
+			}
+		}
+		if pending && delivered == 0 {
+			pending = false
+		}
+	}
+	return nil
+}
+
+// deliver publishes msg on the local bus and reports whether it may be
+// acked: every entry but those a handler failed on.
+func (r *RedisBridge[T]) deliver(topic string, msg redis.XMessage) (ack bool, err error) {
+	origin, _ := msg.Values[fieldOrigin].(string)
+	if r.opts.Origin != "" && origin == r.opts.Origin {
+		return true, nil
+	}
+	// A deleted entry still pending in the group comes back without fields.
+	data, ok := msg.Values[fieldData].(string)
+	if !ok {
+		return true, nil
+	}
+
+	event := r.factory.GetEvent()
+	defer r.factory.PutEvent(event)
+	if err := r.codec.Unmarshal([]byte(data), &event.Data); err != nil {
+		return true, fmt.Errorf("evbus: decode %s %s: %w", topic, msg.ID, err)
+	}
+	if origin == "" {
+		origin = "redis"
+	}
+	event.Origin = origin
+	event.EventID = parseInt(msg.Values[fieldEventID])
+	event.Seq = uint64(parseInt(msg.Values[fieldSeq]))
+	event.CreatedAt = time.Unix(0, parseInt(msg.Values[fieldCreatedAt])).UTC()
+	event.UpdatedAt = time.Unix(0, parseInt(msg.Values[fieldUpdatedAt])).UTC()
+	handlerErr, err := r.bus.publish(topic, event)
+	if err != nil {
+		return true, fmt.Errorf("evbus: publish %s %s: %w", topic, msg.ID, err)
+	}
+	if handlerErr != nil {
+		return false, fmt.Errorf("evbus: handle %s %s: %w", topic, msg.ID, handlerErr)
+	}
+	return true, nil
+}
+
+func parseInt(v any) int64 {
+	s, _ := v.(string)
+	n, _ := strconv.ParseInt(s, 10, 64)
+	return n
+}
@@ -0,0 +1,576 @@
+package evbus
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// respServer is an in-memory stand-in for the subset of Redis stream
+// commands used by RedisBridge.
+type respServer struct {
+	ln      net.Listener
+	mu      sync.Mutex
+	streams map[string]*respStream
+}
+
+type respEntry struct {
+	id     string
+	fields []string
+}
+
+type respStream struct {
+	entries []respEntry
+	groups  map[string]*respGroup
+	nextID  int
+}
+
+type respGroup struct {
+	delivered int               // index of the next entry to deliver with ">"
+	pending   map[string]string // entry ID -> consumer
+}
+
+func newRESPServer(t *testing.T) *respServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	s := &respServer{ln: ln, streams: make(map[string]*respStream)}
+	go s.serve()
+	t.Cleanup(func() { ln.Close() })
+	return s
+}
+
+func (s *respServer) Addr() string { return s.ln.Addr().String() }
+
+func (s *respServer) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *respServer) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		s.dispatch(w, args)
+		if err := w.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[0] != '*' {
+		return nil, fmt.Errorf("unexpected line %q", line)
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+	args := make([]string, n)
+	for i := range args {
+		header, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, _ := strconv.Atoi(strings.TrimSpace(header[1:]))
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func (s *respServer) dispatch(w *bufio.Writer, args []string) {
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		fmt.Fprint(w, "+PONG\r\n")
+	case "XADD":
+		s.xadd(w, args)
+	case "XGROUP":
+		s.xgroup(w, args)
+	case "XREADGROUP":
+		s.xreadgroup(w, args)
+	case "XACK":
+		s.xack(w, args)
+	default:
+		fmt.Fprintf(w, "-ERR unknown command '%s'\r\n", args[0])
+	}
+}
+
+func (s *respServer) stream(key string) *respStream {
+	st, ok := s.streams[key]
+	if !ok {
+		st = &respStream{groups: make(map[string]*respGroup)}
+		s.streams[key] = st
+	}
+	return st
+}
+
+func (s *respServer) xadd(w *bufio.Writer, args []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := s.stream(args[1])
+	i := 2
+	if strings.EqualFold(args[i], "MAXLEN") {
+		i++
+		if args[i] == "~" || args[i] == "=" {
+			i++
+		}
+		i++
+	}
+	st.nextID++
+	entry := respEntry{id: fmt.Sprintf("%d-0", st.nextID), fields: args[i+1:]}
+	st.entries = append(st.entries, entry)
+	writeBulk(w, entry.id)
+}
+
+func (s *respServer) xgroup(w *bufio.Writer, args []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key, group, start := args[2], args[3], args[4]
+	st := s.stream(key)
+	if _, ok := st.groups[group]; ok {
+		fmt.Fprint(w, "-BUSYGROUP Consumer Group name already exists\r\n")
+		return
+	}
+	g := &respGroup{pending: make(map[string]string)}
+	if start == "$" {
+		g.delivered = len(st.entries)
+	}
+	st.groups[group] = g
+	fmt.Fprint(w, "+OK\r\n")
+}
+
+func (s *respServer) xreadgroup(w *bufio.Writer, args []string) {
+	group, consumer := args[2], args[3]
+	count, block := -1, -1
+	i := 4
+	for ; !strings.EqualFold(args[i], "STREAMS"); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "COUNT":
+			count, _ = strconv.Atoi(args[i+1])
+			i++
+		case "BLOCK":
+			block, _ = strconv.Atoi(args[i+1])
+			i++
+		}
+	}
+	rest := args[i+1:]
+	keys, ids := rest[:len(rest)/2], rest[len(rest)/2:]
+
+	deadline := time.Now().Add(time.Duration(block) * time.Millisecond)
+	for {
+		s.mu.Lock()
+		var out []string
+		found := 0
+		for k, key := range keys {
+			st := s.stream(key)
+			g, ok := st.groups[group]
+			if !ok {
+				s.mu.Unlock()
+				fmt.Fprint(w, "-NOGROUP No such key or consumer group\r\n")
+				return
+			}
+			var msgs []respEntry
+			if ids[k] == ">" {
+				for g.delivered < len(st.entries) && (count < 0 || len(msgs) < count) {
+					entry := st.entries[g.delivered]
+					g.pending[entry.id] = consumer
+					g.delivered++
+					msgs = append(msgs, entry)
+				}
+			} else {
+				for _, entry := range st.entries {
+					if g.pending[entry.id] == consumer && entryAfter(entry.id, ids[k]) && (count < 0 || len(msgs) < count) {
+						msgs = append(msgs, entry)
+					}
+				}
+			}
+			found += len(msgs)
+			out = append(out, encodeStream(key, msgs))
+		}
+		s.mu.Unlock()
+
+		if found > 0 || ids[0] != ">" {
+			fmt.Fprintf(w, "*%d\r\n%s", len(out), strings.Join(out, ""))
+			return
+		}
+		if block < 0 || (block > 0 && time.Now().After(deadline)) {
+			fmt.Fprint(w, "*-1\r\n")
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func (s *respServer) xack(w *bufio.Writer, args []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g, ok := s.stream(args[1]).groups[args[2]]
+	acked := 0
+	if ok {
+		for _, id := range args[3:] {
+			if _, ok := g.pending[id]; ok {
+				delete(g.pending, id)
+				acked++
+			}
+		}
+	}
+	fmt.Fprintf(w, ":%d\r\n", acked)
+}
+
+func (s *respServer) pending(key, group string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.stream(key).groups[group].pending)
+}
+
+func (s *respServer) length(key string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.stream(key).entries)
+}
+
+// entryAfter reports whether the "<n>-0" ID id comes after after.
+func entryAfter(id, after string) bool {
+	n, _ := strconv.Atoi(strings.TrimSuffix(id, "-0"))
+	m, _ := strconv.Atoi(strings.TrimSuffix(after, "-0"))
+	return n > m
+}
+
+func encodeStream(key string, msgs []respEntry) string {
+	var b strings.Builder
+	b.WriteString("*2\r\n")
+	fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(key), key)
+	fmt.Fprintf(&b, "*%d\r\n", len(msgs))
+	for _, m := range msgs {
+		b.WriteString("*2\r\n")
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(m.id), m.id)
+		fmt.Fprintf(&b, "*%d\r\n", len(m.fields))
+		for _, f := range m.fields {
+			fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(f), f)
+		}
+	}
+	return b.String()
+}
+
+func writeBulk(w *bufio.Writer, s string) {
+	fmt.Fprintf(w, "$%d\r\n%s\r\n", len(s), s)
+}
+
+func newTestClient(t *testing.T, srv *respServer) *redis.Client {
+	client := redis.NewClient(&redis.Options{Addr: srv.Addr(), Protocol: 2, DisableIdentity: true})
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestRedisBridge_ForwardAndConsume(t *testing.T) {
+	srv := newRESPServer(t)
+	opts := RedisBridgeOptions{BlockTimeout: 20 * time.Millisecond}
+
+	// Producer process
+	producerOpts := opts
+	producerOpts.Origin = "ems"
+	producerBus := NewBus[testData]()
+	producer := NewRedisBridge(newTestClient(t, srv), producerBus, nil, producerOpts)
+	stop, err := producer.Forward([]string{"orders.1.fills"}, func(err error) { t.Errorf("forward: %v", err) })
+	if err != nil {
+		t.Fatalf("Forward failed: %v", err)
+	}
+	defer stop()
+
+	// Consumer process
+	consumerOpts := opts
+	consumerOpts.Origin = "risk"
+	consumerOpts.StartID = "0"
+	consumerBus := NewBus[testData]()
+	consumer := NewRedisBridge(newTestClient(t, srv), consumerBus, nil, consumerOpts)
+	received := make(chan Event[testData], 8)
+	_, _ = consumerBus.Subscribe("orders.1.fills", func(e *Event[testData]) error {
+		received <- *e
+		return nil
+	}, nil)
+	// Re-forwarding on the consumer side must not echo events back to Redis.
+	stopEcho, _ := consumer.Forward([]string{"orders.1.fills"}, nil)
+	defer stopEcho()
+
+	factory := NewEventFactory[testData](nil)
+	event := factory.GetEvent()
+	event.Data.Value = 42
+	if err := producerBus.Publish("orders.1.fills", event); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- consumer.Consume(ctx, "risk", "risk-1", []string{"orders.1.fills"}, func(err error) { t.Errorf("consume: %v", err) })
+	}()
+
+	select {
+	case got := <-received:
+		if got.Data.Value != 42 {
+			t.Errorf("Expected value 42, got %d", got.Data.Value)
+		}
+		if got.EventID != event.EventID {
+			t.Errorf("Expected event ID %d, got %d", event.EventID, got.EventID)
+		}
+		if got.Origin != "ems" {
+			t.Errorf("Expected origin 'ems', got '%s'", got.Origin)
+		}
+		if !got.CreatedAt.Equal(event.CreatedAt) {
+			t.Errorf("Expected CreatedAt %v, got %v", event.CreatedAt, got.CreatedAt)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for bridged event")
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Consume returned error: %v", err)
+	}
+	key := consumer.StreamKey("orders.1.fills")
+	if n := srv.pending(key, "risk"); n != 0 {
+		t.Errorf("Expected all entries acked, %d pending", n)
+	}
+	if n := srv.length(key); n != 1 {
+		t.Errorf("Expected 1 stream entry (no echo), got %d", n)
+	}
+}
+
+func TestRedisBridge_Recover(t *testing.T) {
+	srv := newRESPServer(t)
+	opts := RedisBridgeOptions{BlockTimeout: 20 * time.Millisecond, StartID: "0"}
+	producerBus := NewBus[testData]()
+	producerOpts := opts
+	producerOpts.Origin = "md"
+	stop, err := NewRedisBridge(newTestClient(t, srv), producerBus, nil, producerOpts).Forward([]string{"md.trades"}, nil)
+	if err != nil {
+		t.Fatalf("Forward failed: %v", err)
+	}
+	defer stop()
+	publishValues(t, producerBus, "md.trades", 1, 2, 3)
+
+	consumerBus := NewBus[testData]()
+	consumerBus.SetReplayLog(NewReplayLog[testData](16))
+	received := make(chan struct{}, 8)
+	_, _ = consumerBus.Subscribe("md.trades", func(*Event[testData]) error {
+		received <- struct{}{}
+		return nil
+	}, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	consumer := NewRedisBridge(newTestClient(t, srv), consumerBus, nil, opts)
+	go consumer.Consume(ctx, "strat", "strat-1", []string{"md.trades"}, nil)
+	for i := 0; i < 3; i++ {
+		select {
+		case <-received:
+		case <-time.After(2 * time.Second):
+			t.Fatal("Timed out waiting for bridged events")
+		}
+	}
+	if seq := consumerBus.Seq("md.trades"); seq != 3 {
+		t.Fatalf("Expected bridged events to advance the sequence to 3, got %d", seq)
+	}
+
+	// The snapshot is as of seq 1; the rest must come from the replay log
+	// without waiting for another live event.
+	var got []uint64
+	var gaps []Gap
+	unsubscribe, err := Recover(consumerBus, "md.trades", func() (uint64, error) { return 1, nil }, func(e *Event[testData]) error {
+		got = append(got, e.Seq)
+		return nil
+	}, func(g Gap) { gaps = append(gaps, g) }, nil)
+	if err != nil {
+		t.Fatalf("Recover failed: %v", err)
+	}
+	defer unsubscribe()
+	if fmt.Sprint(got) != "[2 3]" || len(gaps) != 0 {
+		t.Errorf("Expected events [2 3] without gaps, got %v (gaps %v)", got, gaps)
+	}
+}
+
+func TestRedisBridge_RedeliversPending(t *testing.T) {
+	srv := newRESPServer(t)
+	client := newTestClient(t, srv)
+	bridge := NewRedisBridge(client, NewBus[testData](), nil, RedisBridgeOptions{BlockTimeout: 20 * time.Millisecond})
+	key := bridge.StreamKey("md.trades")
+	ctx := context.Background()
+
+	if err := client.XGroupCreateMkStream(ctx, key, "g", "$").Err(); err != nil {
+		t.Fatalf("XGroupCreateMkStream failed: %v", err)
+	}
+	if err := client.XAdd(ctx, &redis.XAddArgs{Stream: key, Values: []any{fieldData, `{"Value":7}`}}).Err(); err != nil {
+		t.Fatalf("XAdd failed: %v", err)
+	}
+	// Simulate a crash after reading but before acking.
+	if err := client.XReadGroup(ctx, &redis.XReadGroupArgs{Group: "g", Consumer: "c", Streams: []string{key, ">"}, Block: -1}).Err(); err != nil {
+		t.Fatalf("XReadGroup failed: %v", err)
+	}
+
+	got := make(chan int, 1)
+	_, _ = bridge.bus.Subscribe("md.trades", func(e *Event[testData]) error {
+		got <- e.Data.Value
+		return nil
+	}, nil)
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() { _ = bridge.Consume(runCtx, "g", "c", []string{"md.trades"}, nil) }()
+
+	select {
+	case v := <-got:
+		if v != 7 {
+			t.Errorf("Expected value 7, got %d", v)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for pending entry")
+	}
+}
+
+func TestRedisBridge_KeepsFailedEntriesPending(t *testing.T) {
+	srv := newRESPServer(t)
+	client := newTestClient(t, srv)
+	bridge := NewRedisBridge(client, NewBus[testData](), nil, RedisBridgeOptions{BlockTimeout: 20 * time.Millisecond})
+	key := bridge.StreamKey("md.trades")
+	ctx := context.Background()
+
+	if err := client.XGroupCreateMkStream(ctx, key, "g", "0").Err(); err != nil {
+		t.Fatalf("XGroupCreateMkStream failed: %v", err)
+	}
+	for _, data := range []string{`{"Value":1}`, `{"Value":2}`, `not json`} {
+		if err := client.XAdd(ctx, &redis.XAddArgs{Stream: key, Values: []any{fieldData, data}}).Err(); err != nil {
+			t.Fatalf("XAdd failed: %v", err)
+		}
+	}
+
+	// The handler fails on value 1 until it is fixed.
+	var mu sync.Mutex
+	fixed := false
+	var handled []int
+	_, _ = bridge.bus.Subscribe("md.trades", func(e *Event[testData]) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if e.Data.Value == 1 && !fixed {
+			return fmt.Errorf("handler failed")
+		}
+		handled = append(handled, e.Data.Value)
+		return nil
+	}, nil)
+	consume := func() int {
+		runCtx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+		defer cancel()
+		errs := 0
+		_ = bridge.Consume(runCtx, "g", "c", []string{"md.trades"}, func(error) { errs++ })
+		return errs
+	}
+
+	if errs := consume(); errs != 2 {
+		t.Errorf("Expected the handler and decoding errors to be reported, got %d", errs)
+	}
+	if n := srv.pending(key, "g"); n != 1 {
+		t.Fatalf("Expected the failed entry to stay pending, got %d pending", n)
+	}
+	mu.Lock()
+	fixed = true
+	mu.Unlock()
+	if errs := consume(); errs != 0 {
+		t.Errorf("Expected the retry to succeed, got %d errors", errs)
+	}
+	if n := srv.pending(key, "g"); n != 0 {
+		t.Errorf("Expected the retried entry to be acked, got %d pending", n)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if fmt.Sprint(handled) != "[2 1]" {
+		t.Errorf("Expected 2 then the retried 1, got %v", handled)
+	}
+}
+
+func TestRedisBridge_ForwardDoesNotBlockPublish(t *testing.T) {
+	// A Redis that accepts connections but never answers.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+	client := redis.NewClient(&redis.Options{Addr: ln.Addr().String(), Protocol: 2, DisableIdentity: true,
+		ReadTimeout: -1, ContextTimeoutEnabled: true})
+	defer client.Close()
+
+	bus := NewBus[testData]()
+	bridge := NewRedisBridge(client, bus, nil, RedisBridgeOptions{ForwardBuffer: 1, WriteTimeout: 50 * time.Millisecond})
+	var mu sync.Mutex
+	var errs []error
+	stop, err := bridge.Forward([]string{"orders.>"}, func(err error) {
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
+	})
+	if err != nil {
+		t.Fatalf("Forward failed: %v", err)
+	}
+
+	start := time.Now()
+	publishValues(t, bus, "orders.1.fills", 1, 2, 3, 4)
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("Expected Publish not to wait for Redis, took %v", elapsed)
+	}
+	stop()
+
+	mu.Lock()
+	defer mu.Unlock()
+	dropped, timedOut := 0, 0
+	for _, err := range errs {
+		switch {
+		case errors.Is(err, ErrForwardQueueFull):
+			dropped++
+		case errors.Is(err, context.DeadlineExceeded):
+			timedOut++
+		}
+	}
+	if dropped == 0 || timedOut == 0 || dropped+timedOut != 4 {
+		t.Errorf("Expected dropped and timed out appends for all 4 events, got %v", errs)
+	}
+}
+
+func TestRedisBridge_ConsumeRejectsPatterns(t *testing.T) {
+	bridge := NewRedisBridge(newTestClient(t, newRESPServer(t)), NewBus[testData](), nil, RedisBridgeOptions{})
+	for _, topics := range [][]string{{"orders.*.fills"}, {"md.trades", "orders.>"}} {
+		if err := bridge.Consume(context.Background(), "g", "c", topics, nil); !errors.Is(err, ErrInvalidTopic) {
+			t.Errorf("Expected ErrInvalidTopic for %v, got %v", topics, err)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package evbus
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrReplayUnavailable is returned when a requested range is no longer (or
+// was never) held by the replay log.
+var ErrReplayUnavailable = errors.New("evbus: replay range unavailable")
+
+// ReplayLog retains the most recent events of every topic so consumers can
+// request retransmission of sequence ranges they missed. Events are stored
+// by value: Data is copied shallowly, so T should not share mutable memory
+// with pooled events.
+type ReplayLog[T any] struct {
+	mu       sync.RWMutex
+	capacity int
+	rings    map[string][]Event[T]
+}
+
+// NewReplayLog creates a log that keeps the last capacity events per topic.
+func NewReplayLog[T any](capacity int) *ReplayLog[T] {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &ReplayLog[T]{capacity: capacity, rings: make(map[string][]Event[T])}
+}
+
+// Record stores a copy of event, which must have Topic and Seq set.
+func (l *ReplayLog[T]) Record(event *Event[T]) {
+	if event.Seq == 0 {
+		return
+	}
+	l.mu.Lock()
+	ring, ok := l.rings[event.Topic]
+	if !ok {
+		ring = make([]Event[T], l.capacity)
+		l.rings[event.Topic] = ring
+	}
+	ring[event.Seq%uint64(l.capacity)] = *event
+	l.mu.Unlock()
+}
+
+// Range calls fn with events from..to (inclusive) of topic in order. It
+// fails with ErrReplayUnavailable without calling fn when any event in the
+// range has been evicted.
+func (l *ReplayLog[T]) Range(topic string, from, to uint64, fn func(*Event[T]) error) error {
+	if from == 0 || to < from {
+		return fmt.Errorf("%w: %s [%d, %d]", ErrReplayUnavailable, topic, from, to)
+	}
+	if to-from >= uint64(l.capacity) {
+		return fmt.Errorf("%w: %s [%d, %d] exceeds capacity %d", ErrReplayUnavailable, topic, from, to, l.capacity)
+	}
+
+	l.mu.RLock()
+	ring := l.rings[topic]
+	events := make([]Event[T], 0, to-from+1)
+	for seq := from; seq <= to && ring != nil; seq++ {
+		event := ring[seq%uint64(l.capacity)]
+		if event.Seq != seq {
+			break
+		}
+		events = append(events, event)
+	}
+	l.mu.RUnlock()
+
+	if uint64(len(events)) != to-from+1 {
+		return fmt.Errorf("%w: %s [%d, %d]", ErrReplayUnavailable, topic, from, to)
+	}
+	for i := range events {
+		if err := fn(&events[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Replay retransmits events from..to of topic from the bus replay log.
+func (b *Bus[T]) Replay(topic string, from, to uint64, fn func(*Event[T]) error) error {
+	log := b.replay.Load()
+	if log == nil {
+		return fmt.Errorf("%w: no replay log", ErrReplayUnavailable)
+	}
+	return log.Range(topic, from, to, fn)
+}
+
+// Gap describes sequence numbers a consumer did not receive on a topic.
+type Gap struct {
+	Topic     string
+	From      uint64 // First missing sequence number
+	To        uint64 // Last missing sequence number
+	Recovered bool   // Whether the missing events were retransmitted
+	Err       error  // Why retransmission failed, if it did
+}
+
+func (g Gap) String() string {
+	return fmt.Sprintf("gap on %s [%d, %d] recovered=%t", g.Topic, g.From, g.To, g.Recovered)
+}
+
+// Retransmitter re-delivers a range of events, e.g. Bus.Replay.
+type Retransmitter[T any] func(topic string, from, to uint64, fn func(*Event[T]) error) error
+
+// GapDetector wraps a handler and tracks the last sequence number seen per
+// topic. Duplicates and stale events are dropped. When a gap is found it is
+// reported to onGap and, if retransmit is set, the missing events are
+// requested and delivered to the handler before the current one.
+type GapDetector[T any] struct {
+	mu         sync.Mutex
+	last       map[string]uint64
+	handler    Handler[T]
+	onGap      func(Gap)
+	retransmit Retransmitter[T]
+}
+
+// NewGapDetector creates a detector delivering in-order events to handler.
+// onGap and retransmit may be nil.
+func NewGapDetector[T any](handler Handler[T], onGap func(Gap), retransmit Retransmitter[T]) *GapDetector[T] {
+	return &GapDetector[T]{
+		last:       make(map[string]uint64),
+		handler:    handler,
+		onGap:      onGap,
+		retransmit: retransmit,
+	}
+}
+
+// Handle is a Handler that can be passed to Bus.Subscribe.
+func (d *GapDetector[T]) Handle(event *Event[T]) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.handleLocked(event)
+}
+
+func (d *GapDetector[T]) handleLocked(event *Event[T]) error {
+	last, seen := d.last[event.Topic]
+	switch {
+	case event.Seq == 0:
+		// Unsequenced events pass through untouched.
+		return d.handler(event)
+	case seen && event.Seq <= last:
+		return nil
+	case seen && event.Seq > last+1:
+		gap := Gap{Topic: event.Topic, From: last + 1, To: event.Seq - 1}
+		if d.retransmit != nil {
+			gap.Err = d.retransmit(event.Topic, gap.From, gap.To, func(missed *Event[T]) error {
+				d.last[event.Topic] = missed.Seq
+				return d.handler(missed)
+			})
+			gap.Recovered = gap.Err == nil
+		} else {
+			gap.Err = ErrReplayUnavailable
+		}
+		if d.onGap != nil {
+			d.onGap(gap)
+		}
+	}
+	d.last[event.Topic] = event.Seq
+	return d.handler(event)
+}
+
+// Last returns the last sequence number delivered on topic.
+func (d *GapDetector[T]) Last(topic string) uint64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.last[topic]
+}
+
+// Reset sets the last delivered sequence number of topic, e.g. after
+// loading a snapshot taken at seq.
+func (d *GapDetector[T]) Reset(topic string, seq uint64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.last[topic] = seq
+}
+
+// Recover subscribes a late joiner to topic using snapshot+delta recovery.
+// snapshot loads the consumer's state and returns the sequence number it
+// reflects; every later event is then delivered to handler exactly once and
+// in order: first from the replay log, then live. Live events published
+// while the snapshot is loading are buffered. Gaps that cannot be filled
+// from the replay log are reported to onGap as soon as they are found,
+// including a catch-up range the log no longer holds.
+func Recover[T any](bus *Bus[T], topic string, snapshot func() (uint64, error), handler Handler[T], onGap func(Gap), errHandler func(error)) (unsubscribe func(), err error) {
+	detector := NewGapDetector(handler, onGap, bus.Replay)
+
+	var (
+		mu      sync.Mutex
+		ready   bool
+		pending []Event[T]
+	)
+	unsubscribe, err = bus.Subscribe(topic, func(event *Event[T]) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if !ready {
+			pending = append(pending, *event)
+			return nil
+		}
+		return detector.Handle(event)
+	}, errHandler)
+	if err != nil {
+		return nil, err
+	}
+
+	seq, err := snapshot()
+	if err != nil {
+		unsubscribe()
+		return nil, fmt.Errorf("evbus: snapshot %s: %w", topic, err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	detector.Reset(topic, seq)
+	// Catch up on everything published up to now; later events are either
+	// buffered or will arrive live, and duplicates are dropped by the detector.
+	if latest := bus.Seq(topic); latest > seq {
+		err = bus.Replay(topic, seq+1, latest, detector.Handle)
+		if err != nil && !errors.Is(err, ErrReplayUnavailable) {
+			unsubscribe()
+			return nil, err
+		}
+		if err != nil {
+			// The buffered events still cover the end of the range, so only
+			// what came before them is lost.
+			to := latest
+			for i := range pending {
+				if pending[i].Seq != 0 {
+					to = min(to, pending[i].Seq-1)
+					break
+				}
+			}
+			if to > seq {
+				if onGap != nil {
+					onGap(Gap{Topic: topic, From: seq + 1, To: to, Err: err})
+				}
+				detector.Reset(topic, to)
+			}
+		}
+	}
+	for i := range pending {
+		if err := detector.Handle(&pending[i]); err != nil && errHandler != nil {
+			errHandler(err)
+		}
+	}
+	pending = nil
+	ready = true
+	return unsubscribe, nil
+}
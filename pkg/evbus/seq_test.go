@@ -0,0 +1,179 @@
+package evbus
+
+import (
+	"errors"
+	"testing"
+)
+
+func publishValues(t *testing.T, bus *Bus[testData], topic string, values ...int) {
+	t.Helper()
+	factory := NewEventFactory[testData](nil)
+	for _, v := range values {
+		event := factory.GetEvent()
+		event.Data.Value = v
+		if err := bus.Publish(topic, event); err != nil {
+			t.Fatalf("Publish failed: %v", err)
+		}
+		factory.PutEvent(event)
+	}
+}
+
+func TestBus_PerTopicSequence(t *testing.T) {
+	bus := NewBus[testData]()
+	var seqs []uint64
+	_, _ = bus.Subscribe("a", func(e *Event[testData]) error {
+		seqs = append(seqs, e.Seq)
+		return nil
+	}, nil)
+
+	publishValues(t, bus, "a", 1, 2)
+	publishValues(t, bus, "b", 1)
+	publishValues(t, bus, "a", 3)
+
+	if len(seqs) != 3 || seqs[0] != 1 || seqs[1] != 2 || seqs[2] != 3 {
+		t.Errorf("Expected sequence [1 2 3] on topic a, got %v", seqs)
+	}
+	if bus.Seq("b") != 1 {
+		t.Errorf("Expected topic b at seq 1, got %d", bus.Seq("b"))
+	}
+
+	// Remote events keep their origin's sequence number and advance the
+	// topic to it, but never move it back.
+	remote := &Event[testData]{Origin: "peer", Seq: 100}
+	_ = bus.Publish("a", remote)
+	if remote.Seq != 100 || bus.Seq("a") != 100 {
+		t.Errorf("Expected remote seq preserved, got event %d bus %d", remote.Seq, bus.Seq("a"))
+	}
+	_ = bus.Publish("a", &Event[testData]{Origin: "peer", Seq: 50})
+	if bus.Seq("a") != 100 {
+		t.Errorf("Expected an older remote event to leave seq at 100, got %d", bus.Seq("a"))
+	}
+}
+
+func TestGapDetector_Retransmit(t *testing.T) {
+	bus := NewBus[testData]()
+	bus.SetReplayLog(NewReplayLog[testData](16))
+	publishValues(t, bus, "a", 10, 20, 30, 40)
+
+	var got []int
+	var gaps []Gap
+	detector := NewGapDetector(func(e *Event[testData]) error {
+		got = append(got, e.Data.Value)
+		return nil
+	}, func(g Gap) { gaps = append(gaps, g) }, bus.Replay)
+
+	// Receive 1, miss 2 and 3, receive 4, then a duplicate of 3.
+	_ = bus.Replay("a", 1, 1, detector.Handle)
+	_ = bus.Replay("a", 4, 4, detector.Handle)
+	_ = bus.Replay("a", 3, 3, detector.Handle)
+
+	if len(got) != 4 || got[1] != 20 || got[2] != 30 || got[3] != 40 {
+		t.Errorf("Expected [10 20 30 40], got %v", got)
+	}
+	if len(gaps) != 1 || gaps[0].From != 2 || gaps[0].To != 3 || !gaps[0].Recovered {
+		t.Errorf("Expected one recovered gap [2, 3], got %v", gaps)
+	}
+	if detector.Last("a") != 4 {
+		t.Errorf("Expected last seq 4, got %d", detector.Last("a"))
+	}
+}
+
+func TestGapDetector_Unrecoverable(t *testing.T) {
+	bus := NewBus[testData]()
+	bus.SetReplayLog(NewReplayLog[testData](2))
+	publishValues(t, bus, "a", 1, 2, 3, 4, 5)
+
+	var gaps []Gap
+	var got []int
+	detector := NewGapDetector(func(e *Event[testData]) error {
+		got = append(got, e.Data.Value)
+		return nil
+	}, func(g Gap) { gaps = append(gaps, g) }, bus.Replay)
+	detector.Reset("a", 1)
+	_ = bus.Replay("a", 5, 5, detector.Handle)
+
+	if len(gaps) != 1 || gaps[0].Recovered || !errors.Is(gaps[0].Err, ErrReplayUnavailable) {
+		t.Errorf("Expected one unrecovered gap, got %v", gaps)
+	}
+	if len(got) != 1 || got[0] != 5 {
+		t.Errorf("Expected delivery to resume at 5, got %v", got)
+	}
+}
+
+func TestRecover_SnapshotAndDelta(t *testing.T) {
+	bus := NewBus[testData]()
+	bus.SetReplayLog(NewReplayLog[testData](64))
+	publishValues(t, bus, "a", 1, 2, 3, 4, 5)
+
+	var got []uint64
+	var gaps []Gap
+	_, err := Recover(bus, "a", func() (uint64, error) {
+		// State as of seq 3; one more event is published while loading.
+		publishValues(t, bus, "a", 6)
+		return 3, nil
+	}, func(e *Event[testData]) error {
+		got = append(got, e.Seq)
+		return nil
+	}, func(g Gap) { gaps = append(gaps, g) }, nil)
+	if err != nil {
+		t.Fatalf("Recover failed: %v", err)
+	}
+	publishValues(t, bus, "a", 7)
+
+	want := []uint64{4, 5, 6, 7}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Expected %v, got %v", want, got)
+			break
+		}
+	}
+	if len(gaps) != 0 {
+		t.Errorf("Expected no gaps, got %v", gaps)
+	}
+}
+
+func TestRecover_ReplayUnavailable(t *testing.T) {
+	bus := NewBus[testData]()
+	bus.SetReplayLog(NewReplayLog[testData](2))
+	publishValues(t, bus, "a", 1, 2, 3, 4, 5)
+
+	var got []uint64
+	var gaps []Gap
+	_, err := Recover(bus, "a", func() (uint64, error) {
+		publishValues(t, bus, "a", 6)
+		return 1, nil
+	}, func(e *Event[testData]) error {
+		got = append(got, e.Seq)
+		return nil
+	}, func(g Gap) { gaps = append(gaps, g) }, nil)
+	if err != nil {
+		t.Fatalf("Recover failed: %v", err)
+	}
+
+	// The backlog 2..6 exceeds the log; 6 was buffered while loading.
+	if len(gaps) != 1 || gaps[0].From != 2 || gaps[0].To != 5 || gaps[0].Recovered || !errors.Is(gaps[0].Err, ErrReplayUnavailable) {
+		t.Fatalf("Expected one unrecovered gap [2, 5] at once, got %v", gaps)
+	}
+	publishValues(t, bus, "a", 7)
+	if len(got) != 2 || got[0] != 6 || got[1] != 7 {
+		t.Errorf("Expected delivery to resume at 6, got %v", got)
+	}
+	if len(gaps) != 1 {
+		t.Errorf("Expected the gap to be reported once, got %v", gaps)
+	}
+}
+
+func TestRecover_SnapshotError(t *testing.T) {
+	bus := NewBus[testData]()
+	boom := errors.New("boom")
+	_, err := Recover(bus, "a", func() (uint64, error) { return 0, boom }, func(*Event[testData]) error { return nil }, nil, nil)
+	if !errors.Is(err, boom) {
+		t.Errorf("Expected snapshot error, got %v", err)
+	}
+	if bus.HasSubscribers("a") {
+		t.Error("Expected subscription to be removed on failure")
+	}
+}
@@ -0,0 +1,146 @@
+package evbus
+
+import (
+	"sort"
+	"strings"
+)
+
+// Topics are hierarchical, with tokens separated by '.', e.g.
+// "orders.42.fills" or "md.binance.BTCUSDT.trades". Subscription patterns
+// may use wildcard tokens: "*" matches exactly one token
+// ("md.binance.*.trades") and ">", allowed only as the last token, matches
+// one or more trailing tokens ("orders.42.>").
+const (
+	TopicSeparator  = "."
+	WildcardOne     = "*"
+	WildcardTrailer = ">"
+)
+
+// ValidTopic reports whether topic is a concrete topic that can be published:
+// non-empty tokens and no wildcards.
+func ValidTopic(topic string) bool {
+	if topic == "" {
+		return false
+	}
+	for _, token := range strings.Split(topic, TopicSeparator) {
+		if token == "" || token == WildcardOne || token == WildcardTrailer {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidPattern reports whether pattern is a valid subscription pattern.
+func ValidPattern(pattern string) bool {
+	if pattern == "" {
+		return false
+	}
+	tokens := strings.Split(pattern, TopicSeparator)
+	for i, token := range tokens {
+		if token == "" || (token == WildcardTrailer && i != len(tokens)-1) {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchTopic reports whether the concrete topic matches pattern.
+func MatchTopic(pattern, topic string) bool {
+	p := strings.Split(pattern, TopicSeparator)
+	t := strings.Split(topic, TopicSeparator)
+	for i, token := range p {
+		switch {
+		case token == WildcardTrailer:
+			return len(t) > i
+		case i >= len(t):
+			return false
+		case token != WildcardOne && token != t[i]:
+			return false
+		}
+	}
+	return len(p) == len(t)
+}
+
+// topicTrie indexes subscriptions by pattern token. It is only read and
+// written while holding Bus.mu; publishers never touch it.
+type topicTrie[T any] struct {
+	root trieNode[T]
+}
+
+type trieNode[T any] struct {
+	children map[string]*trieNode[T] // literal tokens and '*'
+	subs     []*subscription[T]      // patterns ending at this node
+	trailer  []*subscription[T]      // patterns ending with '>' below this node
+}
+
+func (tr *topicTrie[T]) insert(sub *subscription[T]) {
+	node := &tr.root
+	tokens := strings.Split(sub.pattern, TopicSeparator)
+	for _, token := range tokens {
+		if token == WildcardTrailer {
+			node.trailer = append(node.trailer, sub)
+			return
+		}
+		if node.children == nil {
+			node.children = make(map[string]*trieNode[T])
+		}
+		child, ok := node.children[token]
+		if !ok {
+			child = &trieNode[T]{}
+			node.children[token] = child
+		}
+		node = child
+	}
+	node.subs = append(node.subs, sub)
+}
+
+func (tr *topicTrie[T]) remove(sub *subscription[T]) {
+	tr.root.remove(strings.Split(sub.pattern, TopicSeparator), sub)
+}
+
+// remove deletes sub and reports whether node became empty.
+func (n *trieNode[T]) remove(tokens []string, sub *subscription[T]) bool {
+	switch {
+	case len(tokens) == 0:
+		n.subs = without(n.subs, sub)
+	case tokens[0] == WildcardTrailer:
+		n.trailer = without(n.trailer, sub)
+	default:
+		if child, ok := n.children[tokens[0]]; ok && child.remove(tokens[1:], sub) {
+			delete(n.children, tokens[0])
+		}
+	}
+	return len(n.subs) == 0 && len(n.trailer) == 0 && len(n.children) == 0
+}
+
+func without[T any](subs []*subscription[T], sub *subscription[T]) []*subscription[T] {
+	for i, s := range subs {
+		if s == sub {
+			return append(subs[:i:i], subs[i+1:]...)
+		}
+	}
+	return subs
+}
+
+// match returns every subscription whose pattern matches topic, in
+// subscription order.
+func (tr *topicTrie[T]) match(topic string) []*subscription[T] {
+	var out []*subscription[T]
+	tr.root.match(strings.Split(topic, TopicSeparator), &out)
+	sort.Slice(out, func(i, j int) bool { return out[i].id < out[j].id })
+	return out
+}
+
+func (n *trieNode[T]) match(tokens []string, out *[]*subscription[T]) {
+	if len(tokens) == 0 {
+		*out = append(*out, n.subs...)
+		return
+	}
+	*out = append(*out, n.trailer...)
+	if child, ok := n.children[tokens[0]]; ok {
+		child.match(tokens[1:], out)
+	}
+	if child, ok := n.children[WildcardOne]; ok {
+		child.match(tokens[1:], out)
+	}
+}
@@ -0,0 +1,178 @@
+package evbus
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestMatchTopic(t *testing.T) {
+	tests := []struct {
+		pattern string
+		topic   string
+		want    bool
+	}{
+		{"orders.1.fills", "orders.1.fills", true},
+		{"orders.1.fills", "orders.2.fills", false},
+		{"orders.*.fills", "orders.2.fills", true},
+		{"orders.*.fills", "orders.2.updates", false},
+		{"orders.1.*", "orders.1.fills", true},
+		{"orders.1.*", "orders.1", false},
+		{"orders.1.*", "orders.1.fills.extra", false},
+		{"orders.>", "orders.1.fills", true},
+		{"orders.>", "orders", false},
+		{"md.binance.*.trades", "md.binance.BTCUSDT.trades", true},
+		{"md.*.*.trades", "md.okx.ETH-USDT.trades", true},
+		{">", "anything.at.all", true},
+	}
+	for _, tt := range tests {
+		if got := MatchTopic(tt.pattern, tt.topic); got != tt.want {
+			t.Errorf("MatchTopic(%q, %q) = %t, want %t", tt.pattern, tt.topic, got, tt.want)
+		}
+	}
+}
+
+func TestValidTopicAndPattern(t *testing.T) {
+	for _, topic := range []string{"", "a..b", "a.*", "a.>", ".a"} {
+		if ValidTopic(topic) {
+			t.Errorf("Expected topic %q to be invalid", topic)
+		}
+	}
+	for _, pattern := range []string{"", "a.>.b", "a..b"} {
+		if ValidPattern(pattern) {
+			t.Errorf("Expected pattern %q to be invalid", pattern)
+		}
+	}
+	bus := NewBus[testData]()
+	if err := bus.Publish("orders.*.fills", &Event[testData]{}); !errors.Is(err, ErrInvalidTopic) {
+		t.Errorf("Expected ErrInvalidTopic publishing on a pattern, got %v", err)
+	}
+}
+
+func TestBus_WildcardSubscriptions(t *testing.T) {
+	bus := NewBus[testData]()
+	counts := map[string]int{}
+	subscribe := func(pattern string) func() {
+		unsubscribe, err := bus.Subscribe(pattern, func(*Event[testData]) error {
+			counts[pattern]++
+			return nil
+		}, nil)
+		if err != nil {
+			t.Fatalf("Subscribe(%q) failed: %v", pattern, err)
+		}
+		return unsubscribe
+	}
+
+	// Topic known before the wildcard subscription is added.
+	publishValues(t, bus, "orders.1.fills", 0)
+	subscribe("orders.1.*")
+	unsubscribeAll := subscribe("orders.>")
+	subscribe("orders.*.fills")
+
+	publishValues(t, bus, "orders.1.fills", 1)
+	publishValues(t, bus, "orders.1.updates", 2)
+	publishValues(t, bus, "orders.2.fills", 3)
+
+	want := map[string]int{"orders.1.*": 2, "orders.>": 3, "orders.*.fills": 2}
+	for pattern, n := range want {
+		if counts[pattern] != n {
+			t.Errorf("Expected %d deliveries for %q, got %d", n, pattern, counts[pattern])
+		}
+	}
+
+	unsubscribeAll()
+	publishValues(t, bus, "orders.3.updates", 4)
+	if counts["orders.>"] != 3 {
+		t.Errorf("Expected no deliveries after unsubscribe, got %d", counts["orders.>"])
+	}
+	if bus.HasSubscribers("orders.3.updates") {
+		t.Error("Expected no subscribers for orders.3.updates")
+	}
+	if !bus.HasSubscribers("orders.9.fills") {
+		t.Error("Expected wildcard subscriber for orders.9.fills")
+	}
+}
+
+func TestBus_DeliveryOrderFollowsSubscriptionOrder(t *testing.T) {
+	bus := NewBus[testData]()
+	var order []string
+	for _, pattern := range []string{"a.>", "a.b", "*.b", "a.*"} {
+		pattern := pattern
+		_, _ = bus.Subscribe(pattern, func(*Event[testData]) error {
+			order = append(order, pattern)
+			return nil
+		}, nil)
+	}
+	publishValues(t, bus, "a.b", 1)
+	if fmt.Sprint(order) != "[a.> a.b *.b a.*]" {
+		t.Errorf("Unexpected delivery order %v", order)
+	}
+}
+
+func TestBus_SubscribeWhilePublishing(t *testing.T) {
+	bus := NewBus[testData]()
+	var delivered atomic.Int64
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+
+	for p := 0; p < 4; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			event := &Event[testData]{}
+			topic := fmt.Sprintf("md.venue%d.BTCUSDT.trades", p)
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					_ = bus.Publish(topic, event)
+				}
+			}
+		}(p)
+	}
+	for i := 0; i < 200; i++ {
+		unsubscribe, err := bus.Subscribe("md.*.BTCUSDT.trades", func(*Event[testData]) error {
+			delivered.Add(1)
+			return nil
+		}, nil)
+		if err != nil {
+			t.Fatalf("Subscribe failed: %v", err)
+		}
+		unsubscribe()
+	}
+	close(stop)
+	wg.Wait()
+	if bus.HasSubscribers("md.venue0.BTCUSDT.trades") {
+		t.Error("Expected all subscriptions to be removed")
+	}
+}
+
+func TestBus_PublishZeroAllocation(t *testing.T) {
+	bus := NewBus[testData]()
+	_, _ = bus.Subscribe("md.*.trades", func(*Event[testData]) error { return nil }, nil)
+	event := &Event[testData]{}
+	_ = bus.Publish("md.binance.trades", event)
+	allocs := testing.AllocsPerRun(1000, func() {
+		_ = bus.Publish("md.binance.trades", event)
+	})
+	if allocs > 0 {
+		t.Errorf("Expected zero allocations, got %.2f", allocs)
+	}
+}
+
+// BenchmarkBus_PublishWildcard benchmarks delivery to wildcard subscribers
+func BenchmarkBus_PublishWildcard(b *testing.B) {
+	bus := NewBus[testData]()
+	for _, pattern := range []string{"md.>", "md.*.*.trades", "md.binance.*.trades"} {
+		_, _ = bus.Subscribe(pattern, func(*Event[testData]) error { return nil }, nil)
+	}
+	event := &Event[testData]{}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = bus.Publish("md.binance.BTCUSDT.trades", event)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"sync"
 
 	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
 	"gopkg.in/natefinch/lumberjack.v2"
 )
 
@@ -30,11 +31,12 @@ const (
 
 // Options contains configuration options for the logger
 type Options struct {
-	Level          string // Log level: trace, debug, info, warn, error, fatal, panic
-	Output         string // Output type: "stdout" or "file"
-	Path           string // Log file path (required when Output is "file")
-	MaxByteSize    int    // Max size in bytes before rotation (0 = no rotation)
-	MaxBackupFiles int    // Max number of backup files to keep (0 = keep all)
+	Level          string   // Log level: trace, debug, info, warn, error, fatal, panic
+	Output         string   // Output type: "stdout" or "file"
+	Path           string   // Log file path (required when Output is "file")
+	MaxByteSize    int      // Max size in bytes before rotation (0 = no rotation)
+	MaxBackupFiles int      // Max number of backup files to keep (0 = keep all)
+	RedactPatterns []string // Regular expressions scrubbed from output in addition to DefaultRedactPatterns
 }
 
 var (
@@ -72,7 +74,7 @@ func init() {
 
 	// Initialize console logger with human-friendly output
 	// Using the package-level consoleWriter pointer to reduce heap allocations
-	consoleLogger = zerolog.New(consoleWriter).
+	consoleLogger = zerolog.New(mustRedactWriter(consoleWriter)).
 		With().
 		Timestamp().
 		Caller().
@@ -86,6 +88,8 @@ func init() {
 
 	// Initialize global logger to console by default
 	globalLogger = consoleLogger
+	// Packages logging through zerolog's log package are redacted too
+	log.Logger = consoleLogger
 }
 
 // parseLogLevel parses a string log level and returns the corresponding zerolog.Level
@@ -158,12 +162,19 @@ func Init(opts Options) error {
 		output = "stdout"
 	}
 
+	// Scrub credentials from everything written
+	patterns := append(append([]string{}, DefaultRedactPatterns...), opts.RedactPatterns...)
+	rules, err := compileRedactRules(patterns)
+	if err != nil {
+		return err
+	}
+
 	var writer io.Writer
 
 	switch output {
 	case "stdout", "console":
 		// Use console writer for stdout
-		writer = consoleWriter
+		writer = &RedactWriter{out: consoleWriter, rules: rules}
 	case "file":
 		// Validate that path is provided
 		path := opts.Path
@@ -181,7 +192,7 @@ func Init(opts Options) error {
 			return err
 		}
 
-		writer = fileWriter
+		writer = &RedactWriter{out: fileWriter, rules: rules}
 		logFile = path
 
 		// Also update the file logger for backward compatibility
@@ -196,7 +207,7 @@ func Init(opts Options) error {
 		fileLoggerMutex.Unlock()
 	default:
 		// Unknown output type, default to stdout
-		writer = consoleWriter
+		writer = &RedactWriter{out: consoleWriter, rules: rules}
 	}
 
 	// Create global logger with the selected writer
@@ -206,6 +217,7 @@ func Init(opts Options) error {
 		Caller().
 		Logger().
 		Level(level)
+	log.Logger = globalLogger
 
 	globalLoggerInitialized = true
 	return nil
@@ -248,7 +260,7 @@ func initFileLogger() {
 		return
 	}
 
-	fileLogger = zerolog.New(file).
+	fileLogger = zerolog.New(mustRedactWriter(file)).
 		With().
 		Timestamp().
 		Caller().
@@ -286,7 +298,7 @@ func SetLogFile(path string) error {
 			return err
 		}
 
-		fileLogger = zerolog.New(file).
+		fileLogger = zerolog.New(mustRedactWriter(file)).
 			With().
 			Timestamp().
 			Caller().